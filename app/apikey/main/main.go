@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/subosito/gotenv"
+
+	"github.com/prabudzak/article/logger"
+	"github.com/prabudzak/article/model"
+	"github.com/prabudzak/article/service/auth"
+	authdb "github.com/prabudzak/article/service/auth/mysql"
+	"github.com/prabudzak/article/service/policy"
+)
+
+func main() {
+	gotenv.Load()
+
+	name := flag.String("name", "", "name of the API key owner")
+	role := flag.String("role", model.RoleAuthor, "role of the API key, author, editor or admin")
+	authorID := flag.Int("author-id", 0, "id of the author an author API key writes as")
+	flag.Parse()
+
+	if *name == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	logLevel, err := logger.ParseLevel(os.Getenv("LOG_LEVEL"))
+	if err != nil {
+		logger.Default().Fatal("log level invalid", logger.Err(err))
+	}
+	log := logger.New(os.Stderr, logLevel)
+	logger.SetDefault(log)
+
+	sqlCfg := mysql.NewConfig()
+	sqlCfg.Addr = fmt.Sprintf("%s:%s", os.Getenv("MYSQL_HOST"), os.Getenv("MYSQL_PORT"))
+	sqlCfg.User = os.Getenv("MYSQL_USERNAME")
+	sqlCfg.Passwd = os.Getenv("MYSQL_PASSWORD")
+	sqlCfg.DBName = os.Getenv("MYSQL_DATABASE")
+	sqlCfg.ParseTime = true
+
+	dbDriver, err := mysql.NewConnector(sqlCfg)
+	if err != nil {
+		log.Fatal("mysql connector not created", logger.Err(err))
+	}
+
+	conn := sql.OpenDB(dbDriver)
+	defer conn.Close()
+
+	authService := auth.NewAuthService(authdb.NewAPIKeyDatabase(conn))
+	ctx := logger.NewContext(context.Background(), log)
+	key, apiKey, err := authService.CreateAPIKey(policy.WithSystem(ctx), *name, *role, *authorID)
+	if err != nil {
+		log.Fatal("api key not created", logger.Err(err))
+	}
+
+	// the key is the output of the command rather than a log, it is shown only
+	// once and never logged
+	log.Info("api key created", logger.Int("api_key_id", apiKey.ID), logger.String("role", apiKey.Role), logger.String("name", apiKey.Name))
+	fmt.Println(key)
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/subosito/gotenv"
+
+	"github.com/prabudzak/article/logger"
+)
+
+type tagFlag []string
+
+func (t *tagFlag) String() string {
+	return strings.Join(*t, ",")
+}
+
+func (t *tagFlag) Set(tag string) error {
+	*t = append(*t, tag)
+	return nil
+}
+
+func main() {
+	gotenv.Load()
+
+	var tags tagFlag
+	output := flag.String("output", "", "file to write the export to. Default to articles.<format>, with .gz suffix when gzipped")
+	format := flag.String("format", "ndjson", "export format, ndjson, csv or json")
+	gzipped := flag.Bool("gzip", false, "gzip the export. Default to true when output ends with .gz")
+	author := flag.String("author", "", "export articles of author name or handle only")
+	keyword := flag.String("query", "", "export articles containing keyword only")
+	language := flag.String("language", "", "export articles of two letter language code only")
+	tagMode := flag.String("tag-mode", "", "any or all of tags")
+	status := flag.String("status", "", "export articles of status only, default to published")
+	flag.Var(&tags, "tag", "export articles tagged by tag only, repeatable")
+	serviceURL := flag.String("url", fmt.Sprintf("%s:%s", os.Getenv("URL"), os.Getenv("PORT")), "article service url")
+	apiKey := flag.String("api-key", os.Getenv("API_KEY"), "article service API key of an editor, required to export unpublished articles")
+	flag.Parse()
+
+	logLevel, err := logger.ParseLevel(os.Getenv("LOG_LEVEL"))
+	if err != nil {
+		logger.Default().Fatal("log level invalid", logger.Err(err))
+	}
+	log := logger.New(os.Stderr, logLevel)
+	logger.SetDefault(log)
+
+	if *output == "" {
+		*output = "articles." + *format
+		if *gzipped {
+			*output += ".gz"
+		}
+	}
+
+	if strings.HasSuffix(*output, ".gz") {
+		*gzipped = true
+	}
+
+	query := url.Values{}
+	query.Set("format", *format)
+	for name, value := range map[string]string{"author": *author, "query": *keyword, "language": *language, "tag_mode": *tagMode, "status": *status} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+	for _, tag := range tags {
+		query.Add("tag", tag)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, *serviceURL+"/articles/export?"+query.Encode(), nil)
+	if err != nil {
+		log.Fatal("export request not created", logger.Err(err))
+	}
+	if *apiKey != "" {
+		req.Header.Set("X-API-Key", *apiKey)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Fatal("export request failed", logger.Err(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var body struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		log.Fatal("export failed", logger.Int("status", resp.StatusCode), logger.String("reason", body.Message))
+	}
+
+	written, err := write(*output, resp.Body, *gzipped)
+	if err != nil {
+		log.Fatal("export not written", logger.String("output", *output), logger.Err(err))
+	}
+
+	log.Info("exported", logger.Any("bytes", written), logger.String("output", *output))
+}
+
+// write copy the export to a temporary file next to output, which replace
+// output once the export is complete, so an interrupted export never leave a
+// truncated output behind
+func write(output string, export io.Reader, gzipped bool) (int64, error) {
+	tmp, err := os.Create(filepath.Join(filepath.Dir(output), "."+filepath.Base(output)+".tmp"))
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	var w io.Writer = tmp
+	var zw *gzip.Writer
+	if gzipped {
+		zw = gzip.NewWriter(tmp)
+		w = zw
+	}
+
+	written, err := io.Copy(w, export)
+	if err != nil {
+		return written, err
+	}
+
+	if zw != nil {
+		err = zw.Close()
+		if err != nil {
+			return written, err
+		}
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return written, err
+	}
+
+	return written, os.Rename(tmp.Name(), output)
+}
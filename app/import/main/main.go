@@ -0,0 +1,405 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/subosito/gotenv"
+
+	"github.com/prabudzak/article/logger"
+)
+
+// maxAttempts is the maximum number of attempts to submit a batch before
+// giving up, leaving the checkpoint to resume from
+const maxAttempts = 3
+
+// errRejected represent a batch rejected by the service, which retrying would
+// not help
+var errRejected = errors.New("batch rejected")
+
+// csvColumns is the accepted columns of CSV file header. Tags column hold tags
+// separated by semicolons
+var csvColumns = []string{"author", "title", "body", "body_format", "language", "tags", "status", "publish_at"}
+
+type articleRecord struct {
+	Author     string   `json:"author"`
+	Title      string   `json:"title"`
+	Body       string   `json:"body"`
+	BodyFormat string   `json:"body_format,omitempty"`
+	Language   string   `json:"language,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+	Status     string   `json:"status,omitempty"`
+	PublishAt  string   `json:"publish_at,omitempty"`
+}
+
+type importItem struct {
+	Index   int    `json:"index"`
+	Status  string `json:"status"`
+	ID      int    `json:"id"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Errors  []struct {
+		Field   string `json:"field"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+type importResponse struct {
+	Message string `json:"message"`
+	Data    struct {
+		Created int          `json:"created"`
+		Failed  int          `json:"failed"`
+		Items   []importItem `json:"items"`
+	} `json:"data"`
+}
+
+type importer struct {
+	url        string
+	apiKey     string
+	file       string
+	batchSize  int
+	checkpoint string
+	log        *logger.Logger
+
+	created int
+	failed  int
+}
+
+func main() {
+	gotenv.Load()
+
+	file := flag.String("file", "", "NDJSON or CSV file of articles to import")
+	format := flag.String("format", "", "file format, ndjson or csv. Default to file extension")
+	batchSize := flag.Int("batch-size", 100, "number of articles submitted per request, max 1000")
+	checkpoint := flag.String("checkpoint", "", "file recording the number of imported records to resume from. Default to <file>.checkpoint")
+	url := flag.String("url", fmt.Sprintf("%s:%s", os.Getenv("URL"), os.Getenv("PORT")), "article service url")
+	apiKey := flag.String("api-key", os.Getenv("API_KEY"), "article service API key")
+	flag.Parse()
+
+	if *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(*file)), ".")
+	}
+
+	if *checkpoint == "" {
+		*checkpoint = *file + ".checkpoint"
+	}
+
+	logLevel, err := logger.ParseLevel(os.Getenv("LOG_LEVEL"))
+	if err != nil {
+		logger.Default().Fatal("log level invalid", logger.Err(err))
+	}
+	log := logger.New(os.Stderr, logLevel)
+	logger.SetDefault(log)
+
+	if *batchSize <= 0 || *batchSize > 1000 {
+		log.Fatal("batch size must be between 1 and 1000", logger.Int("batch_size", *batchSize))
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatal("file not opened", logger.String("file", *file), logger.Err(err))
+	}
+	defer f.Close()
+
+	var records recordReader
+	switch *format {
+	case "ndjson", "jsonl":
+		records = newNDJSONReader(f)
+	case "csv":
+		records, err = newCSVReader(f)
+		if err != nil {
+			log.Fatal("csv file not read", logger.String("file", *file), logger.Err(err))
+		}
+	default:
+		log.Fatal("unsupported file format", logger.String("format", *format))
+	}
+
+	i := &importer{
+		url:        *url,
+		apiKey:     *apiKey,
+		file:       *file,
+		batchSize:  *batchSize,
+		checkpoint: *checkpoint,
+		log:        log,
+	}
+
+	err = i.run(records)
+	if err != nil {
+		log.Fatal("import stopped", logger.Int("created", i.created), logger.Int("failed", i.failed), logger.Err(err))
+	}
+
+	log.Info("imported", logger.Int("created", i.created), logger.Int("failed", i.failed))
+}
+
+// run submit records in batches, starting after the last checkpoint and
+// recording a new checkpoint after each submitted batch
+func (i *importer) run(records recordReader) error {
+	done, err := i.readCheckpoint()
+	if err != nil {
+		return err
+	}
+
+	for n := 0; n < done; n++ {
+		_, err = records.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if done > 0 {
+		i.log.Info("resuming", logger.Int("after_record", done))
+	}
+
+	for {
+		batch := make([]json.RawMessage, 0, i.batchSize)
+		for len(batch) < i.batchSize {
+			record, err := records.Next()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return fmt.Errorf("record %d: %w", done+len(batch)+1, err)
+			}
+
+			batch = append(batch, record)
+		}
+
+		if len(batch) == 0 {
+			return nil
+		}
+
+		err = i.submit(done, batch)
+		if err != nil {
+			return err
+		}
+
+		done += len(batch)
+		err = i.writeCheckpoint(done)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// submit send a batch of records starting after the given record number,
+// retrying failed requests with the same idempotency key so a batch processed
+// by the service is never imported twice
+func (i *importer) submit(start int, batch []json.RawMessage) error {
+	var body bytes.Buffer
+	for _, record := range batch {
+		body.Write(record)
+		body.WriteByte('\n')
+	}
+
+	sum := sha256.Sum256(append([]byte(fmt.Sprintf("%s\n%d\n", i.file, start)), body.Bytes()...))
+	idempotencyKey := "import-" + hex.EncodeToString(sum[:])
+
+	var result importResponse
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		result, err = i.post(body.Bytes(), idempotencyKey)
+		if err == nil || errors.Is(err, errRejected) {
+			break
+		}
+
+		i.log.Warn("batch attempt failed", logger.Int("after_record", start), logger.Int("attempt", attempt), logger.Err(err))
+		time.Sleep(time.Duration(attempt) * time.Second)
+	}
+	if err != nil {
+		return fmt.Errorf("batch after record %d: %w", start, err)
+	}
+
+	i.created += result.Data.Created
+	i.failed += result.Data.Failed
+	for _, item := range result.Data.Items {
+		if item.Status == "created" {
+			continue
+		}
+
+		reason := item.Message
+		for _, violation := range item.Errors {
+			reason += fmt.Sprintf("; %s: %s", violation.Field, violation.Message)
+		}
+		i.log.Warn("record failed", logger.Int("record", start+item.Index+1), logger.String("code", item.Code), logger.String("reason", reason))
+	}
+
+	return nil
+}
+
+func (i *importer) post(body []byte, idempotencyKey string) (importResponse, error) {
+	var result importResponse
+
+	req, err := http.NewRequest(http.MethodPost, i.url+"/articles:batch", bytes.NewReader(body))
+	if err != nil {
+		return result, err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set("Idempotency-Key", idempotencyKey)
+	if i.apiKey != "" {
+		req.Header.Set("X-API-Key", i.apiKey)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return result, err
+	}
+	defer resp.Body.Close()
+
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		return result, fmt.Errorf("status %d: %w", resp.StatusCode, err)
+	}
+
+	if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusConflict {
+		return result, fmt.Errorf("status %d: %s", resp.StatusCode, result.Message)
+	} else if resp.StatusCode != http.StatusOK {
+		return result, fmt.Errorf("%w, status %d: %s", errRejected, resp.StatusCode, result.Message)
+	}
+
+	return result, nil
+}
+
+func (i *importer) readCheckpoint() (int, error) {
+	content, err := ioutil.ReadFile(i.checkpoint)
+	if os.IsNotExist(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	done, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil || done < 0 {
+		return 0, fmt.Errorf("invalid checkpoint %s", i.checkpoint)
+	}
+
+	return done, nil
+}
+
+// writeCheckpoint replace the checkpoint atomically, so an interrupted import
+// never leave a partially written checkpoint
+func (i *importer) writeCheckpoint(done int) error {
+	tmp := i.checkpoint + ".tmp"
+	err := ioutil.WriteFile(tmp, []byte(strconv.Itoa(done)+"\n"), 0644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, i.checkpoint)
+}
+
+// recordReader read article records one at a time as JSON objects, returning
+// io.EOF after the last record
+type recordReader interface {
+	Next() (json.RawMessage, error)
+}
+
+type ndjsonReader struct {
+	scanner *bufio.Scanner
+}
+
+func newNDJSONReader(r io.Reader) *ndjsonReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+
+	return &ndjsonReader{scanner: scanner}
+}
+
+func (n *ndjsonReader) Next() (json.RawMessage, error) {
+	for n.scanner.Scan() {
+		line := bytes.TrimSpace(n.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		record := make(json.RawMessage, len(line))
+		copy(record, line)
+		return record, nil
+	}
+
+	if err := n.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+type csvReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("csv header: %w", err)
+	}
+
+	columns := map[string]int{}
+	for i, column := range header {
+		columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+
+	for _, column := range []string{"author", "title", "body"} {
+		if _, ok := columns[column]; !ok {
+			return nil, fmt.Errorf("csv header missing %s column, accepted columns are %s", column, strings.Join(csvColumns, ","))
+		}
+	}
+
+	return &csvReader{reader: reader, columns: columns}, nil
+}
+
+func (c *csvReader) Next() (json.RawMessage, error) {
+	row, err := c.reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	column := func(name string) string {
+		i, ok := c.columns[name]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return row[i]
+	}
+
+	record := articleRecord{
+		Author:     column("author"),
+		Title:      column("title"),
+		Body:       column("body"),
+		BodyFormat: column("body_format"),
+		Language:   column("language"),
+		Status:     column("status"),
+		PublishAt:  column("publish_at"),
+	}
+
+	for _, tag := range strings.Split(column("tags"), ";") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			record.Tags = append(record.Tags, tag)
+		}
+	}
+
+	return json.Marshal(record)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/olivere/elastic"
+	"github.com/subosito/gotenv"
+
+	"github.com/prabudzak/article/logger"
+	"github.com/prabudzak/article/service/article"
+	articleindexer "github.com/prabudzak/article/service/article/elasticsearch"
+	articledb "github.com/prabudzak/article/service/article/mysql"
+	"github.com/prabudzak/article/service/policy"
+)
+
+// reindex write every stored article to elasticsearch again. It is run on
+// deploy after the index mapping changed, so documents indexed before carry
+// every field the service filter on
+func main() {
+	gotenv.Load()
+
+	logLevel, err := logger.ParseLevel(os.Getenv("LOG_LEVEL"))
+	if err != nil {
+		logger.Default().Fatal("log level invalid", logger.Err(err))
+	}
+	log := logger.New(os.Stderr, logLevel)
+	logger.SetDefault(log)
+
+	sqlCfg := mysql.NewConfig()
+	sqlCfg.Addr = fmt.Sprintf("%s:%s", os.Getenv("MYSQL_HOST"), os.Getenv("MYSQL_PORT"))
+	sqlCfg.User = os.Getenv("MYSQL_USERNAME")
+	sqlCfg.Passwd = os.Getenv("MYSQL_PASSWORD")
+	sqlCfg.DBName = os.Getenv("MYSQL_DATABASE")
+	sqlCfg.ParseTime = true
+
+	dbDriver, err := mysql.NewConnector(sqlCfg)
+	if err != nil {
+		log.Fatal("mysql connector not created", logger.Err(err))
+	}
+
+	conn := sql.OpenDB(dbDriver)
+	defer conn.Close()
+
+	esClient, err := elastic.NewClient(
+		elastic.SetURL(os.Getenv("ELASTICSEARCH_URL")),
+		elastic.SetHttpClient(&http.Client{}),
+	)
+	if err != nil {
+		log.Fatal("elasticsearch client not created", logger.Err(err))
+	}
+
+	indexer := articleindexer.NewArticleIndexer(esClient, os.Getenv("ELASTICSEARCH_ARTICLE_INDEX"),
+		articleindexer.WithFuzziness(os.Getenv("ELASTICSEARCH_FUZZINESS")),
+		articleindexer.WithDefaultLanguage(os.Getenv("ELASTICSEARCH_DEFAULT_LANGUAGE")),
+	)
+
+	// reindexing neither read the cache nor resolve authors
+	articleService := article.NewArticleService(articledb.NewArticleDatabase(conn), nil, indexer, nil)
+
+	reindexed, err := articleService.ReindexArticle(policy.WithSystem(logger.NewContext(context.Background(), log)))
+	if err != nil {
+		log.Fatal("reindex stopped", logger.Int("reindexed", reindexed), logger.Err(err))
+	}
+
+	log.Info("reindexed", logger.Int("reindexed", reindexed))
+}
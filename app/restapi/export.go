@@ -0,0 +1,155 @@
+package restapi
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prabudzak/article/model"
+)
+
+// Export formats
+const (
+	exportFormatNDJSON = "ndjson"
+	exportFormatCSV    = "csv"
+	exportFormatJSON   = "json"
+)
+
+// exportFlushSize is the number of exported articles written between flushes
+// to the client
+const exportFlushSize = 100
+
+var exportContentTypes = map[string]string{
+	exportFormatNDJSON: "application/x-ndjson",
+	exportFormatCSV:    "text/csv; charset=utf-8",
+	exportFormatJSON:   "application/json",
+}
+
+var exportCSVHeader = []string{"id", "author_id", "author", "author_handle", "title", "body", "body_format", "language", "tags", "status", "publish_at", "version", "created_at", "updated_at"}
+
+// exportWriter stream exported articles to the client in one of export
+// formats. Response header is written with the first article, so a failure
+// before any article is exported can still be responded as an error
+type exportWriter struct {
+	w       http.ResponseWriter
+	format  string
+	buf     *bufio.Writer
+	csv     *csv.Writer
+	started bool
+	count   int
+}
+
+func newExportWriter(w http.ResponseWriter, format string) *exportWriter {
+	return &exportWriter{
+		w:      w,
+		format: format,
+	}
+}
+
+func (e *exportWriter) start() {
+	e.started = true
+
+	e.w.Header().Set("Content-Type", exportContentTypes[e.format])
+	e.w.Header().Set("Content-Disposition", `attachment; filename="articles.`+e.format+`"`)
+	e.w.WriteHeader(http.StatusOK)
+
+	e.buf = bufio.NewWriter(e.w)
+	switch e.format {
+	case exportFormatCSV:
+		e.csv = csv.NewWriter(e.buf)
+		e.csv.Write(exportCSVHeader)
+	case exportFormatJSON:
+		e.buf.WriteString("[")
+	}
+}
+
+// Write write an exported article
+func (e *exportWriter) Write(article model.Article) error {
+	if !e.started {
+		e.start()
+	}
+
+	article = articleRepresentation(article, false)
+
+	var err error
+	switch e.format {
+	case exportFormatCSV:
+		err = e.csv.Write(articleCSVRecord(article))
+	case exportFormatJSON:
+		if e.count > 0 {
+			e.buf.WriteString(",")
+		}
+		err = json.NewEncoder(e.buf).Encode(article)
+	default:
+		err = json.NewEncoder(e.buf).Encode(article)
+	}
+	if err != nil {
+		return err
+	}
+
+	e.count++
+	if e.count%exportFlushSize == 0 {
+		return e.flush()
+	}
+	return nil
+}
+
+// Close end the export, writing an empty export when no article was written
+func (e *exportWriter) Close() error {
+	if !e.started {
+		e.start()
+	}
+
+	if e.format == exportFormatJSON {
+		e.buf.WriteString("]\n")
+	}
+
+	return e.flush()
+}
+
+func (e *exportWriter) flush() error {
+	if e.csv != nil {
+		e.csv.Flush()
+		if err := e.csv.Error(); err != nil {
+			return err
+		}
+	}
+
+	err := e.buf.Flush()
+	if err != nil {
+		return err
+	}
+
+	if flusher, ok := e.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+func articleCSVRecord(article model.Article) []string {
+	var publishAt string
+	if article.PublishAt != nil {
+		publishAt = article.PublishAt.UTC().Format(time.RFC3339)
+	}
+
+	return []string{
+		strconv.Itoa(article.ID),
+		strconv.Itoa(article.AuthorID),
+		article.Author,
+		article.AuthorHandle,
+		article.Title,
+		article.Body,
+		article.BodyFormat,
+		article.Language,
+		strings.Join(article.Tags, ";"),
+		article.Status,
+		publishAt,
+		strconv.Itoa(article.Version),
+		article.CreatedAt.UTC().Format(time.RFC3339),
+		article.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,262 @@
+package restapi
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prabudzak/article/model"
+	"github.com/prabudzak/article/render"
+)
+
+// Feed content types
+const (
+	contentTypeRSS  = "application/rss+xml; charset=utf-8"
+	contentTypeAtom = "application/atom+xml; charset=utf-8"
+)
+
+// feedNamespace is the namespace of name based UUIDs identifying feeds and
+// their entries, so the identifiers stay the same wherever the API is served
+var feedNamespace = [16]byte{0x61, 0x28, 0xad, 0x5f, 0xcf, 0xa5, 0x4d, 0x44, 0x81, 0x2f, 0x1f, 0xa9, 0xb8, 0x93, 0x4e, 0x4a}
+
+// feed represent a feed of articles, latest first, encoded as RSS or Atom
+type feed struct {
+	id       string
+	title    string
+	link     string
+	self     string
+	updated  time.Time // zero for empty feed
+	articles []model.Article
+	baseURL  string
+}
+
+func newFeed(baseURL string, name string, title string, self string, articles []model.Article) feed {
+	return feed{
+		id:       feedUUID(name),
+		title:    title,
+		link:     baseURL + "/articles",
+		self:     baseURL + self,
+		updated:  articlesLastModified(articles),
+		articles: articles,
+		baseURL:  baseURL,
+	}
+}
+
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Self          atomLink  `xml:"atom:link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+	Categories  []string `xml:"category"`
+	Description string   `xml:"description"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Link       atomLink       `xml:"link"`
+	Author     atomAuthor     `xml:"author"`
+	Categories []atomCategory `xml:"category"`
+	Content    atomContent    `xml:"content"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri,omitempty"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomContent struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+// RSS encode the feed as RSS 2.0 document
+func (f feed) RSS() []byte {
+	channel := rssChannel{
+		Title:         f.title,
+		Link:          f.link,
+		Self:          atomLink{Href: f.self, Rel: "self", Type: "application/rss+xml"},
+		Description:   f.title,
+		LastBuildDate: f.lastUpdated().Format(time.RFC1123Z),
+		Items:         make([]rssItem, 0, len(f.articles)),
+	}
+
+	for _, article := range f.articles {
+		channel.Items = append(channel.Items, rssItem{
+			Title:       article.Title,
+			Link:        f.articleLink(article),
+			GUID:        rssGUID{Value: articleUUID(article)},
+			PubDate:     articlePublished(article).UTC().Format(time.RFC1123Z),
+			Categories:  article.Tags,
+			Description: articleHTML(article),
+		})
+	}
+
+	return encodeFeed(rss{Version: "2.0", Atom: "http://www.w3.org/2005/Atom", Channel: channel})
+}
+
+// Atom encode the feed as Atom 1.0 document
+func (f feed) Atom() []byte {
+	document := atomFeed{
+		ID:      f.id,
+		Title:   f.title,
+		Updated: f.lastUpdated().Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: f.self, Rel: "self", Type: "application/atom+xml"},
+			{Href: f.link, Rel: "alternate"},
+		},
+		Entries: make([]atomEntry, 0, len(f.articles)),
+	}
+
+	for _, article := range f.articles {
+		entry := atomEntry{
+			ID:        articleUUID(article),
+			Title:     article.Title,
+			Published: articlePublished(article).UTC().Format(time.RFC3339),
+			Updated:   article.UpdatedAt.UTC().Format(time.RFC3339),
+			Link:      atomLink{Href: f.articleLink(article), Rel: "alternate"},
+			Author:    atomAuthor{Name: article.Author},
+			Content:   atomContent{Type: "html", Value: articleHTML(article)},
+		}
+
+		if article.AuthorHandle != "" {
+			entry.Author.URI = f.baseURL + "/authors/" + article.AuthorHandle
+		}
+
+		for _, tag := range article.Tags {
+			entry.Categories = append(entry.Categories, atomCategory{Term: tag})
+		}
+
+		document.Entries = append(document.Entries, entry)
+	}
+
+	return encodeFeed(document)
+}
+
+// lastUpdated return the time the feed was last updated, the unix epoch for
+// empty feed so the document stay the same until an article is published
+func (f feed) lastUpdated() time.Time {
+	if f.updated.IsZero() {
+		return time.Unix(0, 0).UTC()
+	}
+	return f.updated.UTC()
+}
+
+func (f feed) articleLink(article model.Article) string {
+	return fmt.Sprintf("%s/articles/%d", f.baseURL, article.ID)
+}
+
+func encodeFeed(document interface{}) []byte {
+	var body bytes.Buffer
+	body.WriteString(xml.Header)
+	xml.NewEncoder(&body).Encode(document)
+	body.WriteString("\n")
+	return body.Bytes()
+}
+
+// articleHTML return the sanitized HTML of an article body, which feed
+// readers display as is. Articles written before body formats have no stored
+// rendering and are rendered on read
+func articleHTML(article model.Article) string {
+	if article.BodyHTML != "" {
+		return article.BodyHTML
+	}
+	return render.HTML(article.BodyFormat, article.Body)
+}
+
+// articlePublished return the time an article was published, or created when
+// it has no publish time
+func articlePublished(article model.Article) time.Time {
+	if article.PublishAt != nil {
+		return *article.PublishAt
+	}
+	return article.CreatedAt
+}
+
+// articleUUID return the stable identifier of an article in feeds
+func articleUUID(article model.Article) string {
+	return feedUUID(fmt.Sprintf("articles/%d", article.ID))
+}
+
+// feedUUID return version 5 name based UUID URN of name in feed namespace
+func feedUUID(name string) string {
+	hash := sha1.New()
+	hash.Write(feedNamespace[:])
+	hash.Write([]byte(name))
+	sum := hash.Sum(nil)
+
+	sum[6] = (sum[6] & 0x0f) | 0x50
+	sum[8] = (sum[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// responseFeed write a feed the same way as responseCacheableContent, last
+// modified as any list. Feeds linked from the request Host header, without
+// configured public URL, are private so a forged Host never reach shared caches
+func (a *API) responseFeed(w http.ResponseWriter, r *http.Request, contentType string, body []byte, updated time.Time) {
+	if a.publicURL == "" {
+		w.Header().Set("Cache-Control", "private, no-cache")
+	}
+
+	a.responseCacheableContent(w, r, contentType, body, a.listLastModified(r, updated))
+}
+
+// baseURL return the configured public base URL of the API, or the one the
+// request was made to when none is configured
+func (a *API) baseURL(r *http.Request) string {
+	if a.publicURL != "" {
+		return a.publicURL
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
@@ -0,0 +1,100 @@
+package restapi
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/prabudzak/article/model"
+)
+
+// fieldsSummary is the fields query parameter value selecting summaryFields
+const fieldsSummary = "summary"
+
+// summaryFields are the article fields of an article listed without its body
+var summaryFields = []string{
+	"id", "title", "excerpt", "word_count", "reading_time", "author_id", "author", "author_handle",
+	"language", "tags", "status", "publish_at", "version", "created_at", "updated_at",
+}
+
+type jsonField struct {
+	index     int
+	omitEmpty bool
+}
+
+// articleJSONFields map the JSON name of every model.Article field to the
+// field, the accepted names of fields query parameter
+var articleJSONFields = jsonFields(reflect.TypeOf(model.Article{}))
+
+func jsonFields(t reflect.Type) map[string]jsonField {
+	fields := map[string]jsonField{}
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("json"), ",")
+		if tag[0] == "" || tag[0] == "-" {
+			continue
+		}
+
+		fields[tag[0]] = jsonField{
+			index:     i,
+			omitEmpty: len(tag) > 1 && tag[1] == "omitempty",
+		}
+	}
+	return fields
+}
+
+// articleFields parse comma separated article JSON field names of fields
+// query parameter, or summary for summaryFields. No field means every field
+func articleFields(queryParam url.Values) ([]string, error) {
+	param := strings.ToLower(strings.TrimSpace(queryParam.Get("fields")))
+	if param == "" {
+		return nil, nil
+	} else if param == fieldsSummary {
+		return summaryFields, nil
+	}
+
+	fields := []string{}
+	seen := map[string]bool{}
+	for _, field := range strings.Split(param, ",") {
+		field = strings.TrimSpace(field)
+		if _, ok := articleJSONFields[field]; !ok {
+			return nil, errInvalidFields.Wrap(fmt.Errorf("unknown article field %q", field))
+		}
+
+		if !seen[field] {
+			seen[field] = true
+			fields = append(fields, field)
+		}
+	}
+
+	return fields, nil
+}
+
+// projectArticles return only given fields of articles, keyed by their JSON
+// name. Empty optional fields are left out as in whole articles
+func projectArticles(articles []model.Article, fields []string) []map[string]interface{} {
+	projected := make([]map[string]interface{}, 0, len(articles))
+	for _, article := range articles {
+		value := reflect.ValueOf(article)
+		projection := make(map[string]interface{}, len(fields))
+		for _, name := range fields {
+			field := articleJSONFields[name]
+			if field.omitEmpty && value.Field(field.index).IsZero() {
+				continue
+			}
+			projection[name] = value.Field(field.index).Interface()
+		}
+		projected = append(projected, projection)
+	}
+	return projected
+}
+
+// hasField report whether field is one of fields
+func hasField(fields []string, field string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
@@ -1,74 +1,638 @@
 package restapi
 
 import (
-	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
 
+	"github.com/prabudzak/article/logger"
 	"github.com/prabudzak/article/model"
+	"github.com/prabudzak/article/service"
 )
 
 func (a *API) createArticle(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
 	var body createArticleRequest
 
-	err := json.NewDecoder(r.Body).Decode(&body)
+	err := decodeRequest(w, r, &body)
 	if err != nil {
-		a.responseMessage(w, http.StatusBadRequest, "bad request")
+		a.responseDecodeError(w, r, err)
 		return
 	}
-	defer r.Body.Close()
 
+	body.Normalize()
 	err = body.Validate()
 	if err != nil {
-		a.responseError(w, http.StatusUnprocessableEntity, err)
+		a.responseError(w, r, err)
 		return
 	}
 
-	article := model.Article{
-		Author: body.Author,
-		Title:  body.Title,
-		Body:   body.Body,
+	html, err := renderHTML(r)
+	if err != nil {
+		a.responseError(w, r, err)
+		return
+	}
+
+	article, err := a.articleService.CreateArticle(r.Context(), body.Article())
+	if err != nil {
+		a.responseError(w, r, err)
+		return
+	}
+
+	response := response{
+		Message: "article created",
+		Data:    articleRepresentation(article, html),
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/articles/%d", article.ID))
+	w.Header().Set("ETag", articleETag(article))
+	a.response(w, http.StatusCreated, response)
+}
+
+func (a *API) importArticles(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	items, err := decodeBatchRequest(w, r)
+	if err != nil {
+		a.responseDecodeError(w, r, err)
+		return
+	} else if len(items) == 0 {
+		a.responseError(w, r, errEmptyBatch)
+		return
+	}
+
+	articles := make([]model.Article, 0, len(items))
+	positions := make([]int, 0, len(items))
+	imported := make([]model.Article, len(items))
+	errs := make([]error, len(items))
+
+	for i, item := range items {
+		if item.err != nil {
+			errs[i] = item.err
+			continue
+		}
+
+		item.request.Normalize()
+		err = item.request.Validate()
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+
+		articles = append(articles, item.request.Article())
+		positions = append(positions, i)
+	}
+
+	if len(articles) > 0 {
+		results, err := a.articleService.ImportArticles(r.Context(), articles)
+		if err != nil {
+			a.responseError(w, r, err)
+			return
+		}
+
+		for n, result := range results {
+			imported[positions[n]] = result.Article
+			errs[positions[n]] = result.Err
+		}
+	}
+
+	report := importResponse{Items: make([]importItemResponse, 0, len(items))}
+	for i := range items {
+		report.add(r.Context(), i, imported[i], errs[i])
+	}
+
+	response := response{
+		Message: "articles imported",
+		Data:    report,
+	}
+
+	a.response(w, http.StatusOK, response)
+}
+
+func (a *API) getArticle(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	id, err := idParam(param, "id")
+	if err != nil {
+		a.responseError(w, r, errInvalidArticleID)
+		return
+	}
+
+	html, err := renderHTML(r)
+	if err != nil {
+		a.responseError(w, r, err)
+		return
+	}
+
+	article, err := a.articleService.GetArticle(r.Context(), id)
+	if err != nil {
+		a.responseError(w, r, err)
+		return
+	}
+
+	response := response{
+		Message: "article retrieved",
+		Data:    articleRepresentation(article, html),
 	}
 
-	err = a.articleService.CreateArticle(r.Context(), article)
+	w.Header().Set("ETag", articleETag(article))
+	a.responseCacheable(w, r, response, article.UpdatedAt)
+}
+
+func (a *API) updateArticle(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	var body updateArticleRequest
+
+	id, err := idParam(param, "id")
 	if err != nil {
-		a.responseError(w, http.StatusInternalServerError, err)
+		a.responseError(w, r, errInvalidArticleID)
 		return
 	}
 
-	a.responseMessage(w, http.StatusCreated, "article created")
+	err = decodeRequest(w, r, &body)
+	if err != nil {
+		a.responseDecodeError(w, r, err)
+		return
+	}
+
+	body.Normalize()
+	err = body.Validate()
+	if err != nil {
+		a.responseError(w, r, err)
+		return
+	}
+
+	version, err := ifMatchVersion(r, id)
+	if err != nil {
+		a.responseStatusError(w, http.StatusPreconditionFailed, codePreconditionFailed, err)
+		return
+	}
+
+	html, err := renderHTML(r)
+	if err != nil {
+		a.responseError(w, r, err)
+		return
+	}
+
+	article := body.Article(id)
+	article.Version = version
+
+	article, err = a.articleService.UpdateArticle(r.Context(), article)
+	if errors.Is(err, service.ErrArticleVersionConflict) && version != 0 {
+		a.responseStatusError(w, http.StatusPreconditionFailed, codePreconditionFailed, err)
+		return
+	} else if err != nil {
+		a.responseError(w, r, err)
+		return
+	}
+
+	response := response{
+		Message: "article updated",
+		Data:    articleRepresentation(article, html),
+	}
+
+	w.Header().Set("ETag", articleETag(article))
+	a.response(w, http.StatusOK, response)
+}
+
+func (a *API) publishArticle(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	id, err := idParam(param, "id")
+	if err != nil {
+		a.responseError(w, r, errInvalidArticleID)
+		return
+	}
+
+	html, err := renderHTML(r)
+	if err != nil {
+		a.responseError(w, r, err)
+		return
+	}
+
+	article, err := a.articleService.PublishArticle(r.Context(), id)
+	if err != nil {
+		a.responseError(w, r, err)
+		return
+	}
+
+	response := response{
+		Message: "article published",
+		Data:    articleRepresentation(article, html),
+	}
+
+	w.Header().Set("ETag", articleETag(article))
+	a.response(w, http.StatusOK, response)
+}
+
+func (a *API) archiveArticle(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	id, err := idParam(param, "id")
+	if err != nil {
+		a.responseError(w, r, errInvalidArticleID)
+		return
+	}
+
+	html, err := renderHTML(r)
+	if err != nil {
+		a.responseError(w, r, err)
+		return
+	}
+
+	article, err := a.articleService.ArchiveArticle(r.Context(), id)
+	if err != nil {
+		a.responseError(w, r, err)
+		return
+	}
+
+	response := response{
+		Message: "article archived",
+		Data:    articleRepresentation(article, html),
+	}
+
+	w.Header().Set("ETag", articleETag(article))
+	a.response(w, http.StatusOK, response)
 }
 
 func (a *API) listArticle(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
 	queryParam, _ := url.ParseQuery(r.URL.RawQuery)
 
+	query, err := searchQuery(queryParam)
+	if err != nil {
+		a.responseError(w, r, err)
+		return
+	}
+
+	html, err := renderHTML(r)
+	if err != nil {
+		a.responseError(w, r, err)
+		return
+	}
+
+	query.Fields, err = articleFields(queryParam)
+	if err != nil {
+		a.responseError(w, r, err)
+		return
+	}
+
+	articles, err := a.articleService.SearchArticle(r.Context(), query)
+	if err != nil {
+		a.responseError(w, r, err)
+		return
+	}
+
+	var data interface{} = articleRepresentations(articles, html)
+	if len(query.Fields) > 0 {
+		html = html || hasField(query.Fields, "body_html")
+		data = projectArticles(articleRepresentations(articles, html), query.Fields)
+	}
+
+	response := response{
+		Message: "articles retrieved",
+		Data:    data,
+	}
+
+	a.responseCacheable(w, r, response, a.listLastModified(r, articlesLastModified(articles)))
+}
+
+func (a *API) exportArticle(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	queryParam, _ := url.ParseQuery(r.URL.RawQuery)
+
+	query, err := searchQuery(queryParam)
+	if err != nil {
+		a.responseError(w, r, err)
+		return
+	}
+
+	query.Status = strings.ToLower(queryParam.Get("status"))
+	if query.Status != "" && !isArticleStatus(query.Status) {
+		a.responseError(w, r, errInvalidStatus)
+		return
+	}
+
+	format := strings.ToLower(queryParam.Get("format"))
+	if format == "" {
+		format = exportFormatNDJSON
+	} else if _, ok := exportContentTypes[format]; !ok {
+		a.responseError(w, r, errInvalidExportFormat)
+		return
+	}
+
+	writer := newExportWriter(w, format)
+	err = a.articleService.ExportArticle(r.Context(), query, writer.Write)
+	if err != nil && !writer.started {
+		a.responseError(w, r, err)
+		return
+	} else if err != nil {
+		// the response is already streaming, the client see a truncated export
+		logger.FromContext(r.Context()).Error("export interrupted", logger.Err(err))
+		return
+	}
+
+	err = writer.Close()
+	if err != nil {
+		logger.FromContext(r.Context()).Error("export not completed", logger.Err(err))
+	}
+}
+
+func (a *API) listRelatedArticle(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	queryParam, _ := url.ParseQuery(r.URL.RawQuery)
+
+	id, err := idParam(param, "id")
+	if err != nil {
+		a.responseError(w, r, errInvalidArticleID)
+		return
+	}
+
+	offset, _ := strconv.ParseInt(queryParam.Get("offset"), 10, 32)
+	limit, _ := strconv.ParseInt(queryParam.Get("limit"), 10, 32)
+	sameAuthor, _ := strconv.ParseBool(queryParam.Get("same_author"))
+
+	query := model.ArticleRelatedQuery{
+		ArticleID:  id,
+		SameAuthor: sameAuthor,
+		Pagination: model.Pagination{Limit: int(limit), Offset: int(offset)},
+	}
+
+	html, err := renderHTML(r)
+	if err != nil {
+		a.responseError(w, r, err)
+		return
+	}
+
+	articles, err := a.articleService.RelatedArticle(r.Context(), query)
+	if err != nil {
+		a.responseError(w, r, err)
+		return
+	}
+
+	response := response{
+		Message: "related articles retrieved",
+		Data:    articleRepresentations(articles, html),
+	}
+
+	a.responseCacheable(w, r, response, a.listLastModified(r, articlesLastModified(articles)))
+}
+
+func (a *API) getAuthor(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	author, err := a.authorService.GetAuthor(r.Context(), param.ByName("handle"))
+	if err != nil {
+		a.responseError(w, r, err)
+		return
+	}
+
+	response := response{
+		Message: "author retrieved",
+		Data:    author,
+	}
+
+	a.responseCacheable(w, r, response, author.UpdatedAt)
+}
+
+func (a *API) listAuthorArticle(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	queryParam, _ := url.ParseQuery(r.URL.RawQuery)
+
+	author, err := a.authorService.GetAuthor(r.Context(), param.ByName("handle"))
+	if err != nil {
+		a.responseError(w, r, err)
+		return
+	}
+
 	offset, _ := strconv.ParseInt(queryParam.Get("offset"), 10, 32)
 	limit, _ := strconv.ParseInt(queryParam.Get("limit"), 10, 32)
 
 	query := model.ArticleSearchQuery{
-		Author:     queryParam.Get("author"),
-		Keyword:    queryParam.Get("query"),
+		Author:     author.Handle,
 		Pagination: model.Pagination{Limit: int(limit), Offset: int(offset)},
 	}
 
+	html, err := renderHTML(r)
+	if err != nil {
+		a.responseError(w, r, err)
+		return
+	}
+
 	articles, err := a.articleService.SearchArticle(r.Context(), query)
 	if err != nil {
-		a.responseError(w, http.StatusInternalServerError, err)
+		a.responseError(w, r, err)
 		return
 	}
 
 	response := response{
 		Message: "articles retrieved",
-		Data:    articles,
+		Data:    articleRepresentations(articles, html),
+	}
+
+	a.responseCacheable(w, r, response, a.listLastModified(r, articlesLastModified(articles)))
+}
+
+func (a *API) feedRSS(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	feed, err := a.latestFeed(r, "/feed.rss")
+	if err != nil {
+		a.responseError(w, r, err)
+		return
 	}
 
+	a.responseFeed(w, r, contentTypeRSS, feed.RSS(), feed.updated)
+}
+
+func (a *API) feedAtom(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	feed, err := a.latestFeed(r, "/feed.atom")
+	if err != nil {
+		a.responseError(w, r, err)
+		return
+	}
+
+	a.responseFeed(w, r, contentTypeAtom, feed.Atom(), feed.updated)
+}
+
+// latestFeed build a feed of the latest published articles
+func (a *API) latestFeed(r *http.Request, self string) (feed, error) {
+	query := model.ArticleSearchQuery{
+		Pagination: model.Pagination{Limit: feedLimit(r)},
+	}
+
+	articles, err := a.articleService.SearchArticle(r.Context(), query)
+	if err != nil {
+		return feed{}, err
+	}
+
+	return newFeed(a.baseURL(r), "feed", "Latest articles", self, articles), nil
+}
+
+func (a *API) authorFeed(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	author, err := a.authorService.GetAuthor(r.Context(), param.ByName("handle"))
+	if err != nil {
+		a.responseError(w, r, err)
+		return
+	}
+
+	query := model.ArticleSearchQuery{
+		Author:     author.Handle,
+		Pagination: model.Pagination{Limit: feedLimit(r)},
+	}
+
+	articles, err := a.articleService.SearchArticle(r.Context(), query)
+	if err != nil {
+		a.responseError(w, r, err)
+		return
+	}
+
+	self := "/authors/" + author.Handle + "/feed.atom"
+	feed := newFeed(a.baseURL(r), "authors/"+author.Handle+"/feed", "Articles by "+author.Name, self, articles)
+
+	a.responseFeed(w, r, contentTypeAtom, feed.Atom(), feed.updated)
+}
+
+func (a *API) listArticleRevision(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	id, err := idParam(param, "id")
+	if err != nil {
+		a.responseError(w, r, errInvalidArticleID)
+		return
+	}
+
+	revisions, err := a.articleService.ListArticleRevision(r.Context(), id)
+	if err != nil {
+		a.responseError(w, r, err)
+		return
+	}
+
+	var lastModified time.Time
+	if len(revisions) > 0 {
+		lastModified = revisions[0].CreatedAt
+	}
+
+	response := response{
+		Message: "article revisions retrieved",
+		Data:    revisions,
+	}
+
+	a.responseCacheable(w, r, response, lastModified)
+}
+
+func (a *API) getArticleRevision(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	id, err := idParam(param, "id")
+	if err != nil {
+		a.responseError(w, r, errInvalidArticleID)
+		return
+	}
+
+	rev, err := idParam(param, "rev")
+	if err != nil {
+		a.responseError(w, r, errInvalidRevision)
+		return
+	}
+
+	revision, err := a.articleService.GetArticleRevision(r.Context(), id, rev)
+	if err != nil {
+		a.responseError(w, r, err)
+		return
+	}
+
+	response := response{
+		Message: "article revision retrieved",
+		Data:    revision,
+	}
+
+	a.responseCacheable(w, r, response, revision.CreatedAt)
+}
+
+func (a *API) diffArticleRevision(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	queryParam, _ := url.ParseQuery(r.URL.RawQuery)
+
+	id, err := idParam(param, "id")
+	if err != nil {
+		a.responseError(w, r, errInvalidArticleID)
+		return
+	}
+
+	rev, err := idParam(param, "rev")
+	if err != nil {
+		a.responseError(w, r, errInvalidRevision)
+		return
+	}
+
+	from := rev - 1
+	if queryParam.Get("from") != "" {
+		parsed, err := strconv.ParseInt(queryParam.Get("from"), 10, 32)
+		if err != nil || parsed < 0 {
+			a.responseError(w, r, errInvalidFromRevision)
+			return
+		}
+		from = int(parsed)
+	}
+
+	result, err := a.articleService.DiffArticleRevision(r.Context(), id, from, rev)
+	if err != nil {
+		a.responseError(w, r, err)
+		return
+	}
+
+	response := response{
+		Message: "article revision diff retrieved",
+		Data:    result,
+	}
+
+	a.responseCacheable(w, r, response, time.Time{})
+}
+
+func (a *API) restoreArticleRevision(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	id, err := idParam(param, "id")
+	if err != nil {
+		a.responseError(w, r, errInvalidArticleID)
+		return
+	}
+
+	rev, err := idParam(param, "rev")
+	if err != nil {
+		a.responseError(w, r, errInvalidRevision)
+		return
+	}
+
+	html, err := renderHTML(r)
+	if err != nil {
+		a.responseError(w, r, err)
+		return
+	}
+
+	article, err := a.articleService.RestoreArticleRevision(r.Context(), id, rev)
+	if err != nil {
+		a.responseError(w, r, err)
+		return
+	}
+
+	response := response{
+		Message: "article revision restored",
+		Data:    articleRepresentation(article, html),
+	}
+
+	w.Header().Set("ETag", articleETag(article))
 	a.response(w, http.StatusOK, response)
 }
 
-func (a *API) healthz(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("ok"))
+func (a *API) listTag(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	queryParam, _ := url.ParseQuery(r.URL.RawQuery)
+
+	limit, _ := strconv.ParseInt(queryParam.Get("limit"), 10, 32)
+
+	tags, err := a.articleService.ListTag(r.Context(), model.TagQuery{Limit: int(limit)})
+	if err != nil {
+		a.responseError(w, r, err)
+		return
+	}
+
+	response := response{
+		Message: "tags retrieved",
+		Data:    tags,
+	}
+
+	a.responseCacheable(w, r, response, time.Time{})
+}
+
+// livez report whether the service is alive, so it is restarted otherwise
+func (a *API) livez(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	a.responseHealth(w, a.health.Live(r.Context()))
+}
+
+// readyz report whether the service can serve requests, so it is sent
+// requests only then. A degraded service still serve requests
+func (a *API) readyz(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+	a.responseHealth(w, a.health.Ready(r.Context()))
 }
@@ -4,9 +4,10 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"io/ioutil"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/go-redis/redis"
 	"github.com/go-sql-driver/mysql"
@@ -15,17 +16,50 @@ import (
 
 	"github.com/prabudzak/article/app/restapi"
 	"github.com/prabudzak/article/event"
+	eventinstrumented "github.com/prabudzak/article/event/instrumented"
 	"github.com/prabudzak/article/event/memory"
+	"github.com/prabudzak/article/health"
+	"github.com/prabudzak/article/logger"
 	"github.com/prabudzak/article/service/article"
 	articleindexer "github.com/prabudzak/article/service/article/elasticsearch"
+	articleinstrumented "github.com/prabudzak/article/service/article/instrumented"
 	articledb "github.com/prabudzak/article/service/article/mysql"
 	articlecache "github.com/prabudzak/article/service/article/redis"
+	"github.com/prabudzak/article/service/article/scheduler"
+	"github.com/prabudzak/article/service/auth"
+	authdb "github.com/prabudzak/article/service/auth/mysql"
+	"github.com/prabudzak/article/service/author"
+	authordb "github.com/prabudzak/article/service/author/mysql"
+	"github.com/prabudzak/article/service/idempotency"
+	idempotencystore "github.com/prabudzak/article/service/idempotency/redis"
+	"github.com/prabudzak/article/service/ratelimit"
+	ratelimitmemory "github.com/prabudzak/article/service/ratelimit/memory"
+	ratelimitredis "github.com/prabudzak/article/service/ratelimit/redis"
+	"github.com/prabudzak/article/tracing"
 )
 
 func main() {
 	gotenv.Load()
 	ctx := context.Background()
 
+	logLevel, err := logger.ParseLevel(os.Getenv("LOG_LEVEL"))
+	if err != nil {
+		logger.Default().Fatal("log level invalid", logger.Err(err))
+	}
+	log := logger.New(os.Stderr, logLevel)
+	logger.SetDefault(log)
+
+	shutdownTracing, err := tracing.Setup(ctx, os.Getenv("TRACING_EXPORTER"), os.Getenv("OTLP_ENDPOINT"), "article")
+	if err != nil {
+		log.Fatal("tracing not set up", logger.Err(err))
+	}
+	defer shutdownTracing(ctx)
+
+	// feeds linked from the request Host header are not publicly cached
+	if os.Getenv("PUBLIC_URL") == "" {
+		log.Warn("public url not configured, feeds are linked from the request host")
+	}
+
 	sqlCfg := mysql.NewConfig()
 	sqlCfg.Addr = fmt.Sprintf("%s:%s", os.Getenv("MYSQL_HOST"), os.Getenv("MYSQL_PORT"))
 	sqlCfg.User = os.Getenv("MYSQL_USERNAME")
@@ -35,21 +69,22 @@ func main() {
 
 	dbDriver, err := mysql.NewConnector(sqlCfg)
 	if err != nil {
-		log.Fatalln(err)
+		log.Fatal("mysql connector not created", logger.Err(err))
 	}
 
 	conn := sql.OpenDB(dbDriver)
 	err = conn.Ping()
 	if err != nil {
-		log.Fatalln(err)
+		log.Fatal("mysql not reachable", logger.Err(err))
 	}
 
 	redisClient := redis.NewClient(&redis.Options{
 		Addr: os.Getenv("REDIS_ADDR"),
 	})
+	// the cache is not critical, the service start degraded without it
 	err = redisClient.Ping().Err()
 	if err != nil {
-		log.Fatalln(err)
+		log.Warn("redis not reachable", logger.Err(err))
 	}
 
 	esClient, err := elastic.NewClient(
@@ -57,26 +92,102 @@ func main() {
 		elastic.SetHttpClient(&http.Client{}),
 	)
 	if err != nil {
-		log.Fatalln(err)
+		log.Fatal("elasticsearch client not created", logger.Err(err))
 	}
 
-	articleDatabase := articledb.NewArticleDatabase(conn)
-	articleCache := articlecache.NewArticleCache(redisClient)
-	articleIndexer := articleindexer.NewArticleIndexer(esClient, os.Getenv("ELASTICSEARCH_ARTICLE_INDEX"))
-	articleService := article.NewArticleService(articleDatabase, articleCache, articleIndexer)
+	authorDatabase := authordb.NewAuthorDatabase(conn)
+	authorService := author.NewAuthorService(authorDatabase)
+
+	mysqlDatabase := articledb.NewArticleDatabase(conn)
+	redisCache := articlecache.NewArticleCache(redisClient)
+	esIndexer := articleindexer.NewArticleIndexer(esClient, os.Getenv("ELASTICSEARCH_ARTICLE_INDEX"),
+		articleindexer.WithFuzziness(os.Getenv("ELASTICSEARCH_FUZZINESS")),
+		articleindexer.WithDefaultLanguage(os.Getenv("ELASTICSEARCH_DEFAULT_LANGUAGE")),
+	)
+
+	articleDatabase := articleinstrumented.NewDatabase(mysqlDatabase)
+	articleCache := articleinstrumented.NewCache(redisCache)
+	articleIndexer := articleinstrumented.NewIndexer(esIndexer)
+	articleService := article.NewArticleService(articleDatabase, articleCache, articleIndexer, authorService)
+
+	memoryDispatcher := memory.NewDispatcher()
+	memoryDispatcher.Start()
 
-	dispatcher := memory.NewDispatcher()
-	dispatcher.Start()
+	healthTimeout, _ := time.ParseDuration(os.Getenv("HEALTH_CHECK_TIMEOUT"))
+	healthCacheTTL, _ := time.ParseDuration(os.Getenv("HEALTH_CHECK_CACHE_TTL"))
+	healthRegistry := health.NewRegistry(health.WithTimeout(healthTimeout), health.WithCacheTTL(healthCacheTTL))
+	healthRegistry.Register("mysql", mysqlDatabase)
+	healthRegistry.Register("redis", redisCache, health.NonCritical())
+	healthRegistry.Register("elasticsearch", esIndexer)
+	healthRegistry.Register("event_dispatcher", memoryDispatcher, health.Liveness())
+	dispatcher := eventinstrumented.NewDispatcher(memoryDispatcher)
 	event.SetDispatcher(dispatcher)
 
 	dispatcher.AddSubscriber(ctx, event.ArticleCreated{}, articleService.SubscriberCacheArticle)
+	dispatcher.AddSubscriber(ctx, event.ArticlePublished{}, articleService.SubscriberCacheArticle)
+	dispatcher.AddSubscriber(ctx, event.ArticleArchived{}, articleService.SubscriberCacheArticle)
+	dispatcher.AddSubscriber(ctx, event.ArticleUpdated{}, articleService.SubscriberCacheArticle)
+
+	schedulerInterval, _ := time.ParseDuration(os.Getenv("SCHEDULER_INTERVAL"))
+	articleScheduler := scheduler.NewScheduler(articleService, schedulerInterval)
+	articleScheduler.Start()
+
+	idempotencyTTL, _ := time.ParseDuration(os.Getenv("IDEMPOTENCY_TTL"))
+	idempotencyStore := idempotencystore.NewIdempotencyStore(redisClient)
+	idempotencyService := idempotency.NewIdempotencyService(idempotencyStore, idempotencyTTL)
+
+	authOptions := []auth.Option{
+		auth.WithHS256Key([]byte(os.Getenv("JWT_HS256_SECRET"))),
+		auth.WithIssuer(os.Getenv("JWT_ISSUER")),
+		auth.WithAudience(os.Getenv("JWT_AUDIENCE")),
+	}
+	if keyFile := os.Getenv("JWT_RS256_PUBLIC_KEY_FILE"); keyFile != "" {
+		keyPEM, err := ioutil.ReadFile(keyFile)
+		if err != nil {
+			log.Fatal("jwt public key not read", logger.Err(err))
+		}
+
+		key, err := auth.ParseRSAPublicKey(keyPEM)
+		if err != nil {
+			log.Fatal("jwt public key not parsed", logger.Err(err))
+		}
+		authOptions = append(authOptions, auth.WithRS256Key(key))
+	}
+	authService := auth.NewAuthService(authdb.NewAPIKeyDatabase(conn), authOptions...)
+
+	options := []restapi.Option{
+		restapi.WithCacheControl(os.Getenv("HTTP_CACHE_CONTROL")),
+		restapi.WithIdempotency(idempotencyService),
+		restapi.WithAuthentication(authService),
+		restapi.WithPublicURL(os.Getenv("PUBLIC_URL")),
+		restapi.WithHealth(healthRegistry),
+	}
+
+	rateLimitOptions, err := ratelimit.ParseRouteLimits(os.Getenv("RATE_LIMIT_ROUTES"))
+	if err != nil {
+		log.Fatal("rate limit routes invalid", logger.Err(err))
+	}
+	if defaultLimit := os.Getenv("RATE_LIMIT"); defaultLimit != "" {
+		limit, err := ratelimit.ParseLimit(defaultLimit)
+		if err != nil {
+			log.Fatal("rate limit invalid", logger.Err(err))
+		}
+		rateLimitOptions = append(rateLimitOptions, ratelimit.WithDefaultLimit(limit))
+	}
+	if len(rateLimitOptions) > 0 {
+		var rateLimitStore ratelimit.Store = ratelimitmemory.NewRateLimitStore()
+		if os.Getenv("RATE_LIMIT_STORE") == "redis" {
+			rateLimitStore = ratelimitredis.NewRateLimitStore(redisClient)
+		}
+		options = append(options, restapi.WithRateLimit(ratelimit.NewRateLimitService(rateLimitStore, rateLimitOptions...)))
+	}
 
-	router := restapi.New(articleService)
+	router := restapi.New(articleService, authorService, options...)
 
-	log.Printf("listening in %s\n", os.Getenv("PORT"))
+	log.Info("listening", logger.String("port", os.Getenv("PORT")))
 	err = http.ListenAndServe(fmt.Sprintf("0.0.0.0:%s", os.Getenv("PORT")), router.Router())
 	if err != nil {
-		log.Fatalln(err)
+		log.Fatal("server stopped", logger.Err(err))
 	}
 
 }
@@ -1,24 +1,388 @@
 package restapi
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/prabudzak/article/model"
+	"github.com/prabudzak/article/service"
+	"github.com/prabudzak/article/validation"
+)
+
+const (
+	// maxRequestBodyBytes is the maximum accepted size of a request body
+	maxRequestBodyBytes = 1 << 20
+	// maxBatchRequestBodyBytes is the maximum accepted size of a batch request
+	// body, each of its item is still limited to maxRequestBodyBytes
+	maxBatchRequestBodyBytes = 32 << 20
+	// maxIdempotencyKeyLength is the maximum accepted length of Idempotency-Key
+	// request header
+	maxIdempotencyKeyLength = 255
+	// authFailureMethod and authFailurePath name the pseudo route whose limit
+	// apply to the authentication failures of each IP address
+	authFailureMethod = "AUTH"
+	authFailurePath   = "/failures"
+)
+
+// Machine readable codes of errors detected by the api itself
+const (
+	codeValidationFailed   = "validation_failed"
+	codeRequestTooLarge    = "request_too_large"
+	codePreconditionFailed = "precondition_failed"
+	codeRateLimited        = "rate_limited"
+)
+
+var (
+	tagSpacePattern = regexp.MustCompile(`\s+`)
+
+	// replayedHeaders are the response headers set by idempotent route
+	// handlers, the only ones stored to be replayed
+	replayedHeaders = []string{"Content-Type", "Location", "ETag"}
+
+	errRequestTooLarge      = fmt.Errorf("request body exceed %d bytes", maxRequestBodyBytes)
+	errBatchRequestTooLarge = fmt.Errorf("request body exceed %d bytes or one of its line exceed %d bytes", maxBatchRequestBodyBytes, maxRequestBodyBytes)
+	errRateLimited          = errors.New("too many requests")
+
+	errMalformedRequest    = service.NewError(service.KindInvalidArgument, "malformed_request", "bad request")
+	errInvalidArticleID    = service.NewError(service.KindInvalidArgument, "invalid_article_id", "invalid article id")
+	errInvalidRevision     = service.NewError(service.KindInvalidArgument, "invalid_revision", "invalid revision")
+	errInvalidFromRevision = service.NewError(service.KindInvalidArgument, "invalid_from_revision", "invalid from revision")
+	errInvalidTagMode      = service.NewError(service.KindInvalidArgument, "invalid_tag_mode", "invalid tag mode")
+	errEmptyBatch          = service.NewError(service.KindInvalidArgument, "empty_batch", "batch has no item")
+	errInvalidStatus       = service.NewError(service.KindInvalidArgument, "invalid_status", "invalid article status")
+	errInvalidExportFormat = service.NewError(service.KindInvalidArgument, "invalid_export_format", "invalid export format, expected ndjson, csv or json")
+	errInvalidRender       = service.NewError(service.KindInvalidArgument, "invalid_render", "invalid render, expected html")
+	errInvalidFields       = service.NewError(service.KindInvalidArgument, "invalid_fields", "invalid fields")
+	errMissingCredentials  = service.NewError(service.KindUnauthenticated, "missing_credentials", "missing api key or bearer token")
+
+	errInvalidIdempotencyKey = service.NewError(service.KindInvalidArgument, "invalid_idempotency_key",
+		fmt.Sprintf("idempotency key exceed %d characters", maxIdempotencyKeyLength))
 )
 
 type createArticleRequest struct {
-	Author string `json:"author"`
-	Title  string `json:"title"`
-	Body   string `json:"body"`
+	Author     string     `json:"author"`
+	Title      string     `json:"title"`
+	Body       string     `json:"body"`
+	BodyFormat string     `json:"body_format"`
+	Language   string     `json:"language"`
+	Tags       []string   `json:"tags"`
+	Status     string     `json:"status"`
+	PublishAt  *time.Time `json:"publish_at"`
+}
+
+func (c *createArticleRequest) Normalize() {
+	c.Author = strings.TrimSpace(c.Author)
+	c.Title = strings.TrimSpace(c.Title)
+	c.Body = strings.TrimSpace(c.Body)
+	c.BodyFormat = strings.ToLower(strings.TrimSpace(c.BodyFormat))
+	c.Language = strings.ToLower(strings.TrimSpace(c.Language))
+	c.Tags = normalizeTags(c.Tags)
+	c.Status = strings.ToLower(strings.TrimSpace(c.Status))
+	if c.Status == "" && c.PublishAt != nil {
+		c.Status = model.ArticleStatusScheduled
+	}
 }
 
 func (c createArticleRequest) Validate() error {
-	if c.Author == "" {
-		return errors.New("author is blank")
+	return validation.Article(c.Article())
+}
+
+func (c createArticleRequest) Article() model.Article {
+	return model.Article{
+		Author:     c.Author,
+		Title:      c.Title,
+		Body:       c.Body,
+		BodyFormat: c.BodyFormat,
+		Language:   c.Language,
+		Tags:       c.Tags,
+		Status:     c.Status,
+		PublishAt:  c.PublishAt,
+	}
+}
+
+type updateArticleRequest struct {
+	Title      string   `json:"title"`
+	Body       string   `json:"body"`
+	BodyFormat string   `json:"body_format"`
+	Language   string   `json:"language"`
+	Tags       []string `json:"tags"`
+}
+
+func (u *updateArticleRequest) Normalize() {
+	u.Title = strings.TrimSpace(u.Title)
+	u.Body = strings.TrimSpace(u.Body)
+	u.BodyFormat = strings.ToLower(strings.TrimSpace(u.BodyFormat))
+	u.Language = strings.ToLower(strings.TrimSpace(u.Language))
+	u.Tags = normalizeTags(u.Tags)
+}
+
+func (u updateArticleRequest) Validate() error {
+	return validation.ArticleContent(u.Article(0))
+}
+
+func (u updateArticleRequest) Article(id int) model.Article {
+	return model.Article{
+		ID:         id,
+		Title:      u.Title,
+		Body:       u.Body,
+		BodyFormat: u.BodyFormat,
+		Language:   u.Language,
+		Tags:       u.Tags,
+	}
+}
+
+// decodeRequest decode a JSON request body, returning errRequestTooLarge when
+// the body exceed maxRequestBodyBytes. The body is checked to be valid UTF-8
+// before decoding, as decoding replace invalid bytes silently
+func decodeRequest(w http.ResponseWriter, r *http.Request, body interface{}) error {
+	raw, err := readRequest(w, r, maxRequestBodyBytes, errRequestTooLarge)
+	if err != nil {
+		return err
+	}
+
+	if !utf8.Valid(raw) {
+		return utf8Violations(raw)
+	}
+	return json.NewDecoder(bytes.NewReader(raw)).Decode(body)
+}
+
+// utf8Violations report the fields of a JSON object which are not valid UTF-8,
+// in their order. A value which is not an object, or whose invalid bytes are
+// outside of field values, is reported as a whole request violation
+func utf8Violations(raw []byte) error {
+	v := &validation.Validator{}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	if token, err := decoder.Token(); err == nil && token == json.Delim('{') {
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				break
+			}
+
+			var value json.RawMessage
+			if decoder.Decode(&value) != nil {
+				break
+			}
+
+			if field, _ := key.(string); !utf8.Valid(value) {
+				v.Add(field, validation.CodeInvalidUTF8, fmt.Sprintf("%s is not valid UTF-8", field))
+			}
+		}
+	}
+
+	if v.Err() == nil {
+		v.Add("request", validation.CodeInvalidUTF8, "request is not valid UTF-8")
+	}
+	return v.Err()
+}
+
+// batchItem represent an item of a batch request, or the reason it could not
+// be decoded
+type batchItem struct {
+	request createArticleRequest
+	err     error
+}
+
+// decodeBatchRequest decode create article requests from a JSON array body,
+// or a newline delimited JSON body for application/x-ndjson content type. A
+// malformed item or line is reported at its position instead of failing the
+// body
+func decodeBatchRequest(w http.ResponseWriter, r *http.Request) ([]batchItem, error) {
+	defer r.Body.Close()
+
+	body := http.MaxBytesReader(w, r.Body, maxBatchRequestBodyBytes)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	if mediaType != "application/x-ndjson" {
+		items, err := decodeBatchArray(json.NewDecoder(body))
+		if err != nil && err.Error() == "http: request body too large" {
+			return nil, errBatchRequestTooLarge
+		}
+		return items, err
+	}
+
+	items := []batchItem{}
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), maxRequestBodyBytes)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var item batchItem
+		if !utf8.Valid(line) {
+			item.err = utf8Violations(line)
+		} else if json.Unmarshal(line, &item.request) != nil {
+			item.err = errMalformedRequest
+		}
+		items = append(items, item)
+	}
+
+	err := scanner.Err()
+	if err == bufio.ErrTooLong || (err != nil && err.Error() == "http: request body too large") {
+		return nil, errBatchRequestTooLarge
 	}
-	if c.Title == "" {
-		return errors.New("title is blank")
+	return items, err
+}
+
+// decodeBatchArray decode the items of a JSON array one at a time, so an item
+// of unexpected shape fail only itself. The array itself must be well formed
+func decodeBatchArray(decoder *json.Decoder) ([]batchItem, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	} else if token != json.Delim('[') {
+		return nil, errMalformedRequest
 	}
-	if c.Body == "" {
-		return errors.New("body is blank")
+
+	items := []batchItem{}
+	for decoder.More() {
+		var raw json.RawMessage
+		err = decoder.Decode(&raw)
+		if err != nil {
+			return nil, err
+		}
+
+		var item batchItem
+		if len(raw) > maxRequestBodyBytes {
+			item.err = errRequestTooLarge
+		} else if !utf8.Valid(raw) {
+			item.err = utf8Violations(raw)
+		} else if json.Unmarshal(raw, &item.request) != nil {
+			item.err = errMalformedRequest
+		}
+		items = append(items, item)
 	}
-	return nil
+
+	_, err = decoder.Token()
+	return items, err
+}
+
+// readRequest read the whole request body, returning errTooLarge when the
+// body exceed limit bytes
+func readRequest(w http.ResponseWriter, r *http.Request, limit int64, errTooLarge error) ([]byte, error) {
+	defer r.Body.Close()
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	if err != nil && err.Error() == "http: request body too large" {
+		return nil, errTooLarge
+	}
+	return body, err
+}
+
+// ifMatchVersion parse article version expected by If-Match request header,
+// which must be an entity tag of the article. Missing or "*" header expect
+// any version and return 0
+func ifMatchVersion(r *http.Request, id int) (int, error) {
+	ifMatch := strings.TrimSpace(r.Header.Get("If-Match"))
+	if ifMatch == "" || ifMatch == "*" {
+		return 0, nil
+	}
+
+	var tagID, version int
+	_, err := fmt.Sscanf(ifMatch, `"%d-%d"`, &tagID, &version)
+	if err != nil || tagID != id || version <= 0 {
+		return 0, errors.New("if-match does not match any article version")
+	}
+
+	return version, nil
+}
+
+// searchQuery parse article search filters and pagination from query
+// parameters
+func searchQuery(queryParam url.Values) (model.ArticleSearchQuery, error) {
+	offset, _ := strconv.ParseInt(queryParam.Get("offset"), 10, 32)
+	limit, _ := strconv.ParseInt(queryParam.Get("limit"), 10, 32)
+
+	tagMode := queryParam.Get("tag_mode")
+	if tagMode != "" && tagMode != model.TagModeAny && tagMode != model.TagModeAll {
+		return model.ArticleSearchQuery{}, errInvalidTagMode
+	}
+
+	return model.ArticleSearchQuery{
+		Author:     queryParam.Get("author"),
+		Keyword:    queryParam.Get("query"),
+		Language:   strings.ToLower(queryParam.Get("language")),
+		Tags:       normalizeTags(queryParam["tag"]),
+		TagMode:    tagMode,
+		Pagination: model.Pagination{Limit: int(limit), Offset: int(offset)},
+	}, nil
+}
+
+// feedLimit parse the number of feed entries from limit query parameter,
+// default to 20 and at most 100
+func feedLimit(r *http.Request) int {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 || limit > 100 {
+		return 20
+	}
+	return limit
+}
+
+// renderHTML report whether render query parameter ask for article body
+// rendered to HTML
+func renderHTML(r *http.Request) (bool, error) {
+	switch strings.ToLower(r.URL.Query().Get("render")) {
+	case "":
+		return false, nil
+	case "html":
+		return true, nil
+	default:
+		return false, errInvalidRender
+	}
+}
+
+// isArticleStatus report whether status is one of article statuses
+func isArticleStatus(status string) bool {
+	switch status {
+	case model.ArticleStatusDraft, model.ArticleStatusScheduled, model.ArticleStatusPublished, model.ArticleStatusArchived:
+		return true
+	}
+	return false
+}
+
+// idParam parse a positive integer id from path parameter
+func idParam(param httprouter.Params, name string) (int, error) {
+	id, err := strconv.ParseInt(param.ByName(name), 10, 32)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid %s", name)
+	}
+
+	return int(id), nil
+}
+
+// normalizeTags lowercase, trim and hyphenate whitespaces of tags, dropping
+// blank and duplicate tags
+func normalizeTags(tags []string) []string {
+	var normalized []string
+	seen := map[string]bool{}
+
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		tag = tagSpacePattern.ReplaceAllString(tag, "-")
+		if tag == "" || seen[tag] {
+			continue
+		}
+
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	return normalized
 }
@@ -1,13 +1,87 @@
 package restapi
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prabudzak/article/health"
+	"github.com/prabudzak/article/logger"
+	"github.com/prabudzak/article/model"
+	"github.com/prabudzak/article/render"
+	"github.com/prabudzak/article/service"
+	"github.com/prabudzak/article/validation"
+)
+
+// Import item status
+const (
+	importStatusCreated = "created"
+	importStatusFailed  = "failed"
 )
 
+// errorStatusCodes map service error kind to HTTP status code
+var errorStatusCodes = map[service.Kind]int{
+	service.KindInternal:         http.StatusInternalServerError,
+	service.KindNotFound:         http.StatusNotFound,
+	service.KindInvalidArgument:  http.StatusBadRequest,
+	service.KindConflict:         http.StatusConflict,
+	service.KindUnavailable:      http.StatusServiceUnavailable,
+	service.KindPermissionDenied: http.StatusForbidden,
+	service.KindUnauthenticated:  http.StatusUnauthorized,
+}
+
 type response struct {
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
+	Message string            `json:"message"`
+	Code    string            `json:"code,omitempty"`
+	Data    interface{}       `json:"data,omitempty"`
+	Errors  validation.Errors `json:"errors,omitempty"`
+}
+
+type importItemResponse struct {
+	Index    int               `json:"index"`
+	Status   string            `json:"status"`
+	ID       int               `json:"id,omitempty"`
+	Location string            `json:"location,omitempty"`
+	Code     string            `json:"code,omitempty"`
+	Message  string            `json:"message,omitempty"`
+	Errors   validation.Errors `json:"errors,omitempty"`
+}
+
+type importResponse struct {
+	Created int                  `json:"created"`
+	Failed  int                  `json:"failed"`
+	Items   []importItemResponse `json:"items"`
+}
+
+// add report the outcome of importing the item at given position
+func (i *importResponse) add(ctx context.Context, index int, article model.Article, err error) {
+	if err != nil {
+		_, response := errorResponse(ctx, err)
+		i.Failed++
+		i.Items = append(i.Items, importItemResponse{
+			Index:   index,
+			Status:  importStatusFailed,
+			Code:    response.Code,
+			Message: response.Message,
+			Errors:  response.Errors,
+		})
+		return
+	}
+
+	i.Created++
+	i.Items = append(i.Items, importItemResponse{
+		Index:    index,
+		Status:   importStatusCreated,
+		ID:       article.ID,
+		Location: fmt.Sprintf("/articles/%d", article.ID),
+	})
 }
 
 func (a *API) response(w http.ResponseWriter, statusCode int, response response) {
@@ -16,10 +90,194 @@ func (a *API) response(w http.ResponseWriter, statusCode int, response response)
 	json.NewEncoder(w).Encode(response)
 }
 
-func (a *API) responseMessage(w http.ResponseWriter, statusCode int, message string) {
-	a.response(w, statusCode, response{Message: message})
+// responseHealth write a health report, with 503 status code when the service
+// is unavailable
+func (a *API) responseHealth(w http.ResponseWriter, report health.Report) {
+	statusCode := http.StatusOK
+	if report.Status == health.StatusUnavailable {
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	a.response(w, statusCode, response{Message: report.Status, Data: report})
+}
+
+// responseError write an error response with HTTP status code and machine
+// readable code derived from the service error
+func (a *API) responseError(w http.ResponseWriter, r *http.Request, err error) {
+	statusCode, response := errorResponse(r.Context(), err)
+	a.response(w, statusCode, response)
+}
+
+// errorResponse map an error to its HTTP status code and response. Validation
+// errors list every violation, internal and unavailable errors are logged and
+// hide their cause from client
+func errorResponse(ctx context.Context, err error) (int, response) {
+	var violations validation.Errors
+	if errors.As(err, &violations) {
+		return http.StatusUnprocessableEntity, response{Message: "validation failed", Code: codeValidationFailed, Errors: violations}
+	}
+
+	kind := service.KindOf(err)
+	message := err.Error()
+	switch kind {
+	case service.KindInternal:
+		logger.FromContext(ctx).Error("request failed", logger.Err(err))
+		message = "internal server error"
+	case service.KindUnavailable:
+		logger.FromContext(ctx).Error("request failed", logger.Err(err))
+		message = "service unavailable"
+	}
+
+	return errorStatusCodes[kind], response{Message: message, Code: service.CodeOf(err)}
+}
+
+// responseStatusError write an error response of a failure detected by the
+// api itself rather than the service, such as an unmet request precondition
+func (a *API) responseStatusError(w http.ResponseWriter, statusCode int, code string, err error) {
+	a.response(w, statusCode, response{Message: err.Error(), Code: code})
+}
+
+// responseDecodeError write a response for an undecodable request body
+func (a *API) responseDecodeError(w http.ResponseWriter, r *http.Request, err error) {
+	if err == errRequestTooLarge || err == errBatchRequestTooLarge {
+		a.responseStatusError(w, http.StatusRequestEntityTooLarge, codeRequestTooLarge, err)
+		return
+	}
+
+	var violations validation.Errors
+	if errors.As(err, &violations) {
+		a.responseError(w, r, err)
+		return
+	}
+
+	a.responseError(w, r, errMalformedRequest)
+}
+
+// responseCacheable write a successful read response with Cache-Control and
+// validator headers, or an empty 304 response when the request conditional
+// headers show the client copy is still fresh. ETag default to content hash
+// unless the handler already set one, so does Cache-Control. Responses to
+// authenticated callers are private
+func (a *API) responseCacheable(w http.ResponseWriter, r *http.Request, response response, lastModified time.Time) {
+	var body bytes.Buffer
+	json.NewEncoder(&body).Encode(response)
+
+	a.responseCacheableContent(w, r, "application/json", body.Bytes(), lastModified)
+}
+
+// responseCacheableContent write a successful read response of given content
+// type the same way as responseCacheable
+func (a *API) responseCacheableContent(w http.ResponseWriter, r *http.Request, contentType string, body []byte, lastModified time.Time) {
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		sum := sha256.Sum256(body)
+		etag = `"` + hex.EncodeToString(sum[:16]) + `"`
+		w.Header().Set("ETag", etag)
+	}
+
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	// responses to authenticated callers may carry unpublished articles, they
+	// must not be stored by shared caches
+	if _, ok := service.PrincipalFrom(r.Context()); ok {
+		w.Header().Set("Cache-Control", "private, no-cache")
+	} else if w.Header().Get("Cache-Control") == "" {
+		w.Header().Set("Cache-Control", a.cacheControl)
+	}
+
+	if notModified(r, etag, lastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Add("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// notModified evaluate If-None-Match, or If-Modified-Since when the former is
+// absent, against the current representation validators
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		for _, tag := range strings.Split(ifNoneMatch, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if lastModified.IsZero() {
+		return false
+	}
+
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// articleETag return strong entity tag identifying an article version
+func articleETag(article model.Article) string {
+	return fmt.Sprintf(`"%d-%d"`, article.ID, article.Version)
+}
+
+// articleRepresentation return an article as responded to client, with body
+// rendered to HTML only when asked. Articles written before body formats and
+// summaries have neither stored rendering nor summary, and are derived on read
+// when their body is retrieved
+func articleRepresentation(article model.Article, html bool) model.Article {
+	if article.WordCount == 0 && article.Body != "" {
+		article = render.Article(article)
+	}
+
+	if !html {
+		article.BodyHTML = ""
+	}
+
+	return article
+}
+
+// articleRepresentations return articles as responded to client, the same way
+// as articleRepresentation
+func articleRepresentations(articles []model.Article, html bool) []model.Article {
+	represented := make([]model.Article, 0, len(articles))
+	for _, article := range articles {
+		represented = append(represented, articleRepresentation(article, html))
+	}
+	return represented
+}
+
+// listLastModified return the Last-Modified of a list of articles last updated
+// at listed. It is the latest update time of any article rather than of the
+// listed ones only, as removing an article from the list advance the first but
+// not the second. It is left out when it can not be retrieved
+func (a *API) listLastModified(r *http.Request, listed time.Time) time.Time {
+	lastModified, err := a.articleService.LastModified(r.Context())
+	if err != nil {
+		logger.FromContext(r.Context()).Warn("article last modification not retrieved", logger.Err(err))
+		return time.Time{}
+	}
+
+	if listed.After(lastModified) {
+		return listed
+	}
+	return lastModified
 }
 
-func (a *API) responseError(w http.ResponseWriter, statusCode int, err error) {
-	a.response(w, statusCode, response{Message: err.Error()})
+// articlesLastModified return the latest update time of given articles
+func articlesLastModified(articles []model.Article) time.Time {
+	var lastModified time.Time
+	for _, article := range articles {
+		if article.UpdatedAt.After(lastModified) {
+			lastModified = article.UpdatedAt
+		}
+	}
+	return lastModified
 }
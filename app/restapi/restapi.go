@@ -1,33 +1,135 @@
 package restapi
 
 import (
-	"log"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/julienschmidt/httprouter"
 
+	"github.com/prabudzak/article/health"
+	"github.com/prabudzak/article/logger"
+	"github.com/prabudzak/article/metrics"
+	"github.com/prabudzak/article/model"
 	"github.com/prabudzak/article/service"
+	"github.com/prabudzak/article/service/policy"
+	"github.com/prabudzak/article/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/semconv"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// serverName is the HTTP server name of request spans
+const serverName = "article"
+
+// maxRequestIDLength is the longest X-Request-ID header kept as request id
+const maxRequestIDLength = 128
+
 type route struct {
-	method  string
-	path    string
-	handler httprouter.Handle
+	method     string
+	path       string
+	handler    httprouter.Handle
+	idempotent bool
+	batch      bool
 }
 
 type middleware func(route route, fn httprouter.Handle) httprouter.Handle
 
 // API represent REST API application
 type API struct {
-	articleService service.ArticleService
+	articleService     service.ArticleService
+	authorService      service.AuthorService
+	idempotencyService service.IdempotencyService
+	authService        service.AuthService
+	rateLimitService   service.RateLimitService
+	health             *health.Registry
+
+	cacheControl string
+	publicURL    string
+}
+
+// Option represent REST API application configuration option
+type Option func(a *API)
+
+// WithCacheControl set Cache-Control header of cacheable read responses.
+// Default to "no-cache", which let caches store responses but revalidate them
+// on every request
+func WithCacheControl(cacheControl string) Option {
+	return func(a *API) {
+		if cacheControl != "" {
+			a.cacheControl = cacheControl
+		}
+	}
+}
+
+// WithPublicURL set the base URL the API is publicly served at, used to link
+// articles from feeds. Default to the URL of each request, taken from its
+// client controlled Host header, in which case feeds are not publicly cached
+func WithPublicURL(publicURL string) Option {
+	return func(a *API) {
+		a.publicURL = strings.TrimRight(publicURL, "/")
+	}
+}
+
+// WithIdempotency enable replaying responses of requests retried with the same
+// Idempotency-Key header on idempotent routes
+func WithIdempotency(idempotencyService service.IdempotencyService) Option {
+	return func(a *API) {
+		a.idempotencyService = idempotencyService
+	}
+}
+
+// WithAuthentication require write routes to be called with a valid API key
+// or JWT bearer token, and unpublished articles to be read with one. Without
+// it every request runs as policy.WithSystem, bypassing every permission, so
+// every route and article is public, which is only fit for local development
+func WithAuthentication(authService service.AuthService) Option {
+	return func(a *API) {
+		a.authService = authService
+	}
+}
+
+// WithRateLimit limit requests of each client per route, responding 429 to
+// requests over the limit. Clients are identified by their authenticated
+// principal, or their IP address when anonymous. Authentication failures are
+// limited per IP address as requests to the "AUTH /failures" route
+func WithRateLimit(rateLimitService service.RateLimitService) Option {
+	return func(a *API) {
+		a.rateLimitService = rateLimitService
+	}
+}
+
+// WithHealth report the health of the components checked by registry on
+// liveness and readiness probes. Probes report ok without it
+func WithHealth(registry *health.Registry) Option {
+	return func(a *API) {
+		a.health = registry
+	}
 }
 
 // New create a new instance of REST API application
-func New(articleService service.ArticleService) *API {
-	return &API{
+func New(articleService service.ArticleService, authorService service.AuthorService, options ...Option) *API {
+	api := &API{
 		articleService: articleService,
+		authorService:  authorService,
+		health:         health.NewRegistry(),
+		cacheControl:   "no-cache",
 	}
+
+	for _, option := range options {
+		option(api)
+	}
+
+	return api
 }
 
 // Router return registered REST API path
@@ -35,17 +137,82 @@ func (a *API) Router() http.Handler {
 	router := httprouter.New()
 
 	routes := []route{
-		{method: http.MethodPost, path: "/articles", handler: a.createArticle},
+		{method: http.MethodPost, path: "/articles", handler: a.createArticle, idempotent: true},
+		{method: http.MethodPost, path: "/articles/:id/publish", handler: a.publishArticle},
+		{method: http.MethodPost, path: "/articles/:id/archive", handler: a.archiveArticle},
 		{method: http.MethodGet, path: "/articles", handler: a.listArticle},
+		{method: http.MethodGet, path: "/articles/:id", handler: a.getArticle},
+		{method: http.MethodPut, path: "/articles/:id", handler: a.updateArticle},
+		{method: http.MethodGet, path: "/articles/:id/related", handler: a.listRelatedArticle},
+		{method: http.MethodGet, path: "/articles/:id/revisions", handler: a.listArticleRevision},
+		{method: http.MethodGet, path: "/articles/:id/revisions/:rev", handler: a.getArticleRevision},
+		{method: http.MethodGet, path: "/articles/:id/revisions/:rev/diff", handler: a.diffArticleRevision},
+		{method: http.MethodPost, path: "/articles/:id/revisions/:rev/restore", handler: a.restoreArticleRevision},
+
+		{method: http.MethodGet, path: "/authors/:handle", handler: a.getAuthor},
+		{method: http.MethodGet, path: "/authors/:handle/articles", handler: a.listAuthorArticle},
+		{method: http.MethodGet, path: "/authors/:handle/feed.atom", handler: a.authorFeed},
+
+		{method: http.MethodGet, path: "/tags", handler: a.listTag},
 
-		{method: http.MethodGet, path: "/healthz", handler: a.healthz},
+		{method: http.MethodGet, path: "/feed.rss", handler: a.feedRSS},
+		{method: http.MethodGet, path: "/feed.atom", handler: a.feedAtom},
+	}
+
+	// exact routes, such as /articles:batch custom method, can not be
+	// registered to the router next to a parameterized path of the same prefix
+	exactRoutes := []route{
+		{method: http.MethodPost, path: "/articles:batch", handler: a.importArticles, idempotent: true, batch: true},
+		{method: http.MethodGet, path: "/articles/export", handler: a.exportArticle},
 	}
 
 	for _, route := range routes {
-		router.Handle(route.method, route.path, a.log(route, route.handler))
+		router.Handle(route.method, route.path, a.handle(route))
 	}
 
-	return router
+	// metrics are scraped by the monitoring system, they are neither logged
+	// nor limited
+	router.Handler(http.MethodGet, "/metrics", metrics.Handler())
+
+	// probes are frequent and checked by the orchestrator, they are neither
+	// logged nor limited. /healthz is kept for clients of the former probe
+	router.GET("/livez", a.livez)
+	router.GET("/readyz", a.readyz)
+	router.GET("/healthz", a.readyz)
+
+	exactHandlers := map[string]httprouter.Handle{}
+	for _, route := range exactRoutes {
+		exactHandlers[route.method+" "+route.path] = a.handle(route)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if handler, ok := exactHandlers[r.Method+" "+r.URL.Path]; ok {
+			handler(w, r, nil)
+			return
+		}
+
+		router.ServeHTTP(w, r)
+	})
+}
+
+// handle wrap route handler with its middlewares
+func (a *API) handle(route route) httprouter.Handle {
+	handler := route.handler
+	if route.idempotent {
+		handler = a.idempotent(route, handler)
+	}
+
+	if a.rateLimitService != nil {
+		handler = a.rateLimit(route, handler)
+	}
+
+	handler = a.authenticate(route, handler)
+
+	if a.rateLimitService != nil {
+		handler = a.limitAuthFailures(handler)
+	}
+
+	return a.trace(route, a.requestID(route, a.log(route, handler)))
 }
 
 type wrapperResponseWriter struct {
@@ -65,6 +232,51 @@ func (w *wrapperResponseWriter) WriteHeader(code int) {
 	w.ResponseWriter.WriteHeader(code)
 }
 
+func (w *wrapperResponseWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush send buffered data to client, so streamed responses such as exports
+// are not held back by the wrapper
+func (w *wrapperResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+type recordResponseWriter struct {
+	http.ResponseWriter
+
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (w *recordResponseWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+
+	w.wroteHeader = true
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *recordResponseWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush send buffered data to client the same way as wrapperResponseWriter,
+// the whole body is still recorded
+func (w *recordResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
 func (a *API) log(route route, fn httprouter.Handle) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
 		start := time.Now()
@@ -72,6 +284,304 @@ func (a *API) log(route route, fn httprouter.Handle) httprouter.Handle {
 		writer := &wrapperResponseWriter{ResponseWriter: w}
 		fn(writer, r, param)
 
-		log.Printf("%d %s %s in %dms\n", writer.status, route.method, route.path, time.Since(start).Milliseconds())
+		duration := time.Since(start)
+		metrics.ObserveRequest(route.path, route.method, writer.status, duration)
+		logger.FromContext(r.Context()).Info("request served",
+			logger.Int("status", writer.status),
+			logger.String("method", route.method),
+			logger.String("route", route.path),
+			logger.Duration("duration_ms", duration),
+		)
+	}
+}
+
+// requestID identify a request by its X-Request-ID header, or a generated id
+// when the header is missing or unfit, echoed in the response header. Every
+// log of the request carry its id
+func (a *API) requestID(route route, fn httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+		id := r.Header.Get("X-Request-ID")
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set("X-Request-ID", id)
+
+		ctx := logger.With(r.Context(), logger.RequestID(id))
+		if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+			ctx = logger.With(ctx, logger.String("trace_id", spanContext.TraceID.String()))
+		}
+
+		fn(w, r.WithContext(ctx), param)
+	}
+}
+
+// validRequestID report whether a client request id is short printable ASCII,
+// safe to log and echo
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID generate a random request id
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// trace record a request as a server span named after its route, continuing
+// the W3C trace context of the request headers, if any
+func (a *API) trace(route route, fn httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), r.Header)
+		ctx, span := tracing.Start(ctx, route.method+" "+route.path,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(semconv.HTTPServerAttributesFromHTTPRequest(serverName, route.path, r)...),
+		)
+		defer span.End()
+
+		writer := &wrapperResponseWriter{ResponseWriter: w}
+		fn(writer, r.WithContext(ctx), param)
+
+		status := writer.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		// client errors are the outcome of a request served well
+		span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(status)...)
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(semconv.SpanStatusFromHTTPStatusCode(status))
+		}
+	}
+}
+
+// authenticate reject a request without valid X-API-Key header or
+// Authorization bearer token, and put the authenticated principal into the
+// request context. Read routes are served anonymously without credentials,
+// and to the principal of given ones, which may read unpublished articles
+func (a *API) authenticate(route route, fn httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+		if a.authService == nil {
+			fn(w, r.WithContext(policy.WithSystem(r.Context())), param)
+			return
+		}
+
+		var principal model.Principal
+		var err error
+		if key := r.Header.Get("X-API-Key"); key != "" {
+			principal, err = a.authService.AuthenticateAPIKey(r.Context(), key)
+		} else if token, ok := bearerToken(r); ok {
+			principal, err = a.authService.AuthenticateToken(r.Context(), token)
+		} else if route.method == http.MethodGet {
+			fn(w, r, param)
+			return
+		} else {
+			err = errMissingCredentials
+		}
+
+		if err != nil {
+			if service.KindOf(err) == service.KindUnauthenticated {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="article"`)
+			}
+			a.responseError(w, r, err)
+			return
+		}
+
+		fn(w, r.WithContext(service.WithPrincipal(r.Context(), principal)), param)
+	}
+}
+
+// bearerToken return the token of Authorization bearer header
+func bearerToken(r *http.Request) (string, bool) {
+	parts := strings.SplitN(r.Header.Get("Authorization"), " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+		return "", false
+	}
+
+	token := strings.TrimSpace(parts[1])
+	return token, token != ""
+}
+
+// rateLimit take a token from the bucket of the client on the route, and
+// reject the request with 429 when the bucket is empty. Requests are let
+// through when the limit can not be checked
+func (a *API) rateLimit(route route, fn httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+		result, err := a.rateLimitService.Allow(r.Context(), requestClient(r), route.method, route.path)
+		if err != nil {
+			logger.FromContext(r.Context()).Warn("rate limit not checked", logger.Err(err))
+			fn(w, r, param)
+			return
+		}
+
+		if result.Limit > 0 {
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			w.Header().Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+		}
+
+		if !result.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			a.responseStatusError(w, http.StatusTooManyRequests, codeRateLimited, errRateLimited)
+			return
+		}
+
+		fn(w, r, param)
+	}
+}
+
+// limitAuthFailures reject requests with credentials with 429 once their IP
+// address failed to authenticate too often, before the credentials are
+// checked. Only failures take a token from the bucket of the address, so
+// authenticated clients sharing an address are not limited together
+func (a *API) limitAuthFailures(fn httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+		if _, ok := bearerToken(r); !ok && r.Header.Get("X-API-Key") == "" {
+			fn(w, r, param)
+			return
+		}
+
+		client := addressClient(r)
+		result, err := a.rateLimitService.Check(r.Context(), client, authFailureMethod, authFailurePath)
+		if err != nil {
+			logger.FromContext(r.Context()).Warn("rate limit not checked", logger.Err(err))
+		} else if !result.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			a.responseStatusError(w, http.StatusTooManyRequests, codeRateLimited, errRateLimited)
+			return
+		}
+
+		writer := &wrapperResponseWriter{ResponseWriter: w}
+		fn(writer, r, param)
+
+		if writer.status == http.StatusUnauthorized {
+			_, err = a.rateLimitService.Allow(r.Context(), client, authFailureMethod, authFailurePath)
+			if err != nil {
+				logger.FromContext(r.Context()).Warn("authentication failure not counted", logger.Err(err))
+			}
+		}
+	}
+}
+
+// requestClient identify the client of a request by its authenticated
+// principal, or by its IP address, to scope its rate limit and idempotency
+// keys
+func requestClient(r *http.Request) string {
+	if principal, ok := service.PrincipalFrom(r.Context()); ok {
+		return principal.Method + ":" + principal.Subject
+	}
+	return addressClient(r)
+}
+
+// addressClient identify the client of a request by its IP address
+func addressClient(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// replayedHeader return the headers set by idempotent route handlers, leaving
+// out those of the outer middlewares such as X-Request-ID and X-RateLimit-*,
+// which describe the original request rather than its retries
+func replayedHeader(header http.Header) map[string][]string {
+	replayed := make(map[string][]string)
+	for _, name := range replayedHeaders {
+		if values, ok := header[name]; ok {
+			replayed[name] = values
+		}
+	}
+
+	return replayed
+}
+
+// ceilSeconds return duration in whole seconds, rounded up
+func ceilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
+// idempotent process a request with Idempotency-Key header at most once,
+// replaying the stored response to its retries. Key reused for a different
+// request is rejected. Failed request is forgotten so it can be retried
+func (a *API) idempotent(route route, fn httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, param httprouter.Params) {
+		key := r.Header.Get("Idempotency-Key")
+		if a.idempotencyService == nil || key == "" {
+			fn(w, r, param)
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			a.responseError(w, r, errInvalidIdempotencyKey)
+			return
+		}
+
+		limit, errTooLarge := int64(maxRequestBodyBytes), errRequestTooLarge
+		if route.batch {
+			limit, errTooLarge = maxBatchRequestBodyBytes, errBatchRequestTooLarge
+		}
+
+		body, err := readRequest(w, r, limit, errTooLarge)
+		if err != nil {
+			a.responseDecodeError(w, r, err)
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		// keys of different clients or routes never replay each other responses
+		key = requestClient(r) + " " + route.method + " " + r.URL.Path + " " + key
+		sum := sha256.Sum256(body)
+		requestHash := hex.EncodeToString(sum[:])
+
+		record, err := a.idempotencyService.Begin(r.Context(), key, requestHash)
+		if errors.Is(err, service.ErrIdempotencyKeyReused) {
+			a.responseStatusError(w, http.StatusUnprocessableEntity, service.CodeOf(err), err)
+			return
+		} else if err != nil {
+			a.responseError(w, r, err)
+			return
+		}
+
+		if record.Completed {
+			for _, name := range replayedHeaders {
+				if values, ok := record.Header[name]; ok {
+					w.Header()[name] = values
+				}
+			}
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(record.StatusCode)
+			w.Write(record.Body)
+			return
+		}
+
+		writer := &recordResponseWriter{ResponseWriter: w}
+		fn(writer, r, param)
+
+		// the client may have gone already, the outcome is stored regardless
+		ctx := logger.NewContext(context.Background(), logger.FromContext(r.Context()))
+		if writer.status >= http.StatusInternalServerError {
+			err = a.idempotencyService.Release(ctx, key)
+		} else {
+			err = a.idempotencyService.Complete(ctx, model.IdempotencyRecord{
+				Key:         key,
+				RequestHash: requestHash,
+				StatusCode:  writer.status,
+				Header:      replayedHeader(writer.Header()),
+				Body:        writer.body.Bytes(),
+			})
+		}
+		if err != nil {
+			logger.FromContext(ctx).Error("idempotency outcome not stored", logger.Err(err))
+		}
 	}
 }
@@ -1,25 +1,53 @@
 package restapi_test
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/prabudzak/article/app/restapi"
+	"github.com/prabudzak/article/health"
+	"github.com/prabudzak/article/logger"
 	"github.com/prabudzak/article/model"
+	"github.com/prabudzak/article/service"
+	"github.com/prabudzak/article/service/article"
+	articlemock "github.com/prabudzak/article/service/article/mock"
 	"github.com/prabudzak/article/service/mock"
+	"github.com/prabudzak/article/service/ratelimit"
+	ratelimitmemory "github.com/prabudzak/article/service/ratelimit/memory"
+	"github.com/prabudzak/article/tracing"
+	"github.com/prabudzak/article/validation"
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/oteltest"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type dependency struct {
-	articleService *mock.MockArticleService
+	articleService     *mock.MockArticleService
+	authorService      *mock.MockAuthorService
+	idempotencyService *mock.MockIdempotencyService
+	authService        *mock.MockAuthService
+	rateLimitService   *mock.MockRateLimitService
 }
 
 func initialize(ctrl *gomock.Controller) dependency {
 	return dependency{
-		articleService: mock.NewMockArticleService(ctrl),
+		articleService:     mock.NewMockArticleService(ctrl),
+		authorService:      mock.NewMockAuthorService(ctrl),
+		idempotencyService: mock.NewMockIdempotencyService(ctrl),
+		authService:        mock.NewMockAuthService(ctrl),
+		rateLimitService:   mock.NewMockRateLimitService(ctrl),
 	}
 }
 
@@ -48,6 +76,11 @@ func TestCreateArticle(t *testing.T) {
 			`,
 			expectedStatusCode: http.StatusBadRequest,
 		},
+		{
+			name:               "body is not valid UTF-8",
+			body:               "{\"author\": \"john doe\", \"title\": \"A Valid Title\", \"body\": \"content \xff\"}",
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
 		{
 			name: "author is blank",
 			body: `
@@ -78,6 +111,115 @@ func TestCreateArticle(t *testing.T) {
 			`,
 			expectedStatusCode: http.StatusUnprocessableEntity,
 		},
+		{
+			name: "success created, with language",
+			body: `
+				{
+					"author": "john doe",
+					"title": "A Valid Title",
+					"body": "A very interesting content",
+					"language": " EN "
+				}
+			`,
+			expectedStatusCode: http.StatusCreated,
+		},
+		{
+			name: "success created, with tags",
+			body: `
+				{
+					"author": "john doe",
+					"title": "A Valid Title",
+					"body": "A very interesting content",
+					"tags": ["Go", " go ", "Event Driven"]
+				}
+			`,
+			expectedStatusCode: http.StatusCreated,
+		},
+		{
+			name: "tag is invalid",
+			body: `
+				{
+					"author": "john doe",
+					"title": "A Valid Title",
+					"body": "A very interesting content",
+					"tags": ["c++"]
+				}
+			`,
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "too many tags",
+			body: `
+				{
+					"author": "john doe",
+					"title": "A Valid Title",
+					"body": "A very interesting content",
+					"tags": ["a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k"]
+				}
+			`,
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "success created, as draft",
+			body: `
+				{
+					"author": "john doe",
+					"title": "A Valid Title",
+					"body": "A very interesting content",
+					"status": "draft"
+				}
+			`,
+			expectedStatusCode: http.StatusCreated,
+		},
+		{
+			name: "success created, scheduled",
+			body: `
+				{
+					"author": "john doe",
+					"title": "A Valid Title",
+					"body": "A very interesting content",
+					"publish_at": "2100-01-01T00:00:00Z"
+				}
+			`,
+			expectedStatusCode: http.StatusCreated,
+		},
+		{
+			name: "scheduled in the past",
+			body: `
+				{
+					"author": "john doe",
+					"title": "A Valid Title",
+					"body": "A very interesting content",
+					"status": "scheduled",
+					"publish_at": "2000-01-01T00:00:00Z"
+				}
+			`,
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "status is invalid",
+			body: `
+				{
+					"author": "john doe",
+					"title": "A Valid Title",
+					"body": "A very interesting content",
+					"status": "archived"
+				}
+			`,
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "language is invalid",
+			body: `
+				{
+					"author": "john doe",
+					"title": "A Valid Title",
+					"body": "A very interesting content",
+					"language": "english"
+				}
+			`,
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
 		{
 			name: "unable to create article",
 			body: `
@@ -98,9 +240,9 @@ func TestCreateArticle(t *testing.T) {
 			defer ctrl.Finish()
 
 			dep := initialize(ctrl)
-			dep.articleService.EXPECT().CreateArticle(gomock.Any(), gomock.Any()).MaxTimes(1).Return(tc.createArticleErr)
+			dep.articleService.EXPECT().CreateArticle(gomock.Any(), gomock.Any()).MaxTimes(1).Return(model.Article{ID: 12, Version: 1}, tc.createArticleErr)
 
-			api := restapi.New(dep.articleService)
+			api := restapi.New(dep.articleService, dep.authorService)
 			router := api.Router()
 			server := httptest.NewServer(router)
 			defer server.Close()
@@ -109,6 +251,17 @@ func TestCreateArticle(t *testing.T) {
 			resp, err := http.DefaultClient.Post(url, "appplication/json", strings.NewReader(tc.body))
 			assert.NoError(t, err)
 			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+
+			if tc.expectedStatusCode == http.StatusCreated {
+				var body struct {
+					Data model.Article `json:"data"`
+				}
+				err = json.NewDecoder(resp.Body).Decode(&body)
+				assert.NoError(t, err)
+				assert.Equal(t, 12, body.Data.ID)
+				assert.Equal(t, "/articles/12", resp.Header.Get("Location"))
+				assert.Equal(t, `"12-1"`, resp.Header.Get("ETag"))
+			}
 		})
 	}
 }
@@ -163,6 +316,32 @@ func TestListArticle(t *testing.T) {
 			},
 			expectedStatusCode: http.StatusOK,
 		},
+		{
+			name: "articles retrieved, with language param",
+			path: "/articles?query=some%20keyword&language=EN",
+			expectedQuery: model.ArticleSearchQuery{
+				Author:     "",
+				Keyword:    "some keyword",
+				Language:   "en",
+				Pagination: model.Pagination{Limit: 0, Offset: 0},
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: "articles retrieved, with tag params",
+			path: "/articles?tag=Go&tag=redis&tag=go&tag_mode=all",
+			expectedQuery: model.ArticleSearchQuery{
+				Tags:       []string{"go", "redis"},
+				TagMode:    model.TagModeAll,
+				Pagination: model.Pagination{Limit: 0, Offset: 0},
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "invalid tag mode",
+			path:               "/articles?tag=go&tag_mode=some",
+			expectedStatusCode: http.StatusBadRequest,
+		},
 		{
 			name:             "unable to retreive articles",
 			path:             "/articles",
@@ -184,8 +363,279 @@ func TestListArticle(t *testing.T) {
 
 			dep := initialize(ctrl)
 			dep.articleService.EXPECT().SearchArticle(gomock.Any(), tc.expectedQuery).MaxTimes(1).Return(tc.searchArticle, tc.searchArticleErr)
+			dep.articleService.EXPECT().LastModified(gomock.Any()).AnyTimes().Return(time.Time{}, nil)
+
+			api := restapi.New(dep.articleService, dep.authorService)
+			router := api.Router()
+			server := httptest.NewServer(router)
+			defer server.Close()
+
+			url := server.URL + tc.path
+			resp, err := http.DefaultClient.Get(url)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+		})
+	}
+}
+
+func TestListArticleSummary(t *testing.T) {
+	tests := []struct {
+		name               string
+		path               string
+		expectedStatusCode int
+		expectBody         bool
+	}{
+		{
+			name:               "whole articles by default",
+			path:               "/articles",
+			expectedStatusCode: http.StatusOK,
+			expectBody:         true,
+		},
+		{
+			name:               "summaries",
+			path:               "/articles?fields=summary",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "invalid fields",
+			path:               "/articles?fields=everything",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dep := initialize(ctrl)
+			dep.articleService.EXPECT().SearchArticle(gomock.Any(), gomock.Any()).MaxTimes(1).Return([]model.Article{
+				{ID: 1, Title: "First", Body: "First sentence. Second sentence.", BodyFormat: model.BodyFormatPlain, Excerpt: "First sentence. Second sentence.", WordCount: 4, ReadingTime: 1},
+			}, nil)
+			dep.articleService.EXPECT().LastModified(gomock.Any()).AnyTimes().Return(time.Time{}, nil)
+
+			api := restapi.New(dep.articleService, dep.authorService)
+			server := httptest.NewServer(api.Router())
+			defer server.Close()
+
+			resp, err := http.DefaultClient.Get(server.URL + tc.path)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+			if tc.expectedStatusCode != http.StatusOK {
+				return
+			}
+
+			var body struct {
+				Data []map[string]interface{} `json:"data"`
+			}
+			assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			assert.Len(t, body.Data, 1)
+
+			_, hasBody := body.Data[0]["body"]
+			assert.Equal(t, tc.expectBody, hasBody)
+			assert.Equal(t, "First sentence. Second sentence.", body.Data[0]["excerpt"])
+			assert.Equal(t, float64(4), body.Data[0]["word_count"])
+			assert.Equal(t, float64(1), body.Data[0]["reading_time"])
+		})
+	}
+}
+
+func TestListArticleFields(t *testing.T) {
+	createdAt := time.Date(2021, 1, 30, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name               string
+		path               string
+		expectedFields     []string
+		expectedStatusCode int
+		expectedData       string
+	}{
+		{
+			name:               "selected fields",
+			path:               "/articles?fields=id,title,author,created_at,id",
+			expectedFields:     []string{"id", "title", "author", "created_at"},
+			expectedStatusCode: http.StatusOK,
+			expectedData:       `[{"author":"John Doe","created_at":"2021-01-30T10:00:00Z","id":1,"title":"First"}]`,
+		},
+		{
+			name:               "empty optional field left out",
+			path:               "/articles?fields=id,%20publish_at",
+			expectedFields:     []string{"id", "publish_at"},
+			expectedStatusCode: http.StatusOK,
+			expectedData:       `[{"id":1}]`,
+		},
+		{
+			name:               "rendered body",
+			path:               "/articles?fields=id,body_html",
+			expectedFields:     []string{"id", "body_html"},
+			expectedStatusCode: http.StatusOK,
+			expectedData:       `[{"body_html":"\u003cp\u003eFirst body\u003c/p\u003e\n","id":1}]`,
+		},
+		{
+			name:               "unknown field",
+			path:               "/articles?fields=id,password",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "field names are case insensitive",
+			path:               "/articles?fields=Title",
+			expectedFields:     []string{"title"},
+			expectedStatusCode: http.StatusOK,
+			expectedData:       `[{"title":"First"}]`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dep := initialize(ctrl)
+			dep.articleService.EXPECT().SearchArticle(gomock.Any(), model.ArticleSearchQuery{Fields: tc.expectedFields}).MaxTimes(1).Return([]model.Article{
+				{ID: 1, Title: "First", Body: "First body", Author: "John Doe", CreatedAt: createdAt},
+			}, nil)
+			dep.articleService.EXPECT().LastModified(gomock.Any()).AnyTimes().Return(time.Time{}, nil)
+
+			api := restapi.New(dep.articleService, dep.authorService)
+			server := httptest.NewServer(api.Router())
+			defer server.Close()
+
+			resp, err := http.DefaultClient.Get(server.URL + tc.path)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+
+			var body struct {
+				Code string          `json:"code"`
+				Data json.RawMessage `json:"data"`
+			}
+			assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			if tc.expectedStatusCode != http.StatusOK {
+				assert.Equal(t, "invalid_fields", body.Code)
+				return
+			}
+			assert.Equal(t, tc.expectedData, string(body.Data))
+		})
+	}
+}
+
+func TestListRelatedArticle(t *testing.T) {
+	tests := []struct {
+		name               string
+		path               string
+		relatedArticle     []model.Article
+		relatedArticleErr  error
+		expectedQuery      model.ArticleRelatedQuery
+		expectedStatusCode int
+	}{
+		{
+			name:           "related articles retrieved",
+			path:           "/articles/12/related",
+			relatedArticle: []model.Article{},
+			expectedQuery: model.ArticleRelatedQuery{
+				ArticleID:  12,
+				Pagination: model.Pagination{Limit: 0, Offset: 0},
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: "related articles retrieved, with same author and pagination param",
+			path: "/articles/12/related?same_author=true&limit=3&offset=1",
+			expectedQuery: model.ArticleRelatedQuery{
+				ArticleID:  12,
+				SameAuthor: true,
+				Pagination: model.Pagination{Limit: 3, Offset: 1},
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "invalid article id",
+			path:               "/articles/abc/related",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:              "article not found",
+			path:              "/articles/12/related",
+			relatedArticleErr: service.ErrArticleNotFound,
+			expectedQuery: model.ArticleRelatedQuery{
+				ArticleID: 12,
+			},
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:              "unable to retrieve related articles",
+			path:              "/articles/12/related",
+			relatedArticleErr: assert.AnError,
+			expectedQuery: model.ArticleRelatedQuery{
+				ArticleID: 12,
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dep := initialize(ctrl)
+			dep.articleService.EXPECT().RelatedArticle(gomock.Any(), tc.expectedQuery).MaxTimes(1).Return(tc.relatedArticle, tc.relatedArticleErr)
+			dep.articleService.EXPECT().LastModified(gomock.Any()).AnyTimes().Return(time.Time{}, nil)
+
+			api := restapi.New(dep.articleService, dep.authorService)
+			router := api.Router()
+			server := httptest.NewServer(router)
+			defer server.Close()
 
-			api := restapi.New(dep.articleService)
+			url := server.URL + tc.path
+			resp, err := http.DefaultClient.Get(url)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+		})
+	}
+}
+
+func TestListTag(t *testing.T) {
+	tests := []struct {
+		name               string
+		path               string
+		listTag            []model.TagCount
+		listTagErr         error
+		expectedQuery      model.TagQuery
+		expectedStatusCode int
+	}{
+		{
+			name:               "tags retrieved",
+			path:               "/tags",
+			listTag:            []model.TagCount{{Tag: "go", Count: 3}},
+			expectedQuery:      model.TagQuery{Limit: 0},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "tags retrieved, with limit param",
+			path:               "/tags?limit=10",
+			expectedQuery:      model.TagQuery{Limit: 10},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "unable to retrieve tags",
+			path:               "/tags",
+			listTagErr:         assert.AnError,
+			expectedQuery:      model.TagQuery{Limit: 0},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dep := initialize(ctrl)
+			dep.articleService.EXPECT().ListTag(gomock.Any(), tc.expectedQuery).MaxTimes(1).Return(tc.listTag, tc.listTagErr)
+
+			api := restapi.New(dep.articleService, dep.authorService)
 			router := api.Router()
 			server := httptest.NewServer(router)
 			defer server.Close()
@@ -197,3 +647,2264 @@ func TestListArticle(t *testing.T) {
 		})
 	}
 }
+
+func TestGetAuthor(t *testing.T) {
+	tests := []struct {
+		name               string
+		path               string
+		getAuthorErr       error
+		expectedHandle     string
+		expectedStatusCode int
+	}{
+		{
+			name:               "author retrieved",
+			path:               "/authors/john-doe",
+			expectedHandle:     "john-doe",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "author not found",
+			path:               "/authors/jane-doe",
+			getAuthorErr:       service.ErrAuthorNotFound,
+			expectedHandle:     "jane-doe",
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "unable to retrieve author",
+			path:               "/authors/john-doe",
+			getAuthorErr:       assert.AnError,
+			expectedHandle:     "john-doe",
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dep := initialize(ctrl)
+			dep.authorService.EXPECT().GetAuthor(gomock.Any(), tc.expectedHandle).MaxTimes(1).Return(model.Author{Handle: tc.expectedHandle}, tc.getAuthorErr)
+
+			api := restapi.New(dep.articleService, dep.authorService)
+			router := api.Router()
+			server := httptest.NewServer(router)
+			defer server.Close()
+
+			url := server.URL + tc.path
+			resp, err := http.DefaultClient.Get(url)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+		})
+	}
+}
+
+func TestListAuthorArticle(t *testing.T) {
+	tests := []struct {
+		name               string
+		path               string
+		getAuthorErr       error
+		searchArticleErr   error
+		expectedQuery      model.ArticleSearchQuery
+		expectedStatusCode int
+	}{
+		{
+			name: "author articles retrieved",
+			path: "/authors/john-doe/articles?limit=10&offset=20",
+			expectedQuery: model.ArticleSearchQuery{
+				Author:     "john-doe",
+				Pagination: model.Pagination{Limit: 10, Offset: 20},
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "author not found",
+			path:               "/authors/john-doe/articles",
+			getAuthorErr:       service.ErrAuthorNotFound,
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "unable to retrieve author",
+			path:               "/authors/john-doe/articles",
+			getAuthorErr:       assert.AnError,
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name:             "unable to retrieve author articles",
+			path:             "/authors/john-doe/articles",
+			searchArticleErr: assert.AnError,
+			expectedQuery: model.ArticleSearchQuery{
+				Author: "john-doe",
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dep := initialize(ctrl)
+			dep.authorService.EXPECT().GetAuthor(gomock.Any(), "john-doe").MaxTimes(1).Return(model.Author{Handle: "john-doe"}, tc.getAuthorErr)
+			dep.articleService.EXPECT().SearchArticle(gomock.Any(), tc.expectedQuery).MaxTimes(1).Return([]model.Article{}, tc.searchArticleErr)
+			dep.articleService.EXPECT().LastModified(gomock.Any()).AnyTimes().Return(time.Time{}, nil)
+
+			api := restapi.New(dep.articleService, dep.authorService)
+			router := api.Router()
+			server := httptest.NewServer(router)
+			defer server.Close()
+
+			url := server.URL + tc.path
+			resp, err := http.DefaultClient.Get(url)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+		})
+	}
+}
+
+func TestPublishArticle(t *testing.T) {
+	tests := []struct {
+		name               string
+		path               string
+		publishArticleErr  error
+		expectedID         int
+		expectedStatusCode int
+	}{
+		{
+			name:               "article published",
+			path:               "/articles/12/publish",
+			expectedID:         12,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "invalid article id",
+			path:               "/articles/abc/publish",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "article not found",
+			path:               "/articles/12/publish",
+			publishArticleErr:  service.ErrArticleNotFound,
+			expectedID:         12,
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "article archived",
+			path:               "/articles/12/publish",
+			publishArticleErr:  service.ErrArticleArchived,
+			expectedID:         12,
+			expectedStatusCode: http.StatusConflict,
+		},
+		{
+			name:               "permission denied",
+			path:               "/articles/12/publish",
+			publishArticleErr:  service.ErrPermissionDenied,
+			expectedID:         12,
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "unable to publish article",
+			path:               "/articles/12/publish",
+			publishArticleErr:  assert.AnError,
+			expectedID:         12,
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dep := initialize(ctrl)
+			dep.articleService.EXPECT().PublishArticle(gomock.Any(), tc.expectedID).MaxTimes(1).Return(model.Article{ID: tc.expectedID}, tc.publishArticleErr)
+
+			api := restapi.New(dep.articleService, dep.authorService)
+			router := api.Router()
+			server := httptest.NewServer(router)
+			defer server.Close()
+
+			url := server.URL + tc.path
+			resp, err := http.DefaultClient.Post(url, "application/json", nil)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+		})
+	}
+}
+
+func TestArchiveArticle(t *testing.T) {
+	tests := []struct {
+		name               string
+		path               string
+		archiveArticleErr  error
+		expectedID         int
+		expectedStatusCode int
+	}{
+		{
+			name:               "article archived",
+			path:               "/articles/12/archive",
+			expectedID:         12,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "invalid article id",
+			path:               "/articles/abc/archive",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "article not found",
+			path:               "/articles/12/archive",
+			archiveArticleErr:  service.ErrArticleNotFound,
+			expectedID:         12,
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "permission denied",
+			path:               "/articles/12/archive",
+			archiveArticleErr:  service.ErrPermissionDenied,
+			expectedID:         12,
+			expectedStatusCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dep := initialize(ctrl)
+			dep.articleService.EXPECT().ArchiveArticle(gomock.Any(), tc.expectedID).MaxTimes(1).Return(model.Article{ID: tc.expectedID, Status: model.ArticleStatusArchived, Version: 4}, tc.archiveArticleErr)
+
+			api := restapi.New(dep.articleService, dep.authorService)
+			server := httptest.NewServer(api.Router())
+			defer server.Close()
+
+			resp, err := http.DefaultClient.Post(server.URL+tc.path, "application/json", nil)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+			if tc.expectedStatusCode == http.StatusOK {
+				assert.Equal(t, `"12-4"`, resp.Header.Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	editor := model.Principal{Subject: "42", Role: model.RoleEditor, Method: model.AuthMethodJWT}
+
+	tests := []struct {
+		name               string
+		method             string
+		path               string
+		header             map[string]string
+		apiKeyErr          error
+		tokenErr           error
+		expectedAPIKey     string
+		expectedToken      string
+		expectedStatusCode int
+		expectChallenge    bool
+	}{
+		{
+			name:               "api key authenticated",
+			method:             http.MethodPost,
+			path:               "/articles/12/publish",
+			header:             map[string]string{"X-API-Key": "ak_secret"},
+			expectedAPIKey:     "ak_secret",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "bearer token authenticated",
+			method:             http.MethodPost,
+			path:               "/articles/12/publish",
+			header:             map[string]string{"Authorization": "Bearer a.b.c"},
+			expectedToken:      "a.b.c",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "missing credentials",
+			method:             http.MethodPost,
+			path:               "/articles/12/publish",
+			expectedStatusCode: http.StatusUnauthorized,
+			expectChallenge:    true,
+		},
+		{
+			name:               "non bearer authorization",
+			method:             http.MethodPost,
+			path:               "/articles/12/publish",
+			header:             map[string]string{"Authorization": "Basic dXNlcjpwYXNz"},
+			expectedStatusCode: http.StatusUnauthorized,
+			expectChallenge:    true,
+		},
+		{
+			name:               "invalid api key",
+			method:             http.MethodPost,
+			path:               "/articles/12/publish",
+			header:             map[string]string{"X-API-Key": "ak_unknown"},
+			apiKeyErr:          service.Unauthenticated(errors.New("api key is invalid")),
+			expectedAPIKey:     "ak_unknown",
+			expectedStatusCode: http.StatusUnauthorized,
+			expectChallenge:    true,
+		},
+		{
+			name:               "invalid bearer token",
+			method:             http.MethodPost,
+			path:               "/articles/12/publish",
+			header:             map[string]string{"Authorization": "Bearer a.b.c"},
+			tokenErr:           service.Unauthenticated(errors.New("token is expired")),
+			expectedToken:      "a.b.c",
+			expectedStatusCode: http.StatusUnauthorized,
+			expectChallenge:    true,
+		},
+		{
+			name:               "api key lookup failure",
+			method:             http.MethodPost,
+			path:               "/articles/12/publish",
+			header:             map[string]string{"X-API-Key": "ak_secret"},
+			apiKeyErr:          assert.AnError,
+			expectedAPIKey:     "ak_secret",
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name:               "update require credentials",
+			method:             http.MethodPut,
+			path:               "/articles/12",
+			expectedStatusCode: http.StatusUnauthorized,
+			expectChallenge:    true,
+		},
+		{
+			name:               "batch import require credentials",
+			method:             http.MethodPost,
+			path:               "/articles:batch",
+			expectedStatusCode: http.StatusUnauthorized,
+			expectChallenge:    true,
+		},
+		{
+			name:               "read is public",
+			method:             http.MethodGet,
+			path:               "/articles/12",
+			expectedStatusCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dep := initialize(ctrl)
+			dep.authService.EXPECT().AuthenticateAPIKey(gomock.Any(), tc.expectedAPIKey).MaxTimes(1).Return(editor, tc.apiKeyErr)
+			dep.authService.EXPECT().AuthenticateToken(gomock.Any(), tc.expectedToken).MaxTimes(1).Return(editor, tc.tokenErr)
+			dep.articleService.EXPECT().PublishArticle(gomock.Any(), 12).MaxTimes(1).DoAndReturn(func(ctx context.Context, id int) (model.Article, error) {
+				principal, ok := service.PrincipalFrom(ctx)
+				assert.True(t, ok)
+				assert.Equal(t, editor, principal)
+				return model.Article{ID: id}, nil
+			})
+			dep.articleService.EXPECT().GetArticle(gomock.Any(), 12).MaxTimes(1).Return(model.Article{ID: 12}, nil)
+
+			api := restapi.New(dep.articleService, dep.authorService, restapi.WithAuthentication(dep.authService))
+			router := api.Router()
+			server := httptest.NewServer(router)
+			defer server.Close()
+
+			req, err := http.NewRequest(tc.method, server.URL+tc.path, nil)
+			assert.NoError(t, err)
+			for name, value := range tc.header {
+				req.Header.Set(name, value)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+			assert.Equal(t, tc.expectChallenge, resp.Header.Get("WWW-Authenticate") != "")
+		})
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	editor := model.Principal{Subject: "42", Role: model.RoleEditor, Method: model.AuthMethodAPIKey}
+
+	type allow struct {
+		client string
+		result model.RateLimitResult
+		err    error
+	}
+
+	tests := []struct {
+		name               string
+		method             string
+		path               string
+		header             map[string]string
+		allows             []allow
+		expectedRoute      string
+		expectedStatusCode int
+		expectedHeader     map[string]string
+	}{
+		{
+			name:               "allowed",
+			method:             http.MethodGet,
+			path:               "/articles/12",
+			allows:             []allow{{client: "ip:127.0.0.1", result: model.RateLimitResult{Allowed: true, Limit: 60, Remaining: 59, Reset: 1500 * time.Millisecond}}},
+			expectedRoute:      "/articles/:id",
+			expectedStatusCode: http.StatusOK,
+			expectedHeader:     map[string]string{"X-RateLimit-Limit": "60", "X-RateLimit-Remaining": "59", "X-RateLimit-Reset": "2", "Retry-After": ""},
+		},
+		{
+			name:               "limited",
+			method:             http.MethodGet,
+			path:               "/articles/12",
+			allows:             []allow{{client: "ip:127.0.0.1", result: model.RateLimitResult{Allowed: false, Limit: 60, Remaining: 0, Reset: time.Minute, RetryAfter: 800 * time.Millisecond}}},
+			expectedRoute:      "/articles/:id",
+			expectedStatusCode: http.StatusTooManyRequests,
+			expectedHeader:     map[string]string{"X-RateLimit-Limit": "60", "X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "60", "Retry-After": "1"},
+		},
+		{
+			name:               "authenticated client limited by principal",
+			method:             http.MethodPost,
+			path:               "/articles/12/publish",
+			header:             map[string]string{"X-API-Key": "ak_secret"},
+			allows:             []allow{{client: "api_key:42", result: model.RateLimitResult{Allowed: false, Limit: 10, Remaining: 0, Reset: time.Minute, RetryAfter: 6 * time.Second}}},
+			expectedRoute:      "/articles/:id/publish",
+			expectedStatusCode: http.StatusTooManyRequests,
+			expectedHeader:     map[string]string{"X-RateLimit-Limit": "10", "X-RateLimit-Remaining": "0", "Retry-After": "6"},
+		},
+		{
+			name:               "authenticated client not limited by address",
+			method:             http.MethodPost,
+			path:               "/articles/12/publish",
+			header:             map[string]string{"X-API-Key": "ak_secret"},
+			allows:             []allow{{client: "api_key:42", result: model.RateLimitResult{Allowed: true, Limit: 10, Remaining: 9, Reset: 6 * time.Second}}},
+			expectedRoute:      "/articles/:id/publish",
+			expectedStatusCode: http.StatusOK,
+			expectedHeader:     map[string]string{"X-RateLimit-Limit": "10", "X-RateLimit-Remaining": "9"},
+		},
+		{
+			name:               "route without limit",
+			method:             http.MethodGet,
+			path:               "/articles/12",
+			allows:             []allow{{client: "ip:127.0.0.1", result: model.RateLimitResult{Allowed: true}}},
+			expectedRoute:      "/articles/:id",
+			expectedStatusCode: http.StatusOK,
+			expectedHeader:     map[string]string{"X-RateLimit-Limit": ""},
+		},
+		{
+			name:               "limit unavailable",
+			method:             http.MethodGet,
+			path:               "/articles/12",
+			allows:             []allow{{client: "ip:127.0.0.1", err: service.Unavailable(assert.AnError)}},
+			expectedRoute:      "/articles/:id",
+			expectedStatusCode: http.StatusOK,
+			expectedHeader:     map[string]string{"X-RateLimit-Limit": ""},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dep := initialize(ctrl)
+			dep.authService.EXPECT().AuthenticateAPIKey(gomock.Any(), gomock.Any()).AnyTimes().Return(editor, nil)
+			calls := make([]*gomock.Call, 0, len(tc.allows))
+			for _, allow := range tc.allows {
+				calls = append(calls, dep.rateLimitService.EXPECT().Allow(gomock.Any(), allow.client, tc.method, tc.expectedRoute).Return(allow.result, allow.err))
+			}
+			gomock.InOrder(calls...)
+			dep.rateLimitService.EXPECT().Check(gomock.Any(), "ip:127.0.0.1", "AUTH", "/failures").AnyTimes().Return(model.RateLimitResult{Allowed: true}, nil)
+			dep.articleService.EXPECT().GetArticle(gomock.Any(), 12).MaxTimes(1).Return(model.Article{ID: 12}, nil)
+			dep.articleService.EXPECT().PublishArticle(gomock.Any(), 12).MaxTimes(1).Return(model.Article{ID: 12}, nil)
+
+			api := restapi.New(dep.articleService, dep.authorService,
+				restapi.WithAuthentication(dep.authService),
+				restapi.WithRateLimit(dep.rateLimitService),
+			)
+			router := api.Router()
+			server := httptest.NewServer(router)
+			defer server.Close()
+
+			req, err := http.NewRequest(tc.method, server.URL+tc.path, nil)
+			assert.NoError(t, err)
+			for name, value := range tc.header {
+				req.Header.Set(name, value)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+			for name, value := range tc.expectedHeader {
+				assert.Equal(t, value, resp.Header.Get(name), name)
+			}
+		})
+	}
+}
+
+func TestRateLimitInvalidCredentials(t *testing.T) {
+	editor := model.Principal{Subject: "42", Role: model.RoleEditor, Method: model.AuthMethodAPIKey}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dep := initialize(ctrl)
+	dep.authService.EXPECT().AuthenticateAPIKey(gomock.Any(), gomock.Any()).Times(5).DoAndReturn(
+		func(ctx context.Context, key string) (model.Principal, error) {
+			if key == "ak_valid" {
+				return editor, nil
+			}
+			return model.Principal{}, service.Unauthenticated(errors.New("api key is invalid"))
+		})
+	dep.articleService.EXPECT().PublishArticle(gomock.Any(), 12).Times(3).Return(model.Article{ID: 12}, nil)
+
+	limiter := ratelimit.NewRateLimitService(ratelimitmemory.NewRateLimitStore(),
+		ratelimit.WithLimit("AUTH", "/failures", model.RateLimit{Limit: 2, Period: time.Minute}),
+	)
+	api := restapi.New(dep.articleService, dep.authorService,
+		restapi.WithAuthentication(dep.authService),
+		restapi.WithRateLimit(limiter),
+	)
+	server := httptest.NewServer(api.Router())
+	defer server.Close()
+
+	// successes take nothing from the failure budget of the address, once
+	// spent every request with credentials is limited before authentication
+	attempts := []struct {
+		key                string
+		expectedStatusCode int
+	}{
+		{key: "ak_valid", expectedStatusCode: http.StatusOK},
+		{key: "ak_valid", expectedStatusCode: http.StatusOK},
+		{key: "ak_valid", expectedStatusCode: http.StatusOK},
+		{key: "ak_guess1", expectedStatusCode: http.StatusUnauthorized},
+		{key: "ak_guess2", expectedStatusCode: http.StatusUnauthorized},
+		{key: "ak_guess3", expectedStatusCode: http.StatusTooManyRequests},
+		{key: "ak_valid", expectedStatusCode: http.StatusTooManyRequests},
+	}
+	for i, attempt := range attempts {
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/articles/12/publish", nil)
+		assert.NoError(t, err)
+		req.Header.Set("X-API-Key", attempt.key)
+
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, attempt.expectedStatusCode, resp.StatusCode, "attempt %d", i+1)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dep := initialize(ctrl)
+	dep.articleService.EXPECT().GetArticle(gomock.Any(), 12).Return(model.Article{}, service.ErrArticleNotFound)
+
+	api := restapi.New(dep.articleService, dep.authorService)
+	router := api.Router()
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.DefaultClient.Get(server.URL + "/articles/12")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, err = http.DefaultClient.Get(server.URL + "/metrics")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `article_http_request_duration_seconds_count{method="GET",route="/articles/:id",status="404"}`)
+}
+
+func TestHealth(t *testing.T) {
+	up := health.CheckerFunc(func(ctx context.Context) error { return nil })
+	down := health.CheckerFunc(func(ctx context.Context) error { return errors.New("connection refused") })
+
+	tests := []struct {
+		name               string
+		path               string
+		register           func(r *health.Registry)
+		expectedStatusCode int
+		expectedStatus     string
+	}{
+		{
+			name: "ready",
+			path: "/readyz",
+			register: func(r *health.Registry) {
+				r.Register("mysql", up)
+				r.Register("redis", up, health.NonCritical())
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedStatus:     health.StatusOK,
+		},
+		{
+			name: "ready degraded",
+			path: "/readyz",
+			register: func(r *health.Registry) {
+				r.Register("mysql", up)
+				r.Register("redis", down, health.NonCritical())
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedStatus:     health.StatusDegraded,
+		},
+		{
+			name: "not ready",
+			path: "/readyz",
+			register: func(r *health.Registry) {
+				r.Register("mysql", down)
+			},
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedStatus:     health.StatusUnavailable,
+		},
+		{
+			name: "former probe not ready",
+			path: "/healthz",
+			register: func(r *health.Registry) {
+				r.Register("mysql", down)
+			},
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedStatus:     health.StatusUnavailable,
+		},
+		{
+			name: "alive while dependency down",
+			path: "/livez",
+			register: func(r *health.Registry) {
+				r.Register("mysql", down)
+				r.Register("event_dispatcher", up, health.Liveness())
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedStatus:     health.StatusOK,
+		},
+		{
+			name: "not alive",
+			path: "/livez",
+			register: func(r *health.Registry) {
+				r.Register("event_dispatcher", down, health.Liveness())
+			},
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedStatus:     health.StatusUnavailable,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			registry := health.NewRegistry()
+			tc.register(registry)
+
+			dep := initialize(ctrl)
+			api := restapi.New(dep.articleService, dep.authorService, restapi.WithHealth(registry))
+			server := httptest.NewServer(api.Router())
+			defer server.Close()
+
+			resp, err := http.DefaultClient.Get(server.URL + tc.path)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+
+			var body struct {
+				Data health.Report `json:"data"`
+			}
+			assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			assert.Equal(t, tc.expectedStatus, body.Data.Status)
+		})
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name              string
+		requestID         string
+		expectedRequestID string
+	}{
+		{
+			name:              "request id header",
+			requestID:         "9f1c2ab0-7d4e",
+			expectedRequestID: "9f1c2ab0-7d4e",
+		},
+		{
+			name:      "missing request id header",
+			requestID: "",
+		},
+		{
+			name:      "unfit request id header",
+			requestID: "request id with \"quotes\"",
+		},
+		{
+			name:      "too long request id header",
+			requestID: strings.Repeat("a", 129),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			var out bytes.Buffer
+			defaultLogger := logger.Default()
+			logger.SetDefault(logger.New(&out, logger.LevelInfo))
+			defer logger.SetDefault(defaultLogger)
+
+			dep := initialize(ctrl)
+			dep.articleService.EXPECT().GetArticle(gomock.Any(), 12).Return(model.Article{}, errors.New("database unreachable"))
+
+			api := restapi.New(dep.articleService, dep.authorService)
+			server := httptest.NewServer(api.Router())
+			defer server.Close()
+
+			req, _ := http.NewRequest(http.MethodGet, server.URL+"/articles/12", nil)
+			if tc.requestID != "" {
+				req.Header.Set("X-Request-ID", tc.requestID)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+
+			requestID := resp.Header.Get("X-Request-ID")
+			if tc.expectedRequestID != "" {
+				assert.Equal(t, tc.expectedRequestID, requestID)
+			} else {
+				assert.Len(t, requestID, 32)
+			}
+
+			// both the failure and the served request are logged with the request id
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			if assert.Len(t, lines, 2) {
+				for _, line := range lines {
+					var decoded map[string]interface{}
+					assert.NoError(t, json.Unmarshal([]byte(line), &decoded))
+					assert.Equal(t, requestID, decoded["request_id"])
+				}
+			}
+		})
+	}
+}
+
+func TestTrace(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tracing.Setup(context.Background(), "", "", "article")
+	recorder := &oteltest.StandardSpanRecorder{}
+	otel.SetTracerProvider(oteltest.NewTracerProvider(oteltest.WithSpanRecorder(recorder)))
+
+	var serviceSpan trace.SpanContext
+	dep := initialize(ctrl)
+	dep.articleService.EXPECT().GetArticle(gomock.Any(), 12).DoAndReturn(func(ctx context.Context, id int) (model.Article, error) {
+		serviceSpan = trace.SpanContextFromContext(ctx)
+		return model.Article{}, errors.New("database unreachable")
+	})
+
+	api := restapi.New(dep.articleService, dep.authorService)
+	server := httptest.NewServer(api.Router())
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/articles/12", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+
+	spans := recorder.Completed()
+	if !assert.Len(t, spans, 1) {
+		return
+	}
+
+	span := spans[0]
+	assert.Equal(t, "GET /articles/:id", span.Name())
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID.String())
+	assert.Equal(t, "00f067aa0ba902b7", span.ParentSpanID().String())
+	assert.Equal(t, span.SpanContext().SpanID, serviceSpan.SpanID, "service is called within the request span")
+	assert.Equal(t, int64(http.StatusInternalServerError), span.Attributes()["http.status_code"].AsInt64())
+}
+
+func TestArticleRevision(t *testing.T) {
+	tests := []struct {
+		name               string
+		method             string
+		path               string
+		mockService        func(s *mock.MockArticleService)
+		expectedStatusCode int
+	}{
+		{
+			name:   "revisions retrieved",
+			method: http.MethodGet,
+			path:   "/articles/12/revisions",
+			mockService: func(s *mock.MockArticleService) {
+				s.EXPECT().ListArticleRevision(gomock.Any(), 12).Return([]model.ArticleRevision{{ArticleID: 12, Revision: 1}}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:   "revisions of unknown article",
+			method: http.MethodGet,
+			path:   "/articles/12/revisions",
+			mockService: func(s *mock.MockArticleService) {
+				s.EXPECT().ListArticleRevision(gomock.Any(), 12).Return(nil, service.ErrArticleNotFound)
+			},
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "revisions with invalid article id",
+			method:             http.MethodGet,
+			path:               "/articles/abc/revisions",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:   "revision retrieved",
+			method: http.MethodGet,
+			path:   "/articles/12/revisions/2",
+			mockService: func(s *mock.MockArticleService) {
+				s.EXPECT().GetArticleRevision(gomock.Any(), 12, 2).Return(model.ArticleRevision{ArticleID: 12, Revision: 2}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:   "revision not found",
+			method: http.MethodGet,
+			path:   "/articles/12/revisions/2",
+			mockService: func(s *mock.MockArticleService) {
+				s.EXPECT().GetArticleRevision(gomock.Any(), 12, 2).Return(model.ArticleRevision{}, service.ErrRevisionNotFound)
+			},
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "invalid revision",
+			method:             http.MethodGet,
+			path:               "/articles/12/revisions/0",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:   "diff against previous revision",
+			method: http.MethodGet,
+			path:   "/articles/12/revisions/3/diff",
+			mockService: func(s *mock.MockArticleService) {
+				s.EXPECT().DiffArticleRevision(gomock.Any(), 12, 2, 3).Return(model.ArticleRevisionDiff{}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:   "diff against given revision",
+			method: http.MethodGet,
+			path:   "/articles/12/revisions/3/diff?from=1",
+			mockService: func(s *mock.MockArticleService) {
+				s.EXPECT().DiffArticleRevision(gomock.Any(), 12, 1, 3).Return(model.ArticleRevisionDiff{}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "diff against invalid revision",
+			method:             http.MethodGet,
+			path:               "/articles/12/revisions/3/diff?from=-1",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:   "diff of unknown revision",
+			method: http.MethodGet,
+			path:   "/articles/12/revisions/3/diff",
+			mockService: func(s *mock.MockArticleService) {
+				s.EXPECT().DiffArticleRevision(gomock.Any(), 12, 2, 3).Return(model.ArticleRevisionDiff{}, service.ErrRevisionNotFound)
+			},
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:   "revision restored",
+			method: http.MethodPost,
+			path:   "/articles/12/revisions/2/restore",
+			mockService: func(s *mock.MockArticleService) {
+				s.EXPECT().RestoreArticleRevision(gomock.Any(), 12, 2).Return(model.Article{ID: 12}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:   "unable to restore revision",
+			method: http.MethodPost,
+			path:   "/articles/12/revisions/2/restore",
+			mockService: func(s *mock.MockArticleService) {
+				s.EXPECT().RestoreArticleRevision(gomock.Any(), 12, 2).Return(model.Article{}, assert.AnError)
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dep := initialize(ctrl)
+			if tc.mockService != nil {
+				tc.mockService(dep.articleService)
+			}
+
+			api := restapi.New(dep.articleService, dep.authorService)
+			router := api.Router()
+			server := httptest.NewServer(router)
+			defer server.Close()
+
+			req, err := http.NewRequest(tc.method, server.URL+tc.path, nil)
+			assert.NoError(t, err)
+
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+		})
+	}
+}
+
+func TestGetArticle(t *testing.T) {
+	editor := model.Principal{Subject: "42", Role: model.RoleEditor, Method: model.AuthMethodAPIKey}
+
+	tests := []struct {
+		name                 string
+		path                 string
+		header               map[string]string
+		getArticleErr        error
+		expectedID           int
+		expectedETag         string
+		expectedCacheControl string
+		expectedStatusCode   int
+	}{
+		{
+			name:                 "article retrieved",
+			path:                 "/articles/12",
+			expectedID:           12,
+			expectedETag:         `"12-3"`,
+			expectedCacheControl: "public, max-age=60",
+			expectedStatusCode:   http.StatusOK,
+		},
+		{
+			name:                 "article retrieved by authenticated caller",
+			path:                 "/articles/12",
+			header:               map[string]string{"X-API-Key": "ak_secret"},
+			expectedID:           12,
+			expectedETag:         `"12-3"`,
+			expectedCacheControl: "private, no-cache",
+			expectedStatusCode:   http.StatusOK,
+		},
+		{
+			name:               "invalid article id",
+			path:               "/articles/abc",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "article not found",
+			path:               "/articles/12",
+			getArticleErr:      service.ErrArticleNotFound,
+			expectedID:         12,
+			expectedStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dep := initialize(ctrl)
+			dep.authService.EXPECT().AuthenticateAPIKey(gomock.Any(), "ak_secret").MaxTimes(1).Return(editor, nil)
+			dep.articleService.EXPECT().GetArticle(gomock.Any(), tc.expectedID).MaxTimes(1).Return(model.Article{ID: tc.expectedID, Version: 3}, tc.getArticleErr)
+
+			api := restapi.New(dep.articleService, dep.authorService, restapi.WithAuthentication(dep.authService), restapi.WithCacheControl("public, max-age=60"))
+			router := api.Router()
+			server := httptest.NewServer(router)
+			defer server.Close()
+
+			req, err := http.NewRequest(http.MethodGet, server.URL+tc.path, nil)
+			assert.NoError(t, err)
+			for name, value := range tc.header {
+				req.Header.Set(name, value)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+			assert.Equal(t, tc.expectedETag, resp.Header.Get("ETag"))
+			assert.Equal(t, tc.expectedCacheControl, resp.Header.Get("Cache-Control"))
+		})
+	}
+}
+
+func TestGetArticleRender(t *testing.T) {
+	tests := []struct {
+		name               string
+		path               string
+		article            model.Article
+		expectedStatusCode int
+		expectedBodyFormat string
+		expectedBodyHTML   string
+	}{
+		{
+			name:               "rendered body omitted by default",
+			path:               "/articles/12",
+			article:            model.Article{ID: 12, Body: "*a*", BodyFormat: model.BodyFormatMarkdown, BodyHTML: "<p><em>a</em></p>\n"},
+			expectedStatusCode: http.StatusOK,
+			expectedBodyFormat: model.BodyFormatMarkdown,
+		},
+		{
+			name:               "stored rendered body",
+			path:               "/articles/12?render=html",
+			article:            model.Article{ID: 12, Body: "*a*", BodyFormat: model.BodyFormatMarkdown, BodyHTML: "<p><em>a</em></p>\n"},
+			expectedStatusCode: http.StatusOK,
+			expectedBodyFormat: model.BodyFormatMarkdown,
+			expectedBodyHTML:   "<p><em>a</em></p>\n",
+		},
+		{
+			name:               "article without rendered body rendered as plain",
+			path:               "/articles/12?render=HTML",
+			article:            model.Article{ID: 12, Body: "<b>a</b>"},
+			expectedStatusCode: http.StatusOK,
+			expectedBodyFormat: model.BodyFormatPlain,
+			expectedBodyHTML:   "<p>&lt;b&gt;a&lt;/b&gt;</p>\n",
+		},
+		{
+			name:               "invalid render",
+			path:               "/articles/12?render=pdf",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dep := initialize(ctrl)
+			dep.articleService.EXPECT().GetArticle(gomock.Any(), 12).MaxTimes(1).Return(tc.article, nil)
+
+			api := restapi.New(dep.articleService, dep.authorService)
+			server := httptest.NewServer(api.Router())
+			defer server.Close()
+
+			resp, err := http.DefaultClient.Get(server.URL + tc.path)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+
+			var body struct {
+				Code string        `json:"code"`
+				Data model.Article `json:"data"`
+			}
+			assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			if tc.expectedStatusCode != http.StatusOK {
+				assert.Equal(t, "invalid_render", body.Code)
+				return
+			}
+
+			assert.Equal(t, tc.expectedBodyFormat, body.Data.BodyFormat)
+			assert.Equal(t, tc.expectedBodyHTML, body.Data.BodyHTML)
+		})
+	}
+}
+
+func TestUpdateArticle(t *testing.T) {
+	validBody := `
+		{
+			"title": "A Valid Title",
+			"body": "A very interesting content",
+			"tags": ["Go"]
+		}
+	`
+
+	tests := []struct {
+		name               string
+		path               string
+		body               string
+		ifMatch            string
+		updateArticleErr   error
+		expectedArticle    model.Article
+		expectedETag       string
+		expectedStatusCode int
+	}{
+		{
+			name: "article updated",
+			path: "/articles/12",
+			body: validBody,
+			expectedArticle: model.Article{
+				ID:    12,
+				Title: "A Valid Title",
+				Body:  "A very interesting content",
+				Tags:  []string{"go"},
+			},
+			expectedETag:       `"12-4"`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:    "article updated, with matching version",
+			path:    "/articles/12",
+			body:    validBody,
+			ifMatch: `"12-3"`,
+			expectedArticle: model.Article{
+				ID:      12,
+				Title:   "A Valid Title",
+				Body:    "A very interesting content",
+				Tags:    []string{"go"},
+				Version: 3,
+			},
+			expectedETag:       `"12-4"`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:    "article updated, with any version",
+			path:    "/articles/12",
+			body:    validBody,
+			ifMatch: "*",
+			expectedArticle: model.Article{
+				ID:    12,
+				Title: "A Valid Title",
+				Body:  "A very interesting content",
+				Tags:  []string{"go"},
+			},
+			expectedETag:       `"12-4"`,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:    "stale version",
+			path:    "/articles/12",
+			body:    validBody,
+			ifMatch: `"12-2"`,
+			expectedArticle: model.Article{
+				ID:      12,
+				Title:   "A Valid Title",
+				Body:    "A very interesting content",
+				Tags:    []string{"go"},
+				Version: 2,
+			},
+			updateArticleErr:   service.ErrArticleVersionConflict,
+			expectedStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:               "if-match of another article",
+			path:               "/articles/12",
+			body:               validBody,
+			ifMatch:            `"13-2"`,
+			expectedStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			name: "concurrent write without precondition",
+			path: "/articles/12",
+			body: validBody,
+			expectedArticle: model.Article{
+				ID:    12,
+				Title: "A Valid Title",
+				Body:  "A very interesting content",
+				Tags:  []string{"go"},
+			},
+			updateArticleErr:   service.ErrArticleVersionConflict,
+			expectedStatusCode: http.StatusConflict,
+		},
+		{
+			name: "article not found",
+			path: "/articles/12",
+			body: validBody,
+			expectedArticle: model.Article{
+				ID:    12,
+				Title: "A Valid Title",
+				Body:  "A very interesting content",
+				Tags:  []string{"go"},
+			},
+			updateArticleErr:   service.ErrArticleNotFound,
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "bad request body",
+			path:               "/articles/12",
+			body:               "not a valid json body",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "title is blank",
+			path: "/articles/12",
+			body: `
+				{
+					"body": "A very interesting content"
+				}
+			`,
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			updated := tc.expectedArticle
+			updated.Version = 4
+
+			dep := initialize(ctrl)
+			dep.articleService.EXPECT().UpdateArticle(gomock.Any(), tc.expectedArticle).MaxTimes(1).Return(updated, tc.updateArticleErr)
+
+			api := restapi.New(dep.articleService, dep.authorService)
+			router := api.Router()
+			server := httptest.NewServer(router)
+			defer server.Close()
+
+			req, err := http.NewRequest(http.MethodPut, server.URL+tc.path, strings.NewReader(tc.body))
+			assert.NoError(t, err)
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+			assert.Equal(t, tc.expectedETag, resp.Header.Get("ETag"))
+		})
+	}
+}
+
+func TestConditionalGet(t *testing.T) {
+	updatedAt := time.Date(2021, 1, 30, 10, 0, 0, 0, time.UTC)
+	article := model.Article{ID: 12, Version: 3, UpdatedAt: updatedAt}
+
+	tests := []struct {
+		name                 string
+		path                 string
+		header               map[string]string
+		options              []restapi.Option
+		lastModified         time.Time
+		expectedStatusCode   int
+		expectedCacheControl string
+		expectedLastModified time.Time
+	}{
+		{
+			name:                 "article retrieved",
+			path:                 "/articles/12",
+			expectedStatusCode:   http.StatusOK,
+			expectedCacheControl: "no-cache",
+		},
+		{
+			name:                 "article retrieved, with configured cache control",
+			path:                 "/articles/12",
+			options:              []restapi.Option{restapi.WithCacheControl("public, max-age=60")},
+			expectedStatusCode:   http.StatusOK,
+			expectedCacheControl: "public, max-age=60",
+		},
+		{
+			name:                 "article not modified, matching etag",
+			path:                 "/articles/12",
+			header:               map[string]string{"If-None-Match": `"12-2", "12-3"`},
+			expectedStatusCode:   http.StatusNotModified,
+			expectedCacheControl: "no-cache",
+		},
+		{
+			name:                 "article modified, stale etag",
+			path:                 "/articles/12",
+			header:               map[string]string{"If-None-Match": `"12-2"`, "If-Modified-Since": updatedAt.Format(http.TimeFormat)},
+			expectedStatusCode:   http.StatusOK,
+			expectedCacheControl: "no-cache",
+		},
+		{
+			name:                 "article not modified since",
+			path:                 "/articles/12",
+			header:               map[string]string{"If-Modified-Since": updatedAt.Format(http.TimeFormat)},
+			expectedStatusCode:   http.StatusNotModified,
+			expectedCacheControl: "no-cache",
+		},
+		{
+			name:                 "article modified since",
+			path:                 "/articles/12",
+			header:               map[string]string{"If-Modified-Since": updatedAt.Add(-time.Second).Format(http.TimeFormat)},
+			expectedStatusCode:   http.StatusOK,
+			expectedCacheControl: "no-cache",
+		},
+		{
+			name:                 "articles not modified since",
+			path:                 "/articles",
+			header:               map[string]string{"If-Modified-Since": updatedAt.Format(http.TimeFormat)},
+			expectedStatusCode:   http.StatusNotModified,
+			expectedCacheControl: "no-cache",
+		},
+		{
+			name:                 "articles modified since, article removed from list",
+			path:                 "/articles",
+			header:               map[string]string{"If-Modified-Since": updatedAt.Format(http.TimeFormat)},
+			lastModified:         updatedAt.Add(time.Minute),
+			expectedStatusCode:   http.StatusOK,
+			expectedCacheControl: "no-cache",
+			expectedLastModified: updatedAt.Add(time.Minute),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dep := initialize(ctrl)
+			dep.articleService.EXPECT().GetArticle(gomock.Any(), 12).AnyTimes().Return(article, nil)
+			dep.articleService.EXPECT().SearchArticle(gomock.Any(), gomock.Any()).AnyTimes().Return([]model.Article{article}, nil)
+			dep.articleService.EXPECT().LastModified(gomock.Any()).AnyTimes().Return(tc.lastModified, nil)
+
+			api := restapi.New(dep.articleService, dep.authorService, tc.options...)
+			router := api.Router()
+			server := httptest.NewServer(router)
+			defer server.Close()
+
+			req, err := http.NewRequest(http.MethodGet, server.URL+tc.path, nil)
+			assert.NoError(t, err)
+			for key, value := range tc.header {
+				req.Header.Set(key, value)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+			assert.Equal(t, tc.expectedCacheControl, resp.Header.Get("Cache-Control"))
+			assert.NotEmpty(t, resp.Header.Get("ETag"))
+
+			expectedLastModified := updatedAt
+			if !tc.expectedLastModified.IsZero() {
+				expectedLastModified = tc.expectedLastModified
+			}
+			assert.Equal(t, expectedLastModified.Format(http.TimeFormat), resp.Header.Get("Last-Modified"))
+		})
+	}
+}
+
+func TestConditionalGetContentHash(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dep := initialize(ctrl)
+	dep.articleService.EXPECT().SearchArticle(gomock.Any(), gomock.Any()).Times(2).Return([]model.Article{{ID: 1}}, nil)
+	dep.articleService.EXPECT().LastModified(gomock.Any()).AnyTimes().Return(time.Time{}, nil)
+
+	api := restapi.New(dep.articleService, dep.authorService)
+	server := httptest.NewServer(api.Router())
+	defer server.Close()
+
+	resp, err := http.DefaultClient.Get(server.URL + "/articles")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	etag := resp.Header.Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/articles", nil)
+	assert.NoError(t, err)
+	req.Header.Set("If-None-Match", etag)
+
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+}
+
+func TestCreateArticleValidationErrors(t *testing.T) {
+	tests := []struct {
+		name               string
+		body               string
+		createArticleErr   error
+		expectedStatusCode int
+		expectedFields     []string
+	}{
+		{
+			name: "all violations reported",
+			body: `
+				{
+					"author": "  ",
+					"language": "english",
+					"tags": ["c++"]
+				}
+			`,
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedFields:     []string{"author", "title", "body", "language", "tags[0]"},
+		},
+		{
+			name: "violations reported by service",
+			body: `
+				{
+					"author": "john doe",
+					"title": "A Valid Title",
+					"body": "A very interesting content"
+				}
+			`,
+			createArticleErr: validation.Errors{
+				{Field: "title", Code: validation.CodeTooLong, Message: "title exceed 255 characters"},
+			},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedFields:     []string{"title"},
+		},
+		{
+			name:               "fields not valid UTF-8",
+			body:               "{\"author\": \"john doe\", \"title\": \"A Valid \xff Title\", \"body\": \"content \xff\", \"tags\": [\"go\"]}",
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedFields:     []string{"title", "body"},
+		},
+		{
+			name:               "request not valid UTF-8",
+			body:               "[\"\xff\"]",
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedFields:     []string{"request"},
+		},
+		{
+			name:               "request body too large",
+			body:               `{"author": "john doe", "title": "A Valid Title", "body": "` + strings.Repeat("a", 1<<20) + `"}`,
+			expectedStatusCode: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dep := initialize(ctrl)
+			dep.articleService.EXPECT().CreateArticle(gomock.Any(), gomock.Any()).MaxTimes(1).Return(model.Article{}, tc.createArticleErr)
+
+			api := restapi.New(dep.articleService, dep.authorService)
+			server := httptest.NewServer(api.Router())
+			defer server.Close()
+
+			resp, err := http.DefaultClient.Post(server.URL+"/articles", "application/json", strings.NewReader(tc.body))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+
+			var body struct {
+				Errors []validation.Violation `json:"errors"`
+			}
+			err = json.NewDecoder(resp.Body).Decode(&body)
+			assert.NoError(t, err)
+
+			fields := []string{}
+			for _, violation := range body.Errors {
+				fields = append(fields, violation.Field)
+			}
+			if tc.expectedFields == nil {
+				assert.Empty(t, fields)
+			} else {
+				assert.Equal(t, tc.expectedFields, fields)
+			}
+		})
+	}
+}
+
+func TestErrorResponse(t *testing.T) {
+	tests := []struct {
+		name               string
+		path               string
+		getArticleErr      error
+		expectedStatusCode int
+		expectedCode       string
+		expectedMessage    string
+	}{
+		{
+			name:               "invalid argument detected by api",
+			path:               "/articles/abc",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedCode:       "invalid_article_id",
+			expectedMessage:    "invalid article id",
+		},
+		{
+			name:               "not found",
+			path:               "/articles/12",
+			getArticleErr:      service.ErrArticleNotFound,
+			expectedStatusCode: http.StatusNotFound,
+			expectedCode:       "article_not_found",
+			expectedMessage:    "article not found",
+		},
+		{
+			name:               "wrapped not found",
+			path:               "/articles/12",
+			getArticleErr:      fmt.Errorf("get article: %w", service.ErrArticleNotFound),
+			expectedStatusCode: http.StatusNotFound,
+			expectedCode:       "article_not_found",
+			expectedMessage:    "get article: article not found",
+		},
+		{
+			name:               "conflict",
+			path:               "/articles/12",
+			getArticleErr:      service.ErrArticleVersionConflict,
+			expectedStatusCode: http.StatusConflict,
+			expectedCode:       "article_version_conflict",
+			expectedMessage:    "article version conflict",
+		},
+		{
+			name:               "invalid argument",
+			path:               "/articles/12",
+			getArticleErr:      service.InvalidArgument(errors.New("id is invalid")),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedCode:       "invalid_argument",
+			expectedMessage:    "invalid argument: id is invalid",
+		},
+		{
+			name:               "unavailable error hide its cause",
+			path:               "/articles/12",
+			getArticleErr:      service.Unavailable(errors.New("dial tcp 10.0.0.1:3306: connection refused")),
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedCode:       "unavailable",
+			expectedMessage:    "service unavailable",
+		},
+		{
+			name:               "permission denied",
+			path:               "/articles/12",
+			getArticleErr:      service.ErrPermissionDenied,
+			expectedStatusCode: http.StatusForbidden,
+			expectedCode:       "permission_denied",
+			expectedMessage:    "permission denied",
+		},
+		{
+			name:               "internal error hide its cause",
+			path:               "/articles/12",
+			getArticleErr:      errors.New("dial tcp 10.0.0.1:3306: connection refused"),
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedCode:       "internal",
+			expectedMessage:    "internal server error",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dep := initialize(ctrl)
+			dep.articleService.EXPECT().GetArticle(gomock.Any(), 12).MaxTimes(1).Return(model.Article{}, tc.getArticleErr)
+
+			api := restapi.New(dep.articleService, dep.authorService)
+			server := httptest.NewServer(api.Router())
+			defer server.Close()
+
+			resp, err := http.DefaultClient.Get(server.URL + tc.path)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+
+			var body struct {
+				Message string `json:"message"`
+				Code    string `json:"code"`
+			}
+			err = json.NewDecoder(resp.Body).Decode(&body)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedCode, body.Code)
+			assert.Equal(t, tc.expectedMessage, body.Message)
+		})
+	}
+}
+
+func TestIdempotentCreateArticle(t *testing.T) {
+	scopedKey := "ip:127.0.0.1 POST /articles key"
+	body := `{"author": "john doe", "title": "A Valid Title", "body": "A very interesting content"}`
+	replayed := model.IdempotencyRecord{
+		Key:        "key",
+		Completed:  true,
+		StatusCode: http.StatusCreated,
+		Header:     map[string][]string{"Content-Type": {"application/json"}, "X-Ratelimit-Remaining": {"9"}},
+		Body:       []byte(`{"message":"article created"}`),
+	}
+
+	tests := []struct {
+		name               string
+		idempotencyKey     string
+		mock               func(dep dependency)
+		expectedStatusCode int
+		expectedReplayed   bool
+	}{
+		{
+			name: "without idempotency key",
+			mock: func(dep dependency) {
+				dep.articleService.EXPECT().CreateArticle(gomock.Any(), gomock.Any()).Return(model.Article{ID: 12, Version: 1}, nil)
+			},
+			expectedStatusCode: http.StatusCreated,
+		},
+		{
+			name:           "new request processed and stored",
+			idempotencyKey: "key",
+			mock: func(dep dependency) {
+				dep.idempotencyService.EXPECT().Begin(gomock.Any(), scopedKey, gomock.Any()).Return(model.IdempotencyRecord{}, nil)
+				dep.articleService.EXPECT().CreateArticle(gomock.Any(), gomock.Any()).Return(model.Article{ID: 12, Version: 1}, nil)
+				dep.idempotencyService.EXPECT().Complete(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(ctx context.Context, record model.IdempotencyRecord) error {
+						assert.Equal(t, scopedKey, record.Key)
+						assert.NotEmpty(t, record.RequestHash)
+						assert.Equal(t, http.StatusCreated, record.StatusCode)
+						assert.Equal(t, "application/json", http.Header(record.Header).Get("Content-Type"))
+						assert.Equal(t, "/articles/12", http.Header(record.Header).Get("Location"))
+						assert.NotContains(t, record.Header, "X-Request-Id")
+						assert.Contains(t, string(record.Body), "article created")
+						return nil
+					})
+			},
+			expectedStatusCode: http.StatusCreated,
+		},
+		{
+			name:           "retried request replayed",
+			idempotencyKey: "key",
+			mock: func(dep dependency) {
+				dep.idempotencyService.EXPECT().Begin(gomock.Any(), scopedKey, gomock.Any()).Return(replayed, nil)
+			},
+			expectedStatusCode: http.StatusCreated,
+			expectedReplayed:   true,
+		},
+		{
+			name:           "key reused for different request",
+			idempotencyKey: "key",
+			mock: func(dep dependency) {
+				dep.idempotencyService.EXPECT().Begin(gomock.Any(), scopedKey, gomock.Any()).Return(model.IdempotencyRecord{}, service.ErrIdempotencyKeyReused)
+			},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "request in progress",
+			idempotencyKey: "key",
+			mock: func(dep dependency) {
+				dep.idempotencyService.EXPECT().Begin(gomock.Any(), scopedKey, gomock.Any()).Return(model.IdempotencyRecord{}, service.ErrIdempotencyKeyInProgress)
+			},
+			expectedStatusCode: http.StatusConflict,
+		},
+		{
+			name:           "failed request released",
+			idempotencyKey: "key",
+			mock: func(dep dependency) {
+				dep.idempotencyService.EXPECT().Begin(gomock.Any(), scopedKey, gomock.Any()).Return(model.IdempotencyRecord{}, nil)
+				dep.articleService.EXPECT().CreateArticle(gomock.Any(), gomock.Any()).Return(model.Article{}, assert.AnError)
+				dep.idempotencyService.EXPECT().Release(gomock.Any(), scopedKey).Return(nil)
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name:               "idempotency key too long",
+			idempotencyKey:     strings.Repeat("k", 256),
+			mock:               func(dep dependency) {},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dep := initialize(ctrl)
+			tc.mock(dep)
+
+			api := restapi.New(dep.articleService, dep.authorService, restapi.WithIdempotency(dep.idempotencyService))
+			server := httptest.NewServer(api.Router())
+			defer server.Close()
+
+			req, _ := http.NewRequest(http.MethodPost, server.URL+"/articles", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			if tc.idempotencyKey != "" {
+				req.Header.Set("Idempotency-Key", tc.idempotencyKey)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+			assert.Equal(t, tc.expectedReplayed, resp.Header.Get("Idempotent-Replayed") == "true")
+			assert.Empty(t, resp.Header.Get("X-RateLimit-Remaining"))
+		})
+	}
+}
+
+func TestImportArticles(t *testing.T) {
+	valid := `{"author": "john doe", "title": "A Valid Title", "body": "A very interesting content"}`
+	invalid := `{"author": "john doe", "title": "A Valid Title"}`
+
+	tests := []struct {
+		name               string
+		contentType        string
+		body               string
+		importArticlesErr  error
+		expectedImported   int
+		expectedStatusCode int
+		expectedStatuses   []string
+	}{
+		{
+			name:               "json array imported",
+			contentType:        "application/json",
+			body:               "[" + valid + "," + invalid + "," + valid + "]",
+			expectedImported:   2,
+			expectedStatusCode: http.StatusOK,
+			expectedStatuses:   []string{"created", "failed", "created"},
+		},
+		{
+			name:               "ndjson stream imported",
+			contentType:        "application/x-ndjson",
+			body:               valid + "\n" + "not a valid json" + "\n\n" + valid + "\n",
+			expectedImported:   2,
+			expectedStatusCode: http.StatusOK,
+			expectedStatuses:   []string{"created", "failed", "created"},
+		},
+		{
+			name:               "json array item of unexpected shape",
+			contentType:        "application/json",
+			body:               "[" + valid + `, {"title": 5}, ` + valid + `, "not an article"]`,
+			expectedImported:   2,
+			expectedStatusCode: http.StatusOK,
+			expectedStatuses:   []string{"created", "failed", "created", "failed"},
+		},
+		{
+			name:               "ndjson line not valid UTF-8",
+			contentType:        "application/x-ndjson",
+			body:               valid + "\n" + `{"author": "john doe", "title": "A Valid Title", "body": "content ` + "\xff" + `"}` + "\n" + valid + "\n",
+			expectedImported:   2,
+			expectedStatusCode: http.StatusOK,
+			expectedStatuses:   []string{"created", "failed", "created"},
+		},
+		{
+			name:               "ndjson line of unexpected shape",
+			contentType:        "application/x-ndjson",
+			body:               valid + "\n" + `{"title": 5}` + "\n" + valid + "\n",
+			expectedImported:   2,
+			expectedStatusCode: http.StatusOK,
+			expectedStatuses:   []string{"created", "failed", "created"},
+		},
+		{
+			name:               "malformed json array",
+			contentType:        "application/json",
+			body:               "not a valid json",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "json body not an array",
+			contentType:        "application/json",
+			body:               valid,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "empty batch",
+			contentType:        "application/json",
+			body:               "[]",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "unable to import articles",
+			contentType:        "application/json",
+			body:               "[" + valid + "]",
+			importArticlesErr:  service.ErrInvalidArgument,
+			expectedImported:   1,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dep := initialize(ctrl)
+			if tc.expectedImported > 0 {
+				dep.articleService.EXPECT().ImportArticles(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(ctx context.Context, articles []model.Article) ([]model.ArticleImportResult, error) {
+						assert.Len(t, articles, tc.expectedImported)
+						if tc.importArticlesErr != nil {
+							return nil, tc.importArticlesErr
+						}
+
+						results := []model.ArticleImportResult{}
+						for i, article := range articles {
+							article.ID = 100 + i
+							results = append(results, model.ArticleImportResult{Article: article})
+						}
+						return results, nil
+					})
+			}
+
+			api := restapi.New(dep.articleService, dep.authorService)
+			server := httptest.NewServer(api.Router())
+			defer server.Close()
+
+			resp, err := http.DefaultClient.Post(server.URL+"/articles:batch", tc.contentType, strings.NewReader(tc.body))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+
+			if tc.expectedStatuses == nil {
+				return
+			}
+
+			var body struct {
+				Data struct {
+					Created int `json:"created"`
+					Failed  int `json:"failed"`
+					Items   []struct {
+						Index    int    `json:"index"`
+						Status   string `json:"status"`
+						ID       int    `json:"id"`
+						Location string `json:"location"`
+						Code     string `json:"code"`
+					} `json:"items"`
+				} `json:"data"`
+			}
+			err = json.NewDecoder(resp.Body).Decode(&body)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedImported, body.Data.Created)
+			assert.Equal(t, len(tc.expectedStatuses)-tc.expectedImported, body.Data.Failed)
+
+			statuses := []string{}
+			for i, item := range body.Data.Items {
+				assert.Equal(t, i, item.Index)
+				statuses = append(statuses, item.Status)
+			}
+			assert.Equal(t, tc.expectedStatuses, statuses)
+			assert.Equal(t, "/articles/100", body.Data.Items[0].Location)
+			assert.Equal(t, "/articles/101", body.Data.Items[2].Location)
+		})
+	}
+}
+
+func TestExportArticleAuthorization(t *testing.T) {
+	author := model.Principal{Subject: "1", Name: "John Doe", Role: model.RoleAuthor, AuthorID: 7, Method: model.AuthMethodAPIKey}
+	editor := model.Principal{Subject: "2", Name: "Jane Roe", Role: model.RoleEditor, Method: model.AuthMethodAPIKey}
+
+	tests := []struct {
+		name               string
+		path               string
+		header             map[string]string
+		principal          model.Principal
+		expectedStatus     string
+		expectedStatusCode int
+	}{
+		{
+			name:               "anonymous export published articles",
+			path:               "/articles/export",
+			expectedStatus:     model.ArticleStatusPublished,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "anonymous export draft articles",
+			path:               "/articles/export?status=draft",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "author export draft articles",
+			path:               "/articles/export?status=draft",
+			header:             map[string]string{"X-API-Key": "ak_author"},
+			principal:          author,
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "editor export draft articles",
+			path:               "/articles/export?status=draft",
+			header:             map[string]string{"X-API-Key": "ak_editor"},
+			principal:          editor,
+			expectedStatus:     model.ArticleStatusDraft,
+			expectedStatusCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dep := initialize(ctrl)
+			dep.authService.EXPECT().AuthenticateAPIKey(gomock.Any(), gomock.Any()).MaxTimes(1).Return(tc.principal, nil)
+
+			// denied exports never reach the database
+			iterateTimes := 1
+			if tc.expectedStatus == "" {
+				iterateTimes = 0
+			}
+
+			database := articlemock.NewMockDatabase(ctrl)
+			database.EXPECT().Iterate(gomock.Any(), gomock.Any(), gomock.Any()).Times(iterateTimes).DoAndReturn(
+				func(ctx context.Context, query model.ArticleSearchQuery, fn func(model.Article) error) error {
+					assert.Equal(t, tc.expectedStatus, query.Status)
+					return nil
+				})
+
+			articleService := article.NewArticleService(database, articlemock.NewMockCache(ctrl), articlemock.NewMockIndexer(ctrl), articlemock.NewMockAuthorResolver(ctrl))
+			api := restapi.New(articleService, dep.authorService, restapi.WithAuthentication(dep.authService))
+			server := httptest.NewServer(api.Router())
+			defer server.Close()
+
+			req, err := http.NewRequest(http.MethodGet, server.URL+tc.path, nil)
+			assert.NoError(t, err)
+			for name, value := range tc.header {
+				req.Header.Set(name, value)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+		})
+	}
+}
+
+func TestExportArticle(t *testing.T) {
+	createdAt := time.Date(2021, 1, 30, 10, 0, 0, 0, time.UTC)
+	articles := []model.Article{
+		{ID: 1, AuthorID: 7, Author: "John Doe", AuthorHandle: "john-doe", Title: "First", Body: "first, \"quoted\" body", BodyFormat: "markdown", BodyHTML: "<p>first, &#34;quoted&#34; body</p>\n", Tags: []string{"go", "web"}, Status: "published", Version: 1, CreatedAt: createdAt, UpdatedAt: createdAt},
+		{ID: 2, AuthorID: 7, Author: "John Doe", AuthorHandle: "john-doe", Title: "Second", Body: "second body", Status: "published", Version: 2, CreatedAt: createdAt, UpdatedAt: createdAt},
+	}
+
+	tests := []struct {
+		name                string
+		path                string
+		exported            []model.Article
+		exportErr           error
+		expectedQuery       model.ArticleSearchQuery
+		expectedStatusCode  int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			name:                "ndjson by default",
+			path:                "/articles/export?author=john-doe&tag=go",
+			exported:            articles,
+			expectedQuery:       model.ArticleSearchQuery{Author: "john-doe", Tags: []string{"go"}},
+			expectedStatusCode:  http.StatusOK,
+			expectedContentType: "application/x-ndjson",
+			expectedBody: `{"id":1,"title":"First","body":"first, \"quoted\" body","body_format":"markdown","excerpt":"first, \"quoted\" body","word_count":3,"reading_time":1,"author_id":7,"author":"John Doe","author_handle":"john-doe","language":"","tags":["go","web"],"status":"published","version":1,"created_at":"2021-01-30T10:00:00Z","updated_at":"2021-01-30T10:00:00Z"}` + "\n" +
+				`{"id":2,"title":"Second","body":"second body","body_format":"plain","excerpt":"second body","word_count":2,"reading_time":1,"author_id":7,"author":"John Doe","author_handle":"john-doe","language":"","tags":null,"status":"published","version":2,"created_at":"2021-01-30T10:00:00Z","updated_at":"2021-01-30T10:00:00Z"}` + "\n",
+		},
+		{
+			name:                "csv",
+			path:                "/articles/export?format=csv&status=draft",
+			exported:            articles,
+			expectedQuery:       model.ArticleSearchQuery{Status: "draft"},
+			expectedStatusCode:  http.StatusOK,
+			expectedContentType: "text/csv; charset=utf-8",
+			expectedBody: "id,author_id,author,author_handle,title,body,body_format,language,tags,status,publish_at,version,created_at,updated_at\n" +
+				`1,7,John Doe,john-doe,First,"first, ""quoted"" body",markdown,,go;web,published,,1,2021-01-30T10:00:00Z,2021-01-30T10:00:00Z` + "\n" +
+				"2,7,John Doe,john-doe,Second,second body,plain,,,published,,2,2021-01-30T10:00:00Z,2021-01-30T10:00:00Z\n",
+		},
+		{
+			name:                "empty json",
+			path:                "/articles/export?format=json",
+			expectedStatusCode:  http.StatusOK,
+			expectedContentType: "application/json",
+			expectedBody:        "[]\n",
+		},
+		{
+			name:               "invalid format",
+			path:               "/articles/export?format=xml",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "invalid status",
+			path:               "/articles/export?status=deleted",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "unable to export before any article",
+			path:               "/articles/export",
+			exportErr:          service.Unavailable(assert.AnError),
+			expectedStatusCode: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dep := initialize(ctrl)
+			dep.articleService.EXPECT().ExportArticle(gomock.Any(), gomock.Any(), gomock.Any()).MaxTimes(1).DoAndReturn(
+				func(ctx context.Context, query model.ArticleSearchQuery, fn func(model.Article) error) error {
+					assert.Equal(t, tc.expectedQuery, query)
+					for _, article := range tc.exported {
+						err := fn(article)
+						if err != nil {
+							return err
+						}
+					}
+					return tc.exportErr
+				})
+
+			api := restapi.New(dep.articleService, dep.authorService)
+			server := httptest.NewServer(api.Router())
+			defer server.Close()
+
+			resp, err := http.DefaultClient.Get(server.URL + tc.path)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+
+			if tc.expectedStatusCode != http.StatusOK {
+				return
+			}
+
+			body, _ := ioutil.ReadAll(resp.Body)
+			assert.Equal(t, tc.expectedContentType, resp.Header.Get("Content-Type"))
+			assert.Equal(t, tc.expectedBody, string(body))
+		})
+	}
+
+	t.Run("json", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		dep := initialize(ctrl)
+		dep.articleService.EXPECT().ExportArticle(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, query model.ArticleSearchQuery, fn func(model.Article) error) error {
+				for _, article := range articles {
+					fn(article)
+				}
+				return nil
+			})
+
+		api := restapi.New(dep.articleService, dep.authorService)
+		server := httptest.NewServer(api.Router())
+		defer server.Close()
+
+		resp, err := http.DefaultClient.Get(server.URL + "/articles/export?format=json")
+		assert.NoError(t, err)
+
+		var exported []model.Article
+		err = json.NewDecoder(resp.Body).Decode(&exported)
+		assert.NoError(t, err)
+		assert.Len(t, exported, 2)
+		assert.Equal(t, "Second", exported[1].Title)
+	})
+}
+
+func TestFeed(t *testing.T) {
+	createdAt := time.Date(2021, 1, 30, 10, 0, 0, 0, time.UTC)
+	updatedAt := time.Date(2021, 2, 1, 10, 0, 0, 0, time.UTC)
+	articles := []model.Article{
+		{ID: 2, Author: "John Doe", AuthorHandle: "john-doe", Title: "Second", Body: "second <b>body</b>", Tags: []string{"go"}, CreatedAt: updatedAt, UpdatedAt: updatedAt},
+		{ID: 1, Author: "John Doe", AuthorHandle: "john-doe", Title: "First", Body: "first body", PublishAt: &createdAt, CreatedAt: createdAt, UpdatedAt: createdAt},
+	}
+
+	type atom struct {
+		ID      string `xml:"id"`
+		Title   string `xml:"title"`
+		Updated string `xml:"updated"`
+		Entries []struct {
+			ID        string `xml:"id"`
+			Title     string `xml:"title"`
+			Published string `xml:"published"`
+			Updated   string `xml:"updated"`
+			Link      struct {
+				Href string `xml:"href,attr"`
+			} `xml:"link"`
+			Content string `xml:"content"`
+		} `xml:"entry"`
+	}
+
+	type rss struct {
+		Channel struct {
+			Title string `xml:"title"`
+			Items []struct {
+				Title   string `xml:"title"`
+				Link    string `xml:"link"`
+				GUID    string `xml:"guid"`
+				PubDate string `xml:"pubDate"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dep := initialize(ctrl)
+	dep.articleService.EXPECT().SearchArticle(gomock.Any(), model.ArticleSearchQuery{Pagination: model.Pagination{Limit: 20}}).AnyTimes().Return(articles, nil)
+	dep.articleService.EXPECT().SearchArticle(gomock.Any(), model.ArticleSearchQuery{Author: "john-doe", Pagination: model.Pagination{Limit: 5}}).AnyTimes().Return(articles, nil)
+	dep.articleService.EXPECT().LastModified(gomock.Any()).AnyTimes().Return(updatedAt, nil)
+	dep.authorService.EXPECT().GetAuthor(gomock.Any(), "john-doe").AnyTimes().Return(model.Author{Handle: "john-doe", Name: "John Doe"}, nil)
+	dep.authorService.EXPECT().GetAuthor(gomock.Any(), "jane-doe").AnyTimes().Return(model.Author{}, service.ErrAuthorNotFound)
+
+	api := restapi.New(dep.articleService, dep.authorService, restapi.WithPublicURL("https://example.com/"))
+	server := httptest.NewServer(api.Router())
+	defer server.Close()
+
+	t.Run("atom", func(t *testing.T) {
+		resp, err := http.DefaultClient.Get(server.URL + "/feed.atom")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "application/atom+xml; charset=utf-8", resp.Header.Get("Content-Type"))
+		assert.Equal(t, updatedAt.Format(http.TimeFormat), resp.Header.Get("Last-Modified"))
+
+		var feed atom
+		err = xml.NewDecoder(resp.Body).Decode(&feed)
+		assert.NoError(t, err)
+		assert.Equal(t, "2021-02-01T10:00:00Z", feed.Updated)
+		assert.Len(t, feed.Entries, 2)
+		assert.Equal(t, "https://example.com/articles/2", feed.Entries[0].Link.Href)
+		assert.Equal(t, "<p>second &lt;b&gt;body&lt;/b&gt;</p>\n", feed.Entries[0].Content)
+		assert.Equal(t, "2021-01-30T10:00:00Z", feed.Entries[1].Published)
+		assert.Regexp(t, `^urn:uuid:[0-9a-f]{8}-[0-9a-f]{4}-5[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, feed.Entries[0].ID)
+		assert.NotEqual(t, feed.Entries[0].ID, feed.Entries[1].ID)
+	})
+
+	t.Run("rss guid match atom id", func(t *testing.T) {
+		resp, err := http.DefaultClient.Get(server.URL + "/feed.rss")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "application/rss+xml; charset=utf-8", resp.Header.Get("Content-Type"))
+
+		var channel rss
+		err = xml.NewDecoder(resp.Body).Decode(&channel)
+		assert.NoError(t, err)
+		assert.Len(t, channel.Channel.Items, 2)
+		assert.Equal(t, "Sat, 30 Jan 2021 10:00:00 +0000", channel.Channel.Items[1].PubDate)
+
+		resp, err = http.DefaultClient.Get(server.URL + "/feed.atom")
+		assert.NoError(t, err)
+
+		var feed atom
+		err = xml.NewDecoder(resp.Body).Decode(&feed)
+		assert.NoError(t, err)
+		assert.Equal(t, feed.Entries[0].ID, channel.Channel.Items[0].GUID)
+	})
+
+	t.Run("author atom", func(t *testing.T) {
+		resp, err := http.DefaultClient.Get(server.URL + "/authors/john-doe/feed.atom?limit=5")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var feed atom
+		err = xml.NewDecoder(resp.Body).Decode(&feed)
+		assert.NoError(t, err)
+		assert.Equal(t, "Articles by John Doe", feed.Title)
+		assert.Len(t, feed.Entries, 2)
+	})
+
+	t.Run("author not found", func(t *testing.T) {
+		resp, err := http.DefaultClient.Get(server.URL + "/authors/jane-doe/feed.atom")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("not modified", func(t *testing.T) {
+		resp, err := http.DefaultClient.Get(server.URL + "/feed.rss")
+		assert.NoError(t, err)
+
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/feed.rss", nil)
+		req.Header.Set("If-None-Match", resp.Header.Get("ETag"))
+		resp, err = http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+
+		req, _ = http.NewRequest(http.MethodGet, server.URL+"/feed.atom", nil)
+		req.Header.Set("If-Modified-Since", updatedAt.Format(http.TimeFormat))
+		resp, err = http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+	})
+}
+
+func TestFeedContent(t *testing.T) {
+	articles := []model.Article{
+		{ID: 3, Title: "Stored", Body: "<p>stored</p><script>alert(1)</script>", BodyFormat: model.BodyFormatHTML, BodyHTML: "<p>stored</p>"},
+		{ID: 2, Title: "Legacy HTML", Body: "<p>legacy</p><script>alert(1)</script>", BodyFormat: model.BodyFormatHTML},
+		{ID: 1, Title: "Legacy plain", Body: "<script>alert(1)</script>"},
+	}
+
+	type atom struct {
+		Entries []struct {
+			Content struct {
+				Type  string `xml:"type,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"content"`
+		} `xml:"entry"`
+	}
+
+	type rss struct {
+		Channel struct {
+			Items []struct {
+				Description string `xml:"description"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+
+	expected := []string{
+		"<p>stored</p>",
+		"<p>legacy</p>",
+		"<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>\n",
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dep := initialize(ctrl)
+	dep.articleService.EXPECT().SearchArticle(gomock.Any(), gomock.Any()).AnyTimes().Return(articles, nil)
+	dep.articleService.EXPECT().LastModified(gomock.Any()).AnyTimes().Return(time.Time{}, nil)
+
+	api := restapi.New(dep.articleService, dep.authorService, restapi.WithPublicURL("https://example.com"))
+	server := httptest.NewServer(api.Router())
+	defer server.Close()
+
+	t.Run("atom", func(t *testing.T) {
+		resp, err := http.DefaultClient.Get(server.URL + "/feed.atom")
+		assert.NoError(t, err)
+
+		var feed atom
+		assert.NoError(t, xml.NewDecoder(resp.Body).Decode(&feed))
+		if assert.Len(t, feed.Entries, len(expected)) {
+			for i, entry := range feed.Entries {
+				assert.Equal(t, "html", entry.Content.Type)
+				assert.Equal(t, expected[i], entry.Content.Value)
+			}
+		}
+	})
+
+	t.Run("rss", func(t *testing.T) {
+		resp, err := http.DefaultClient.Get(server.URL + "/feed.rss")
+		assert.NoError(t, err)
+
+		var channel rss
+		assert.NoError(t, xml.NewDecoder(resp.Body).Decode(&channel))
+		if assert.Len(t, channel.Channel.Items, len(expected)) {
+			for i, item := range channel.Channel.Items {
+				assert.Equal(t, expected[i], item.Description)
+			}
+		}
+	})
+}
+
+func TestFeedBaseURL(t *testing.T) {
+	articles := []model.Article{{ID: 1, Title: "First", Body: "first body"}}
+
+	type rss struct {
+		Channel struct {
+			Items []struct {
+				Link string `xml:"link"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+
+	tests := []struct {
+		name                 string
+		options              []restapi.Option
+		expectedLink         string
+		expectedCacheControl string
+	}{
+		{
+			name:                 "configured public url",
+			options:              []restapi.Option{restapi.WithPublicURL("https://example.com"), restapi.WithCacheControl("public, max-age=60")},
+			expectedLink:         "https://example.com/articles/1",
+			expectedCacheControl: "public, max-age=60",
+		},
+		{
+			name:                 "request host not publicly cached",
+			options:              []restapi.Option{restapi.WithCacheControl("public, max-age=60")},
+			expectedLink:         "http://evil.example.com/articles/1",
+			expectedCacheControl: "private, no-cache",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dep := initialize(ctrl)
+			dep.articleService.EXPECT().SearchArticle(gomock.Any(), gomock.Any()).Return(articles, nil)
+			dep.articleService.EXPECT().LastModified(gomock.Any()).AnyTimes().Return(time.Time{}, nil)
+
+			api := restapi.New(dep.articleService, dep.authorService, tc.options...)
+			server := httptest.NewServer(api.Router())
+			defer server.Close()
+
+			req, err := http.NewRequest(http.MethodGet, server.URL+"/feed.rss", nil)
+			assert.NoError(t, err)
+			req.Host = "evil.example.com"
+
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, tc.expectedCacheControl, resp.Header.Get("Cache-Control"))
+
+			var channel rss
+			assert.NoError(t, xml.NewDecoder(resp.Body).Decode(&channel))
+			if assert.Len(t, channel.Channel.Items, 1) {
+				assert.Equal(t, tc.expectedLink, channel.Channel.Items[0].Link)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+
+	"github.com/go-redis/redis"
+	"github.com/go-sql-driver/mysql"
+	"github.com/subosito/gotenv"
+
+	"github.com/prabudzak/article/logger"
+	"github.com/prabudzak/article/model"
+	"github.com/prabudzak/article/render"
+	articledb "github.com/prabudzak/article/service/article/mysql"
+	articlecache "github.com/prabudzak/article/service/article/redis"
+)
+
+const batchSize = 500
+
+type legacyArticle struct {
+	id         int
+	body       string
+	bodyFormat string
+}
+
+// summarybackfill derive the stored rendering and summary of articles written
+// before summaries, and cache them again, so listings without body carry their
+// excerpt, word count and reading time. Cached articles of previous cache
+// layouts are removed afterwards, as they are never read nor expired
+func main() {
+	gotenv.Load()
+
+	logLevel, err := logger.ParseLevel(os.Getenv("LOG_LEVEL"))
+	if err != nil {
+		logger.Default().Fatal("log level invalid", logger.Err(err))
+	}
+	log := logger.New(os.Stderr, logLevel)
+	logger.SetDefault(log)
+
+	sqlCfg := mysql.NewConfig()
+	sqlCfg.Addr = fmt.Sprintf("%s:%s", os.Getenv("MYSQL_HOST"), os.Getenv("MYSQL_PORT"))
+	sqlCfg.User = os.Getenv("MYSQL_USERNAME")
+	sqlCfg.Passwd = os.Getenv("MYSQL_PASSWORD")
+	sqlCfg.DBName = os.Getenv("MYSQL_DATABASE")
+	sqlCfg.ParseTime = true
+
+	dbDriver, err := mysql.NewConnector(sqlCfg)
+	if err != nil {
+		log.Fatal("mysql connector not created", logger.Err(err))
+	}
+
+	conn := sql.OpenDB(dbDriver)
+	defer conn.Close()
+
+	redisClient := redis.NewClient(&redis.Options{
+		Addr: os.Getenv("REDIS_ADDR"),
+	})
+	defer redisClient.Close()
+
+	ctx := logger.NewContext(context.Background(), log)
+	database := articledb.NewArticleDatabase(conn)
+	cache := articlecache.NewArticleCache(redisClient)
+
+	var backfilled, lastID int
+	for {
+		articles, err := legacyArticles(ctx, conn, lastID)
+		if err != nil {
+			log.Fatal("backfill stopped", logger.Int("backfilled", backfilled), logger.Err(err))
+		}
+
+		if len(articles) == 0 {
+			break
+		}
+
+		for _, legacy := range articles {
+			lastID = legacy.id
+
+			rendered := render.Article(model.Article{Body: legacy.body, BodyFormat: legacy.bodyFormat})
+			_, err = conn.ExecContext(ctx, "UPDATE article SET body_format = ?, body_html = ?, excerpt = ?, word_count = ?, reading_time = ? WHERE id = ? AND word_count = 0",
+				rendered.BodyFormat, rendered.BodyHTML, rendered.Excerpt, rendered.WordCount, rendered.ReadingTime, legacy.id)
+			if err != nil {
+				log.Fatal("backfill stopped", logger.Int("backfilled", backfilled), logger.Err(err))
+			}
+
+			article, err := database.Get(ctx, legacy.id)
+			if err != nil {
+				log.Fatal("backfill stopped", logger.Int("backfilled", backfilled), logger.Err(err))
+			}
+
+			err = cache.Cache(ctx, article)
+			if err != nil {
+				log.Fatal("backfill stopped", logger.Int("backfilled", backfilled), logger.Err(err))
+			}
+			backfilled++
+		}
+	}
+
+	log.Info("backfilled", logger.Int("backfilled", backfilled))
+
+	removed, err := cache.RemoveLegacy(ctx)
+	if err != nil {
+		log.Fatal("legacy cache removal stopped", logger.Int("removed", removed), logger.Err(err))
+	}
+
+	log.Info("legacy cached articles removed", logger.Int("removed", removed))
+}
+
+// legacyArticles retrieve the next batch of articles after given id with a
+// body but no summary
+func legacyArticles(ctx context.Context, conn *sql.DB, afterID int) ([]legacyArticle, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT id, body, body_format FROM article WHERE id > ? AND word_count = 0 AND body <> '' ORDER BY id LIMIT ?", afterID, batchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var articles []legacyArticle
+	for rows.Next() {
+		var article legacyArticle
+		if err := rows.Scan(&article.id, &article.body, &article.bodyFormat); err != nil {
+			return nil, err
+		}
+		articles = append(articles, article)
+	}
+
+	return articles, rows.Err()
+}
@@ -18,18 +18,20 @@ import (
 )
 
 type testContext struct {
-	url string
-	wg  *sync.WaitGroup
+	url    string
+	apiKey string
+	wg     *sync.WaitGroup
 }
 
 func main() {
 	gotenv.Load()
 	t := &testContext{
-		url: fmt.Sprintf("%s:%s", os.Getenv("URL"), os.Getenv("PORT")),
-		wg:  &sync.WaitGroup{},
+		url:    fmt.Sprintf("%s:%s", os.Getenv("URL"), os.Getenv("PORT")),
+		apiKey: os.Getenv("API_KEY"),
+		wg:     &sync.WaitGroup{},
 	}
 	t.wg.Add(4)
-	go testHealthz(t)
+	go testReadyz(t)
 	go testCreateArticle(t)
 	go testGetArticleByAuthor(t)
 	go testGetArticleByKeyword(t)
@@ -37,10 +39,22 @@ func main() {
 	fmt.Println("done")
 }
 
-func testHealthz(t *testContext) {
+// post send a JSON request authenticated by the API key
+func (t *testContext) post(path string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodPost, t.url+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", t.apiKey)
+
+	return http.DefaultClient.Do(req)
+}
+
+func testReadyz(t *testContext) {
 	defer t.wg.Done()
 
-	resp, err := http.DefaultClient.Get(t.url + "/healthz")
+	resp, err := http.DefaultClient.Get(t.url + "/readyz")
 	if err != nil {
 		log.Fatalln(err)
 	}
@@ -109,9 +123,13 @@ func testCreateArticle(t *testContext) {
 	}
 
 	for _, tc := range tests {
-		resp, err := http.DefaultClient.Post(t.url+"/articles", "application/json", strings.NewReader(tc.body))
+		resp, err := t.post("/articles", strings.NewReader(tc.body))
 		assertNoResponseError(tc.name, err)
 		assertStatusCode(tc.name, tc.expectedStatusCode, resp.StatusCode)
+
+		if resp.StatusCode == http.StatusCreated {
+			assertCreatedArticleLocation(t, tc.name, resp)
+		}
 	}
 }
 
@@ -130,7 +148,7 @@ func testGetArticleByAuthor(t *testContext) {
 
 	n := rand.Int()%5 + 3
 	for i := 0; i < n; i++ {
-		resp, err := http.DefaultClient.Post(t.url+"/articles", "application/json", strings.NewReader(body))
+		resp, err := t.post("/articles", strings.NewReader(body))
 		assertNoResponseError(name, err)
 		assertStatusCode(name, http.StatusCreated, resp.StatusCode)
 	}
@@ -159,7 +177,7 @@ func testGetArticleByKeyword(t *testContext) {
 
 	n := rand.Int()%5 + 3
 	for i := 0; i < n; i++ {
-		resp, err := http.DefaultClient.Post(t.url+"/articles", "application/json", strings.NewReader(body))
+		resp, err := t.post("/articles", strings.NewReader(body))
 		assertNoResponseError(name, err)
 		assertStatusCode(name, http.StatusCreated, resp.StatusCode)
 	}
@@ -196,6 +214,49 @@ func assertNoResponseError(name string, err error) bool {
 	return err == nil
 }
 
+// assertCreatedArticleLocation follow Location header of a created article
+// response, expecting the same article as the response body
+func assertCreatedArticleLocation(t *testContext, name string, created *http.Response) {
+	type response struct {
+		Message string        `json:"message"`
+		Data    model.Article `json:"data"`
+	}
+
+	var createdResp response
+	err := json.NewDecoder(created.Body).Decode(&createdResp)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	location := created.Header.Get("Location")
+	if createdResp.Data.ID == 0 || location != fmt.Sprintf("/articles/%d", createdResp.Data.ID) {
+		fmt.Printf("FAIL %s:\n"+
+			"Want location of article %d\n"+
+			"Actual: %s\n",
+			name, createdResp.Data.ID, location)
+		return
+	}
+
+	resp, err := http.DefaultClient.Get(t.url + location)
+	assertNoResponseError(name, err)
+	if !assertStatusCode(name, http.StatusOK, resp.StatusCode) {
+		return
+	}
+
+	var getResp response
+	err = json.NewDecoder(resp.Body).Decode(&getResp)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if getResp.Data.ID != createdResp.Data.ID || getResp.Data.Title != createdResp.Data.Title {
+		fmt.Printf("FAIL %s:\n"+
+			"Want: article %d %s\n"+
+			"Actual: article %d %s\n",
+			name, createdResp.Data.ID, createdResp.Data.Title, getResp.Data.ID, getResp.Data.Title)
+	}
+}
+
 func assertArticleResponseAuthor(name string, reader io.ReadCloser, author string) {
 	type response struct {
 		Message string          `json:"message"`
@@ -0,0 +1,10 @@
+-- only the SHA-256 hash of a key is stored, the key is shown once on creation
+CREATE TABLE IF NOT EXISTS `api_key` (
+  `id` INT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  `name` VARCHAR(64) NOT NULL,
+  `role` VARCHAR(16) NOT NULL,
+  `key_hash` CHAR(64) NOT NULL,
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `revoked_at` TIMESTAMP NULL,
+  UNIQUE KEY `api_key_key_hash_uniq` (`key_hash`)
+) ENGINE=InnoDB;
@@ -0,0 +1,3 @@
+ALTER TABLE `api_key`
+  DROP INDEX `api_key_author_id_idx`,
+  DROP COLUMN `author_id`;
@@ -0,0 +1,4 @@
+-- author keys are bound to the author owning the articles the key may write
+ALTER TABLE `api_key`
+  ADD COLUMN `author_id` INT NULL AFTER `role`,
+  ADD INDEX `api_key_author_id_idx` (`author_id`);
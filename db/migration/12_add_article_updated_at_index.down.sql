@@ -0,0 +1 @@
+ALTER TABLE `article` DROP INDEX `article_updated_at_idx`;
@@ -0,0 +1,2 @@
+-- the latest update time of any article validate cached lists
+ALTER TABLE `article` ADD INDEX `article_updated_at_idx` (`updated_at`);
@@ -0,0 +1 @@
+ALTER TABLE `article` ADD COLUMN `language` VARCHAR(8) NOT NULL DEFAULT '' AFTER `author`;
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS `article_tag` (
+  `article_id` INT NOT NULL,
+  `tag` VARCHAR(32) NOT NULL,
+  PRIMARY KEY (`article_id`, `tag`),
+  INDEX `article_tag_tag_idx` (`tag`)
+) ENGINE=InnoDB;
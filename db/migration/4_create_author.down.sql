@@ -0,0 +1,11 @@
+ALTER TABLE `article` ADD COLUMN `author` TEXT AFTER `id`;
+
+UPDATE `article` `ar`
+JOIN `author` `au` ON `au`.`id` = `ar`.`author_id`
+SET `ar`.`author` = `au`.`name`;
+
+ALTER TABLE `article`
+  DROP INDEX `article_author_id_idx`,
+  DROP COLUMN `author_id`;
+
+DROP TABLE IF EXISTS `author`;
@@ -0,0 +1,49 @@
+CREATE TABLE IF NOT EXISTS `author` (
+  `id` INT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  `handle` VARCHAR(64) NOT NULL,
+  `name` VARCHAR(255) NOT NULL,
+  `bio` TEXT,
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE KEY `author_handle_uniq` (`handle`)
+) ENGINE=InnoDB;
+
+-- derive handles of existing author names the same way as model.AuthorHandle:
+-- ASCII whitespaces become spaces, runs of spaces are collapsed, then the
+-- trimmed lower case name is joined by hyphens
+ALTER TABLE `article` ADD COLUMN `author_handle` TEXT NULL AFTER `author`;
+
+UPDATE `article`
+SET `author_handle` = LOWER(TRIM(
+  REPLACE(REPLACE(REPLACE(REPLACE(REPLACE(IFNULL(`author`, ''),
+    CHAR(9), ' '), CHAR(10), ' '), CHAR(11), ' '), CHAR(12), ' '), CHAR(13), ' ')
+));
+
+-- each pass halve runs of spaces, eight passes collapse runs of 256 spaces
+UPDATE `article`
+SET `author_handle` = REPLACE(REPLACE(REPLACE(REPLACE(REPLACE(REPLACE(REPLACE(REPLACE(`author_handle`,
+  '  ', ' '), '  ', ' '), '  ', ' '), '  ', ' '), '  ', ' '), '  ', ' '), '  ', ' '), '  ', ' ');
+
+-- handles longer than the 64 characters of author.handle are truncated, the
+-- service reject such names for new authors. Articles without author name are
+-- attributed to an unknown author, so every article keep an author
+UPDATE `article`
+SET `author_handle` = TRIM(TRAILING '-' FROM LEFT(REPLACE(`author_handle`, ' ', '-'), 64));
+
+UPDATE `article` SET `author_handle` = 'unknown' WHERE `author_handle` = '';
+
+INSERT INTO `author` (`handle`, `name`)
+SELECT `author_handle`, IF(MIN(TRIM(IFNULL(`author`, ''))) = '', 'Unknown', LEFT(MIN(TRIM(`author`)), 255))
+FROM `article`
+GROUP BY `author_handle`;
+
+ALTER TABLE `article` ADD COLUMN `author_id` INT NULL AFTER `id`;
+
+UPDATE `article` `ar`
+JOIN `author` `au` ON `au`.`handle` = `ar`.`author_handle`
+SET `ar`.`author_id` = `au`.`id`;
+
+ALTER TABLE `article`
+  ADD INDEX `article_author_id_idx` (`author_id`),
+  DROP COLUMN `author_handle`,
+  DROP COLUMN `author`;
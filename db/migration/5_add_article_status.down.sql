@@ -0,0 +1,4 @@
+ALTER TABLE `article`
+  DROP INDEX `article_status_publish_at_idx`,
+  DROP COLUMN `publish_at`,
+  DROP COLUMN `status`;
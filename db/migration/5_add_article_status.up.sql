@@ -0,0 +1,7 @@
+ALTER TABLE `article`
+  ADD COLUMN `status` VARCHAR(16) NOT NULL DEFAULT 'published' AFTER `body`,
+  ADD COLUMN `publish_at` TIMESTAMP NULL DEFAULT NULL AFTER `status`;
+
+UPDATE `article` SET `publish_at` = `created_at`;
+
+ALTER TABLE `article` ADD INDEX `article_status_publish_at_idx` (`status`, `publish_at`);
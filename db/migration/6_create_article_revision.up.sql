@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS `article_revision` (
+  `article_id` INT NOT NULL,
+  `revision` INT NOT NULL,
+  `author_id` INT NOT NULL,
+  `language` VARCHAR(8) NOT NULL DEFAULT '',
+  `title` TEXT,
+  `body` TEXT,
+  `tags` TEXT,
+  `status` VARCHAR(16) NOT NULL,
+  `publish_at` TIMESTAMP NULL DEFAULT NULL,
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`article_id`, `revision`)
+) ENGINE=InnoDB;
+
+-- backfill existing articles as their first revision
+INSERT INTO `article_revision` (`article_id`, `revision`, `author_id`, `language`, `title`, `body`, `tags`, `status`, `publish_at`, `created_at`)
+SELECT `ar`.`id`, 1, `ar`.`author_id`, `ar`.`language`, `ar`.`title`, `ar`.`body`,
+  CONCAT('[', IFNULL(GROUP_CONCAT(CONCAT('"', `at`.`tag`, '"') ORDER BY `at`.`tag`), ''), ']'),
+  `ar`.`status`, `ar`.`publish_at`, `ar`.`updated_at`
+FROM `article` `ar`
+LEFT JOIN `article_tag` `at` ON `at`.`article_id` = `ar`.`id`
+GROUP BY `ar`.`id`;
@@ -0,0 +1,7 @@
+ALTER TABLE `article` ADD COLUMN `version` INT NOT NULL DEFAULT 1 AFTER `publish_at`;
+
+-- existing articles start at the version of their latest revision
+UPDATE `article` `ar`
+JOIN (SELECT `article_id`, MAX(`revision`) AS `revision` FROM `article_revision` GROUP BY `article_id`) `rv`
+  ON `rv`.`article_id` = `ar`.`id`
+SET `ar`.`version` = `rv`.`revision`;
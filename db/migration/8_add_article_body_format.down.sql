@@ -0,0 +1,3 @@
+ALTER TABLE `article_revision` DROP COLUMN `body_format`;
+ALTER TABLE `article` DROP COLUMN `body_html`;
+ALTER TABLE `article` DROP COLUMN `body_format`;
@@ -0,0 +1,5 @@
+ALTER TABLE `article` ADD COLUMN `body_format` VARCHAR(16) NOT NULL DEFAULT 'plain' AFTER `body`;
+-- rendered HTML of articles written before body formats is left NULL, they
+-- are plain text rendered on read
+ALTER TABLE `article` ADD COLUMN `body_html` MEDIUMTEXT NULL AFTER `body_format`;
+ALTER TABLE `article_revision` ADD COLUMN `body_format` VARCHAR(16) NOT NULL DEFAULT 'plain' AFTER `body`;
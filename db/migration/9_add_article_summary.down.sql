@@ -0,0 +1,3 @@
+ALTER TABLE `article` DROP COLUMN `reading_time`;
+ALTER TABLE `article` DROP COLUMN `word_count`;
+ALTER TABLE `article` DROP COLUMN `excerpt`;
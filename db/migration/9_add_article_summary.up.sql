@@ -0,0 +1,5 @@
+-- summary of articles written before summaries is stored by the
+-- backfill-summary command
+ALTER TABLE `article` ADD COLUMN `excerpt` VARCHAR(255) NOT NULL DEFAULT '' AFTER `body_html`;
+ALTER TABLE `article` ADD COLUMN `word_count` INT NOT NULL DEFAULT 0 AFTER `excerpt`;
+ALTER TABLE `article` ADD COLUMN `reading_time` INT NOT NULL DEFAULT 0 AFTER `word_count`;
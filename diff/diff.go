@@ -0,0 +1,264 @@
+// Package diff produce line based unified diff between two texts
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines shown around each change
+const contextLines = 3
+
+type operation int
+
+const (
+	equal operation = iota
+	remove
+	insert
+)
+
+type line struct {
+	op   operation
+	text string
+}
+
+// Unified return the unified diff of two texts labelled with given names, or
+// an empty string when both texts are equal
+func Unified(fromName, toName, from, to string) string {
+	lines := compare(splitLines(from), splitLines(to))
+
+	var b strings.Builder
+	for _, h := range hunks(lines) {
+		if b.Len() == 0 {
+			fmt.Fprintf(&b, "--- %s\n+++ %s\n", fromName, toName)
+		}
+		b.WriteString(h)
+	}
+
+	return b.String()
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// maxEdits is the maximum number of removed and inserted lines searched for a
+// shortest edit script. Time grow with the number of lines times the edits,
+// and memory with the square of the edits, so texts differing more are diffed
+// as a whole replacement of their differing lines instead
+const maxEdits = 1000
+
+// compare find the shortest edit script turning from into to, by Myers'
+// algorithm between their common prefix and suffix
+func compare(from, to []string) []line {
+	prefix := 0
+	for prefix < len(from) && prefix < len(to) && from[prefix] == to[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(from)-prefix && suffix < len(to)-prefix && from[len(from)-1-suffix] == to[len(to)-1-suffix] {
+		suffix++
+	}
+
+	lines := make([]line, 0, len(from)+len(to)-prefix-suffix)
+	for _, text := range from[:prefix] {
+		lines = append(lines, line{op: equal, text: text})
+	}
+	lines = append(lines, shortestEdit(from[prefix:len(from)-suffix], to[prefix:len(to)-suffix])...)
+	for _, text := range from[len(from)-suffix:] {
+		lines = append(lines, line{op: equal, text: text})
+	}
+
+	return lines
+}
+
+// shortestEdit return the edit script of Myers' greedy algorithm, or a
+// replacement of every line when the shortest script exceed maxEdits. The
+// furthest reaching x of each diagonal k = x - y is recorded for every number
+// of edits d, within diagonals -d-1 to d+1, to walk the script back
+func shortestEdit(from, to []string) []line {
+	n, m := len(from), len(to)
+	limit := n + m
+	if limit > maxEdits {
+		limit = maxEdits
+	}
+
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	trace := make([][]int, 0, limit+1)
+
+	for d := 0; d <= limit; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+
+			y := x - k
+			for x < n && y < m && from[x] == to[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				return backtrack(from, to, trace)
+			}
+		}
+	}
+
+	lines := make([]line, 0, n+m)
+	for _, text := range from {
+		lines = append(lines, line{op: remove, text: text})
+	}
+	for _, text := range to {
+		lines = append(lines, line{op: insert, text: text})
+	}
+	return lines
+}
+
+// backtrack walk the recorded furthest reaching paths back from the end of
+// both texts and return the edit script in order
+func backtrack(from, to []string, trace [][]int) []line {
+	lines := []line{}
+	x, y := len(from), len(to)
+
+	for d := len(trace) - 1; d >= 0; d-- {
+		// trace[d] hold diagonals -d-1 to d+1 before d edits were searched
+		v := func(k int) int {
+			return trace[d][k+d+1]
+		}
+
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && v(k-1) < v(k+1)) {
+			prevK = k + 1
+		}
+		prevX := v(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			lines = append(lines, line{op: equal, text: from[x-1]})
+			x--
+			y--
+		}
+
+		if d > 0 {
+			if x == prevX {
+				lines = append(lines, line{op: insert, text: to[y-1]})
+			} else {
+				lines = append(lines, line{op: remove, text: from[x-1]})
+			}
+		}
+
+		x, y = prevX, prevY
+	}
+
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	return lines
+}
+
+// hunks group the edit script into unified diff hunks with surrounding
+// context lines
+func hunks(lines []line) []string {
+	result := []string{}
+
+	fromLine, toLine := 1, 1
+	for start := 0; start < len(lines); {
+		// find next change
+		change := start
+		for change < len(lines) && lines[change].op == equal {
+			change++
+			fromLine++
+			toLine++
+		}
+		if change == len(lines) {
+			break
+		}
+
+		// extend hunk until the unchanged gap is wider than both contexts
+		end := change
+		for end < len(lines) {
+			if lines[end].op != equal {
+				end++
+				continue
+			}
+
+			gap := end
+			for gap < len(lines) && lines[gap].op == equal {
+				gap++
+			}
+			if gap == len(lines) || gap-end > 2*contextLines {
+				break
+			}
+			end = gap
+		}
+
+		before := change - start
+		if before > contextLines {
+			before = contextLines
+		}
+		after := 0
+		for end+after < len(lines) && after < contextLines && lines[end+after].op == equal {
+			after++
+		}
+
+		hunkStart, hunkEnd := change-before, end+after
+		hunkFrom, hunkTo := fromLine-before, toLine-before
+
+		var b strings.Builder
+		fromCount, toCount := 0, 0
+		for _, l := range lines[hunkStart:hunkEnd] {
+			switch l.op {
+			case equal:
+				b.WriteString(" " + l.text + "\n")
+				fromCount++
+				toCount++
+			case remove:
+				b.WriteString("-" + l.text + "\n")
+				fromCount++
+			case insert:
+				b.WriteString("+" + l.text + "\n")
+				toCount++
+			}
+		}
+
+		result = append(result, fmt.Sprintf("@@ -%s +%s @@\n", hunkRange(hunkFrom, fromCount), hunkRange(hunkTo, toCount))+b.String())
+
+		for _, l := range lines[change:hunkEnd] {
+			if l.op != insert {
+				fromLine++
+			}
+			if l.op != remove {
+				toLine++
+			}
+		}
+		start = hunkEnd
+	}
+
+	return result
+}
+
+// hunkRange format hunk line range, where an empty range start at the line
+// before it
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+
+	return fmt.Sprintf("%d,%d", start, count)
+}
@@ -0,0 +1,185 @@
+package diff_test
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/prabudzak/article/diff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		expected string
+	}{
+		{
+			name:     "equal text",
+			from:     "a\nb\n",
+			to:       "a\nb\n",
+			expected: "",
+		},
+		{
+			name: "added text",
+			from: "",
+			to:   "a\nb\n",
+			expected: "--- from\n+++ to\n" +
+				"@@ -0,0 +1,2 @@\n" +
+				"+a\n" +
+				"+b\n",
+		},
+		{
+			name: "removed text",
+			from: "a\nb\n",
+			to:   "",
+			expected: "--- from\n+++ to\n" +
+				"@@ -1,2 +0,0 @@\n" +
+				"-a\n" +
+				"-b\n",
+		},
+		{
+			name: "changed line with context",
+			from: "a\nb\nc\nd\ne\nf\n",
+			to:   "a\nb\nc\nD\ne\nf\n",
+			expected: "--- from\n+++ to\n" +
+				"@@ -1,6 +1,6 @@\n" +
+				" a\n" +
+				" b\n" +
+				" c\n" +
+				"-d\n" +
+				"+D\n" +
+				" e\n" +
+				" f\n",
+		},
+		{
+			name: "distant changes in separate hunks",
+			from: "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n",
+			to:   "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\nn\n",
+			expected: "--- from\n+++ to\n" +
+				"@@ -1,5 +1,5 @@\n" +
+				" a\n" +
+				"-b\n" +
+				"+B\n" +
+				" c\n" +
+				" d\n" +
+				" e\n" +
+				"@@ -11,3 +11,4 @@\n" +
+				" k\n" +
+				" l\n" +
+				" m\n" +
+				"+n\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, diff.Unified("from", "to", tc.from, tc.to))
+		})
+	}
+}
+
+// edits count removed and inserted lines of a unified diff
+func edits(unified string) int {
+	count := 0
+	for _, l := range strings.Split(unified, "\n") {
+		if strings.HasPrefix(l, "---") || strings.HasPrefix(l, "+++") {
+			continue
+		}
+		if strings.HasPrefix(l, "-") || strings.HasPrefix(l, "+") {
+			count++
+		}
+	}
+	return count
+}
+
+// minEdits return the number of removed and inserted lines of the shortest
+// edit script, from the longest common subsequence of both texts
+func minEdits(from, to []string) int {
+	lcs := make([][]int, len(from)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(to)+1)
+	}
+	for i := len(from) - 1; i >= 0; i-- {
+		for j := len(to) - 1; j >= 0; j-- {
+			if from[i] == to[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] > lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	return len(from) + len(to) - 2*lcs[0][0]
+}
+
+func TestUnifiedShortest(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	randomLines := func() []string {
+		lines := make([]string, random.Intn(30))
+		for i := range lines {
+			lines[i] = string(rune('a' + random.Intn(4)))
+		}
+		return lines
+	}
+
+	for i := 0; i < 200; i++ {
+		from, to := randomLines(), randomLines()
+		unified := diff.Unified("from", "to", strings.Join(from, "\n"), strings.Join(to, "\n"))
+		assert.Equal(t, minEdits(from, to), edits(unified), "from %q to %q", from, to)
+	}
+}
+
+func TestUnifiedMaxBody(t *testing.T) {
+	// 65535 bytes is the maximum article body
+	lines := make([]string, 0, 8192)
+	for i := 0; i < 8191; i++ {
+		lines = append(lines, fmt.Sprintf("line %02d", i%100))
+	}
+	body := strings.Join(lines, "\n")
+
+	changed := append([]string(nil), lines...)
+	for i := 0; i < len(changed); i += 1000 {
+		changed[i] = "changed"
+	}
+
+	tests := []struct {
+		name          string
+		from          string
+		to            string
+		expectedEdits int
+	}{
+		{
+			name:          "few changes",
+			from:          body,
+			to:            strings.Join(changed, "\n"),
+			expectedEdits: 2 * 9,
+		},
+		{
+			name:          "every line changed",
+			from:          strings.Repeat("a\n", 32767) + "a",
+			to:            strings.Repeat("b\n", 32767) + "b",
+			expectedEdits: 2 * 32768,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.LessOrEqual(t, len(tc.from), 65535)
+			assert.LessOrEqual(t, len(tc.to), 65535)
+
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+			unified := diff.Unified("from", "to", tc.from, tc.to)
+			runtime.ReadMemStats(&after)
+
+			assert.Equal(t, tc.expectedEdits, edits(unified))
+			assert.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(64<<20), "diff allocate less than 64 MiB")
+		})
+	}
+}
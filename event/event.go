@@ -16,6 +16,33 @@ func (a ArticleCreated) String() string {
 	return "event_article_created"
 }
 
+// ArticleUpdated represent article updated event
+type ArticleUpdated struct {
+	Article model.Article
+}
+
+func (a ArticleUpdated) String() string {
+	return "event_article_updated"
+}
+
+// ArticlePublished represent article published event
+type ArticlePublished struct {
+	Article model.Article
+}
+
+func (a ArticlePublished) String() string {
+	return "event_article_published"
+}
+
+// ArticleArchived represent article archived event
+type ArticleArchived struct {
+	Article model.Article
+}
+
+func (a ArticleArchived) String() string {
+	return "event_article_archived"
+}
+
 // ArticleCreateFailed represent article create failed event
 type ArticleCreateFailed struct {
 	Article model.Article
@@ -42,3 +69,25 @@ type ArticleCachingFailed struct {
 func (a ArticleCachingFailed) String() string {
 	return "event_article_caching_failed"
 }
+
+// ArticleIDOf return the id of the article an event is about, or 0 when the
+// event is not about an article
+func ArticleIDOf(e Event) int {
+	switch message := e.(type) {
+	case ArticleCreated:
+		return message.Article.ID
+	case ArticleUpdated:
+		return message.Article.ID
+	case ArticlePublished:
+		return message.Article.ID
+	case ArticleArchived:
+		return message.Article.ID
+	case ArticleCreateFailed:
+		return message.Article.ID
+	case ArticleNotFound:
+		return message.ArticleID
+	case ArticleCachingFailed:
+		return message.Article.ID
+	}
+	return 0
+}
@@ -0,0 +1,64 @@
+// Package instrumented decorate event dispatcher with event metrics and
+// trace spans
+package instrumented
+
+import (
+	"context"
+
+	"github.com/prabudzak/article/event"
+	"github.com/prabudzak/article/metrics"
+	"github.com/prabudzak/article/tracing"
+	"go.opentelemetry.io/otel/semconv"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// messagingSystem is the messaging system of event spans
+const messagingSystem = "article.event"
+
+// Dispatcher represent event dispatcher instrumenting decorator
+type Dispatcher struct {
+	next event.Dispatcher
+}
+
+// NewDispatcher create a new instance of event dispatcher recording events
+// dispatched through next and failures of their subscribers
+func NewDispatcher(next event.Dispatcher) *Dispatcher {
+	return &Dispatcher{
+		next: next,
+	}
+}
+
+// AddSubscriber register a subscriber counting its failures, traced as a
+// consumer of the dispatched event
+func (d *Dispatcher) AddSubscriber(ctx context.Context, e event.Event, fn event.SubscribeFunc) error {
+	return d.next.AddSubscriber(ctx, e, func(ctx context.Context, e event.Event) error {
+		ctx, span := tracing.Start(ctx, "event.subscribe "+e.String(),
+			trace.WithSpanKind(trace.SpanKindConsumer),
+			trace.WithAttributes(semconv.MessagingSystemKey.String(messagingSystem), semconv.MessagingDestinationKey.String(e.String())),
+		)
+		err := fn(ctx, e)
+		tracing.End(span, err)
+		if err != nil {
+			metrics.EventSubscriberFailures.WithLabelValues(e.String()).Inc()
+		}
+		return err
+	})
+}
+
+// Dispatch send an event to next, counted as queued until next take it and
+// traced as the producer of the event
+func (d *Dispatcher) Dispatch(ctx context.Context, e event.Event) error {
+	metrics.EventsDispatched.WithLabelValues(e.String()).Inc()
+
+	ctx, span := tracing.Start(ctx, "event.dispatch "+e.String(),
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(semconv.MessagingSystemKey.String(messagingSystem), semconv.MessagingDestinationKey.String(e.String())),
+	)
+
+	metrics.EventQueueDepth.Inc()
+	err := d.next.Dispatch(ctx, e)
+	metrics.EventQueueDepth.Dec()
+
+	tracing.End(span, err)
+	return err
+}
@@ -0,0 +1,93 @@
+package instrumented_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/oteltest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/prabudzak/article/event"
+	"github.com/prabudzak/article/event/instrumented"
+	"github.com/prabudzak/article/metrics"
+)
+
+type eventTested struct{}
+
+func (e eventTested) String() string {
+	return "event_tested"
+}
+
+// syncDispatcher call subscribers of an event as it is dispatched
+type syncDispatcher struct {
+	subscribers []event.SubscribeFunc
+	queueDepth  float64
+}
+
+func (s *syncDispatcher) AddSubscriber(ctx context.Context, e event.Event, fn event.SubscribeFunc) error {
+	s.subscribers = append(s.subscribers, fn)
+	return nil
+}
+
+func (s *syncDispatcher) Dispatch(ctx context.Context, e event.Event) error {
+	s.queueDepth = testutil.ToFloat64(metrics.EventQueueDepth)
+	for _, fn := range s.subscribers {
+		fn(ctx, e)
+	}
+	return nil
+}
+
+func TestDispatcher(t *testing.T) {
+	dispatched := metrics.EventsDispatched.WithLabelValues("event_tested")
+	failures := metrics.EventSubscriberFailures.WithLabelValues("event_tested")
+	dispatchedBefore := testutil.ToFloat64(dispatched)
+	failuresBefore := testutil.ToFloat64(failures)
+
+	next := &syncDispatcher{}
+	dispatcher := instrumented.NewDispatcher(next)
+
+	ctx := context.Background()
+	dispatcher.AddSubscriber(ctx, eventTested{}, func(ctx context.Context, e event.Event) error {
+		return nil
+	})
+	dispatcher.AddSubscriber(ctx, eventTested{}, func(ctx context.Context, e event.Event) error {
+		return errors.New("subscriber failed")
+	})
+
+	dispatcher.Dispatch(ctx, eventTested{})
+	dispatcher.Dispatch(ctx, eventTested{})
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(dispatched)-dispatchedBefore)
+	assert.Equal(t, float64(2), testutil.ToFloat64(failures)-failuresBefore)
+	assert.Equal(t, float64(1), next.queueDepth, "event is queued while dispatched")
+	assert.Equal(t, float64(0), testutil.ToFloat64(metrics.EventQueueDepth), "event is not queued once taken")
+}
+
+func TestDispatcherTrace(t *testing.T) {
+	recorder := &oteltest.StandardSpanRecorder{}
+	otel.SetTracerProvider(oteltest.NewTracerProvider(oteltest.WithSpanRecorder(recorder)))
+
+	dispatcher := instrumented.NewDispatcher(&syncDispatcher{})
+
+	ctx := context.Background()
+	dispatcher.AddSubscriber(ctx, eventTested{}, func(ctx context.Context, e event.Event) error {
+		return nil
+	})
+	dispatcher.Dispatch(ctx, eventTested{})
+
+	spans := map[string]*oteltest.Span{}
+	for _, span := range recorder.Completed() {
+		spans[span.Name()] = span
+	}
+
+	dispatch, subscribe := spans["event.dispatch event_tested"], spans["event.subscribe event_tested"]
+	if assert.NotNil(t, dispatch) && assert.NotNil(t, subscribe) {
+		assert.Equal(t, trace.SpanKindProducer, dispatch.SpanKind())
+		assert.Equal(t, trace.SpanKindConsumer, subscribe.SpanKind())
+		assert.Equal(t, dispatch.SpanContext().SpanID, subscribe.ParentSpanID(), "subscriber continue the trace of the dispatch")
+	}
+}
@@ -2,25 +2,78 @@ package memory
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sync"
+	"time"
 
 	"github.com/prabudzak/article/event"
+	"github.com/prabudzak/article/logger"
+	"github.com/prabudzak/article/tracing"
 )
 
+const (
+	// defaultStallTimeout is how long a dispatch may wait for the processor, or
+	// a subscriber may run, before the dispatcher is reported unhealthy
+	defaultStallTimeout = 30 * time.Second
+	// panicWindow is how long a subscriber panic is reported after it happened
+	panicWindow = time.Minute
+)
+
+// envelope carry a dispatched event with the trace context and logger it was
+// dispatched with, continued by its subscribers
+type envelope struct {
+	carrier tracing.Carrier
+	logger  *logger.Logger
+	event   event.Event
+}
+
 type Dispatcher struct {
 	subsriberMap map[string][]event.SubscribeFunc
-	eventChan    chan event.Event
+	eventChan    chan envelope
+
+	processor    int
+	running      int
+	stallTimeout time.Duration
+	mutex        sync.Mutex
+
+	// waiting is the number of dispatches waiting for the processor, which
+	// last took an event or started to be waited for at progressed
+	waiting    int
+	progressed time.Time
+	// handling hold the start time of every running subscriber call
+	handling map[int]time.Time
+	calls    int
+	panicked time.Time
+	panicErr error
+}
+
+// Option represent memory dispatcher option
+type Option func(d *Dispatcher)
 
-	processor int
-	mutex     sync.Mutex
+// WithStallTimeout set how long a dispatch may wait for the processor, or a
+// subscriber may run, before the dispatcher is reported unhealthy, default to
+// 30 seconds
+func WithStallTimeout(timeout time.Duration) Option {
+	return func(d *Dispatcher) {
+		d.stallTimeout = timeout
+	}
 }
 
-func NewDispatcher() *Dispatcher {
-	return &Dispatcher{
+func NewDispatcher(options ...Option) *Dispatcher {
+	d := &Dispatcher{
 		subsriberMap: make(map[string][]event.SubscribeFunc),
-		eventChan:    make(chan event.Event),
+		eventChan:    make(chan envelope),
 		processor:    1,
+		stallTimeout: defaultStallTimeout,
+		handling:     make(map[int]time.Time),
 	}
+
+	for _, option := range options {
+		option(d)
+	}
+
+	return d
 }
 
 func (d *Dispatcher) AddSubscriber(ctx context.Context, e event.Event, fn event.SubscribeFunc) error {
@@ -37,26 +90,113 @@ func (d *Dispatcher) AddSubscriber(ctx context.Context, e event.Event, fn event.
 }
 
 func (d *Dispatcher) Dispatch(ctx context.Context, e event.Event) error {
-	d.eventChan <- e
+	d.mutex.Lock()
+	if d.waiting == 0 {
+		d.progressed = time.Now()
+	}
+	d.waiting++
+	d.mutex.Unlock()
+
+	d.eventChan <- envelope{carrier: tracing.Inject(ctx), logger: logger.FromContext(ctx), event: e}
+
+	d.mutex.Lock()
+	d.waiting--
+	d.mutex.Unlock()
 	return nil
 }
 
 func (d *Dispatcher) Start() {
+	d.mutex.Lock()
+	d.running += d.processor
+	d.mutex.Unlock()
+
 	for i := 0; i < d.processor; i++ {
 		go d.start()
 	}
 }
 
 func (d *Dispatcher) start() {
-	for e := range d.eventChan {
-		subs, ok := d.subsriberMap[e.String()]
+	defer func() {
+		d.mutex.Lock()
+		d.running--
+		d.mutex.Unlock()
+	}()
+
+	for envelope := range d.eventChan {
+		d.mutex.Lock()
+		d.progressed = time.Now()
+		subs, ok := d.subsriberMap[envelope.event.String()]
+		d.mutex.Unlock()
 		if !ok {
 			continue
 		}
 
-		ctx := context.Background()
+		ctx := tracing.Extract(context.Background(), envelope.carrier)
+		ctx = logger.NewContext(ctx, envelope.logger.With(logger.Event(envelope.event.String())))
+		if id := event.ArticleIDOf(envelope.event); id != 0 {
+			ctx = logger.With(ctx, logger.ArticleID(id))
+		}
+
 		for _, sub := range subs {
-			go sub(ctx, e)
+			go d.subscribe(ctx, sub, envelope.event)
 		}
 	}
 }
+
+// CheckHealth report whether dispatched events are processed, which they are
+// not until the dispatcher is started, nor while dispatches wait for the
+// processor or a subscriber run longer than the stall timeout. A subscriber
+// panic is reported for a minute after it happened
+func (d *Dispatcher) CheckHealth(ctx context.Context) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.running == 0 {
+		return errors.New("event dispatcher is not processing events")
+	}
+
+	now := time.Now()
+	if d.waiting > 0 && now.Sub(d.progressed) > d.stallTimeout {
+		return fmt.Errorf("%d event dispatches waiting for over %s", d.waiting, d.stallTimeout)
+	}
+
+	for _, started := range d.handling {
+		if now.Sub(started) > d.stallTimeout {
+			return fmt.Errorf("event subscriber running for over %s", d.stallTimeout)
+		}
+	}
+
+	if !d.panicked.IsZero() && now.Sub(d.panicked) < panicWindow {
+		return d.panicErr
+	}
+	return nil
+}
+
+// subscribe call a subscriber, logging its failure. A panicking subscriber is
+// recovered, so it neither stop the service nor other subscribers
+func (d *Dispatcher) subscribe(ctx context.Context, sub event.SubscribeFunc, e event.Event) {
+	d.mutex.Lock()
+	d.calls++
+	call := d.calls
+	d.handling[call] = time.Now()
+	d.mutex.Unlock()
+
+	defer func() {
+		recovered := recover()
+
+		d.mutex.Lock()
+		defer d.mutex.Unlock()
+		delete(d.handling, call)
+
+		if recovered != nil {
+			d.panicked = time.Now()
+			d.panicErr = fmt.Errorf("event subscriber panicked: %v", recovered)
+			logger.FromContext(ctx).Error("event subscriber panicked", logger.Err(d.panicErr))
+		}
+	}()
+
+	err := sub(ctx, e)
+	if err != nil {
+		logger.FromContext(ctx).Error("event subscriber failed", logger.Err(err))
+	}
+}
@@ -1,13 +1,22 @@
 package memory_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/prabudzak/article/event"
 	"github.com/prabudzak/article/event/memory"
+	"github.com/prabudzak/article/logger"
+	"github.com/prabudzak/article/model"
+	"github.com/prabudzak/article/tracing"
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/oteltest"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type eventIncreaseCount struct{}
@@ -68,3 +77,125 @@ func TestMemoryDispatch(t *testing.T) {
 	assert.Equal(t, 4, sub.triggerCount)
 	assert.Equal(t, 1, otherSub.triggerCount)
 }
+
+func TestMemoryDispatchTraceContext(t *testing.T) {
+	tracing.Setup(context.Background(), "", "", "article")
+
+	_, span := oteltest.NewTracerProvider().Tracer("test").Start(context.Background(), "dispatch")
+	defer span.End()
+	ctx := trace.ContextWithSpan(context.Background(), span)
+
+	received := make(chan trace.SpanContext, 1)
+	dispatcher := memory.NewDispatcher()
+	dispatcher.Start()
+
+	dispatcher.AddSubscriber(ctx, eventIncreaseCount{}, func(ctx context.Context, e event.Event) error {
+		received <- trace.RemoteSpanContextFromContext(ctx)
+		return nil
+	})
+	dispatcher.Dispatch(ctx, eventIncreaseCount{})
+
+	select {
+	case spanContext := <-received:
+		assert.Equal(t, span.SpanContext().TraceID, spanContext.TraceID)
+		assert.Equal(t, span.SpanContext().SpanID, spanContext.SpanID)
+	case <-time.After(time.Second):
+		t.Fatal("subscriber not called")
+	}
+}
+
+// syncBuffer is a log output safe to read while subscribers write
+type syncBuffer struct {
+	mutex sync.Mutex
+	bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.Buffer.Write(p)
+}
+
+func (b *syncBuffer) Bytes() []byte {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return append([]byte{}, b.Buffer.Bytes()...)
+}
+
+func TestMemoryDispatchSubscriberFailure(t *testing.T) {
+	out := &syncBuffer{}
+	ctx := logger.NewContext(context.Background(), logger.New(out, logger.LevelInfo).With(logger.RequestID("req-1")))
+
+	dispatcher := memory.NewDispatcher()
+	dispatcher.Start()
+
+	dispatcher.AddSubscriber(ctx, event.ArticleCreated{}, func(ctx context.Context, e event.Event) error {
+		return errors.New("cache unreachable")
+	})
+	dispatcher.Dispatch(ctx, event.ArticleCreated{Article: model.Article{ID: 12}})
+
+	// wait until subscriber done processing
+	deadline := time.Now().Add(time.Second)
+	for len(out.Bytes()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	var line map[string]interface{}
+	err := json.Unmarshal(out.Bytes(), &line)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "event subscriber failed", line["message"])
+		assert.Equal(t, "req-1", line["request_id"])
+		assert.Equal(t, "event_article_created", line["event"])
+		assert.Equal(t, float64(12), line["article_id"])
+		assert.Equal(t, "cache unreachable", line["error"])
+	}
+}
+
+func TestMemoryDispatcherHealth(t *testing.T) {
+	ctx := logger.NewContext(context.Background(), logger.New(&syncBuffer{}, logger.LevelInfo))
+
+	t.Run("not started", func(t *testing.T) {
+		dispatcher := memory.NewDispatcher()
+		assert.Error(t, dispatcher.CheckHealth(ctx), "events are not processed before start")
+
+		dispatcher.Start()
+		assert.NoError(t, dispatcher.CheckHealth(ctx))
+	})
+
+	t.Run("subscriber stalled", func(t *testing.T) {
+		release := make(chan struct{})
+		done := make(chan struct{})
+		dispatcher := memory.NewDispatcher(memory.WithStallTimeout(10 * time.Millisecond))
+		dispatcher.Start()
+		dispatcher.AddSubscriber(ctx, eventIncreaseCount{}, func(ctx context.Context, e event.Event) error {
+			<-release
+			close(done)
+			return nil
+		})
+
+		dispatcher.Dispatch(ctx, eventIncreaseCount{})
+		time.Sleep(20 * time.Millisecond)
+		assert.Error(t, dispatcher.CheckHealth(ctx))
+
+		close(release)
+		<-done
+		time.Sleep(time.Millisecond)
+		assert.NoError(t, dispatcher.CheckHealth(ctx))
+	})
+
+	t.Run("subscriber panicked", func(t *testing.T) {
+		dispatcher := memory.NewDispatcher()
+		dispatcher.Start()
+		dispatcher.AddSubscriber(ctx, eventIncreaseCount{}, func(ctx context.Context, e event.Event) error {
+			panic("nil map")
+		})
+
+		dispatcher.Dispatch(ctx, eventIncreaseCount{})
+
+		deadline := time.Now().Add(time.Second)
+		for dispatcher.CheckHealth(ctx) == nil && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		assert.EqualError(t, dispatcher.CheckHealth(ctx), "event subscriber panicked: nil map")
+	})
+}
@@ -7,13 +7,18 @@ require (
 	github.com/go-redis/redis v6.15.9+incompatible
 	github.com/go-sql-driver/mysql v1.5.0
 	github.com/golang/mock v1.4.4
-	github.com/google/go-cmp v0.5.4 // indirect
 	github.com/julienschmidt/httprouter v1.3.0
 	github.com/mailru/easyjson v0.7.6 // indirect
 	github.com/olivere/elastic v6.2.35+incompatible
 	github.com/onsi/ginkgo v1.14.2 // indirect
 	github.com/onsi/gomega v1.10.4 // indirect
-	github.com/pkg/errors v0.9.1 // indirect
+	github.com/prometheus/client_golang v1.9.0
 	github.com/stretchr/testify v1.7.0
 	github.com/subosito/gotenv v1.2.0
+	github.com/yuin/goldmark v1.2.1
+	go.opentelemetry.io/otel v0.15.0
+	go.opentelemetry.io/otel/exporters/otlp v0.15.0
+	go.opentelemetry.io/otel/exporters/stdout v0.15.0
+	go.opentelemetry.io/otel/sdk v0.15.0
+	golang.org/x/net v0.0.0-20201202161906-c7110b5ffcbb
 )
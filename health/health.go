@@ -0,0 +1,248 @@
+// Package health check the health of the service components, reported by
+// liveness and readiness probes
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Component status
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// Overall status
+const (
+	StatusOK          = "ok"
+	StatusDegraded    = "degraded"
+	StatusUnavailable = "unavailable"
+)
+
+// Default check settings
+const (
+	defaultTimeout  = 2 * time.Second
+	defaultCacheTTL = 5 * time.Second
+)
+
+// Checker represent a component reporting its health
+type Checker interface {
+	CheckHealth(ctx context.Context) error
+}
+
+// CheckerFunc represent a function reporting the health of a component
+type CheckerFunc func(ctx context.Context) error
+
+// CheckHealth call f
+func (f CheckerFunc) CheckHealth(ctx context.Context) error {
+	return f(ctx)
+}
+
+// ComponentReport represent the health of a component
+type ComponentReport struct {
+	Status    string    `json:"status"`
+	Critical  bool      `json:"critical"`
+	Error     string    `json:"error,omitempty"`
+	Duration  float64   `json:"duration_ms"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Report represent the health of the service. The service is unavailable
+// when a critical component is down, degraded when only non-critical ones are
+type Report struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentReport `json:"components"`
+}
+
+// check represent a registered component check and its latest result
+type check struct {
+	name     string
+	checker  Checker
+	critical bool
+	liveness bool
+
+	mutex  sync.Mutex
+	result ComponentReport
+}
+
+// CheckOption represent component check option
+type CheckOption func(c *check)
+
+// NonCritical let the service degrade instead of failing when the component
+// is down
+func NonCritical() CheckOption {
+	return func(c *check) {
+		c.critical = false
+	}
+}
+
+// Liveness check the component on liveness probes too. Only components whose
+// failure require a restart of the service, rather than of a dependency,
+// should be liveness checked
+func Liveness() CheckOption {
+	return func(c *check) {
+		c.liveness = true
+	}
+}
+
+// Registry represent the registered component checks
+type Registry struct {
+	timeout  time.Duration
+	cacheTTL time.Duration
+	now      func() time.Time
+
+	mutex  sync.RWMutex
+	checks []*check
+}
+
+// Option represent health check registry configuration option
+type Option func(r *Registry)
+
+// WithTimeout fail a component check not done within timeout
+func WithTimeout(timeout time.Duration) Option {
+	return func(r *Registry) {
+		if timeout > 0 {
+			r.timeout = timeout
+		}
+	}
+}
+
+// WithCacheTTL reuse a component check result for ttl, so frequent probes do
+// not load the component
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(r *Registry) {
+		if ttl > 0 {
+			r.cacheTTL = ttl
+		}
+	}
+}
+
+// NewRegistry create a new health check registry
+func NewRegistry(options ...Option) *Registry {
+	r := &Registry{
+		timeout:  defaultTimeout,
+		cacheTTL: defaultCacheTTL,
+		now:      time.Now,
+	}
+
+	for _, option := range options {
+		option(r)
+	}
+
+	return r
+}
+
+// Register add a critical readiness check of a component
+func (r *Registry) Register(name string, checker Checker, options ...CheckOption) {
+	c := &check{
+		name:     name,
+		checker:  checker,
+		critical: true,
+	}
+
+	for _, option := range options {
+		option(c)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.checks = append(r.checks, c)
+}
+
+// Live report the health of liveness checked components
+func (r *Registry) Live(ctx context.Context) Report {
+	return r.report(ctx, true)
+}
+
+// Ready report the health of every component
+func (r *Registry) Ready(ctx context.Context) Report {
+	return r.report(ctx, false)
+}
+
+// report check components concurrently
+func (r *Registry) report(ctx context.Context, liveness bool) Report {
+	r.mutex.RLock()
+	checks := make([]*check, 0, len(r.checks))
+	for _, c := range r.checks {
+		if c.liveness || !liveness {
+			checks = append(checks, c)
+		}
+	}
+	r.mutex.RUnlock()
+
+	results := make([]ComponentReport, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c *check) {
+			defer wg.Done()
+			results[i] = r.check(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := Report{
+		Status:     StatusOK,
+		Components: make(map[string]ComponentReport, len(checks)),
+	}
+	for i, c := range checks {
+		result := results[i]
+		report.Components[c.name] = result
+
+		if result.Status == StatusUp {
+			continue
+		} else if result.Critical {
+			report.Status = StatusUnavailable
+		} else if report.Status == StatusOK {
+			report.Status = StatusDegraded
+		}
+	}
+
+	return report
+}
+
+// check return the cached result of a component check, or check it again
+// once the result expired. Concurrent probes share a single check. The check
+// of a probe gone early is abandoned rather than cached as down
+func (r *Registry) check(ctx context.Context, c *check) ComponentReport {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := r.now()
+	if !c.result.CheckedAt.IsZero() && now.Before(c.result.CheckedAt.Add(r.cacheTTL)) {
+		return c.result
+	}
+
+	checkCtx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- c.checker.CheckHealth(checkCtx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-checkCtx.Done():
+		err = errors.New("health check timed out")
+	case <-ctx.Done():
+		return ComponentReport{Status: StatusDown, Critical: c.critical, Error: ctx.Err().Error(), CheckedAt: now}
+	}
+
+	c.result = ComponentReport{
+		Status:    StatusUp,
+		Critical:  c.critical,
+		Duration:  float64(r.now().Sub(now)) / float64(time.Millisecond),
+		CheckedAt: now,
+	}
+	if err != nil {
+		c.result.Status = StatusDown
+		c.result.Error = err.Error()
+	}
+
+	return c.result
+}
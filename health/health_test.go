@@ -0,0 +1,120 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/prabudzak/article/health"
+)
+
+func up(ctx context.Context) error {
+	return nil
+}
+
+func down(ctx context.Context) error {
+	return errors.New("connection refused")
+}
+
+func hang(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestReady(t *testing.T) {
+	tests := []struct {
+		name               string
+		register           func(r *health.Registry)
+		expectedStatus     string
+		expectedComponents map[string]string
+	}{
+		{
+			name:               "no component",
+			register:           func(r *health.Registry) {},
+			expectedStatus:     health.StatusOK,
+			expectedComponents: map[string]string{},
+		},
+		{
+			name: "every component up",
+			register: func(r *health.Registry) {
+				r.Register("mysql", health.CheckerFunc(up))
+				r.Register("redis", health.CheckerFunc(up), health.NonCritical())
+			},
+			expectedStatus:     health.StatusOK,
+			expectedComponents: map[string]string{"mysql": health.StatusUp, "redis": health.StatusUp},
+		},
+		{
+			name: "non-critical component down",
+			register: func(r *health.Registry) {
+				r.Register("mysql", health.CheckerFunc(up))
+				r.Register("redis", health.CheckerFunc(down), health.NonCritical())
+			},
+			expectedStatus:     health.StatusDegraded,
+			expectedComponents: map[string]string{"mysql": health.StatusUp, "redis": health.StatusDown},
+		},
+		{
+			name: "critical component down",
+			register: func(r *health.Registry) {
+				r.Register("mysql", health.CheckerFunc(down))
+				r.Register("redis", health.CheckerFunc(down), health.NonCritical())
+			},
+			expectedStatus:     health.StatusUnavailable,
+			expectedComponents: map[string]string{"mysql": health.StatusDown, "redis": health.StatusDown},
+		},
+		{
+			name: "critical component timed out",
+			register: func(r *health.Registry) {
+				r.Register("elasticsearch", health.CheckerFunc(hang))
+			},
+			expectedStatus:     health.StatusUnavailable,
+			expectedComponents: map[string]string{"elasticsearch": health.StatusDown},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			registry := health.NewRegistry(health.WithTimeout(10 * time.Millisecond))
+			tc.register(registry)
+
+			report := registry.Ready(context.Background())
+			assert.Equal(t, tc.expectedStatus, report.Status)
+
+			components := map[string]string{}
+			for name, component := range report.Components {
+				components[name] = component.Status
+				assert.Equal(t, component.Status == health.StatusDown, component.Error != "")
+			}
+			assert.Equal(t, tc.expectedComponents, components)
+		})
+	}
+}
+
+func TestLive(t *testing.T) {
+	registry := health.NewRegistry()
+	registry.Register("mysql", health.CheckerFunc(down))
+	registry.Register("event_dispatcher", health.CheckerFunc(up), health.Liveness())
+
+	report := registry.Live(context.Background())
+	assert.Equal(t, health.StatusOK, report.Status, "dependencies are not liveness checked")
+	assert.Len(t, report.Components, 1)
+	assert.Contains(t, report.Components, "event_dispatcher")
+}
+
+func TestCachedResult(t *testing.T) {
+	calls := 0
+	checker := health.CheckerFunc(func(ctx context.Context) error {
+		calls++
+		return nil
+	})
+
+	registry := health.NewRegistry(health.WithCacheTTL(time.Hour))
+	registry.Register("mysql", checker)
+
+	first := registry.Ready(context.Background())
+	second := registry.Ready(context.Background())
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, first.Components["mysql"].CheckedAt, second.Components["mysql"].CheckedAt)
+}
@@ -0,0 +1,239 @@
+// Package logger write leveled structured logs as JSON lines. The logger of a
+// request is carried in its context, so every log of the request share its
+// fields, such as the request id
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level represent log severity
+type Level int
+
+// Log levels
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = map[Level]string{
+	LevelDebug: "debug",
+	LevelInfo:  "info",
+	LevelWarn:  "warn",
+	LevelError: "error",
+}
+
+func (l Level) String() string {
+	return levelNames[l]
+}
+
+// ParseLevel parse one of debug, info, warn or error level name. Blank name
+// is info level
+func ParseLevel(name string) (Level, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return LevelInfo, nil
+	}
+
+	for level, levelName := range levelNames {
+		if levelName == name {
+			return level, nil
+		}
+	}
+	return LevelInfo, fmt.Errorf("log level %q is not one of debug, info, warn or error", name)
+}
+
+// Field represent a key value pair of a log
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// String return a string field
+func String(key string, value string) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Int return an integer field
+func Int(key string, value int) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Duration return a field of duration in milliseconds
+func Duration(key string, value time.Duration) Field {
+	return Field{Key: key, Value: float64(value) / float64(time.Millisecond)}
+}
+
+// Any return a field of any JSON encodable value
+func Any(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Err return the error field of err
+func Err(err error) Field {
+	if err == nil {
+		return Field{Key: "error", Value: nil}
+	}
+	return Field{Key: "error", Value: err.Error()}
+}
+
+// RequestID return the field of the id of the request being served
+func RequestID(id string) Field {
+	return String("request_id", id)
+}
+
+// ArticleID return the field of the id of the article being processed
+func ArticleID(id int) Field {
+	return Int("article_id", id)
+}
+
+// Event return the field of the name of the event being processed
+func Event(name string) Field {
+	return String("event", name)
+}
+
+// Logger represent a structured logger writing JSON lines
+type Logger struct {
+	out    io.Writer
+	mutex  *sync.Mutex
+	level  Level
+	fields []Field
+	now    func() time.Time
+}
+
+// New create a new logger writing logs of given level and above to out
+func New(out io.Writer, level Level) *Logger {
+	return &Logger{
+		out:   out,
+		mutex: &sync.Mutex{},
+		level: level,
+		now:   time.Now,
+	}
+}
+
+// With return a copy of the logger adding fields to every log. A field
+// replace the logger field of the same key, such as the event of an event
+// dispatched by a subscriber of another
+func (l *Logger) With(fields ...Field) *Logger {
+	child := *l
+	child.fields = make([]Field, 0, len(l.fields)+len(fields))
+	child.fields = append(child.fields, l.fields...)
+
+next:
+	for _, field := range fields {
+		for i := range child.fields {
+			if child.fields[i].Key == field.Key {
+				child.fields[i] = field
+				continue next
+			}
+		}
+		child.fields = append(child.fields, field)
+	}
+
+	return &child
+}
+
+// Debug write a debug log
+func (l *Logger) Debug(message string, fields ...Field) {
+	l.log(LevelDebug, message, fields)
+}
+
+// Info write an info log
+func (l *Logger) Info(message string, fields ...Field) {
+	l.log(LevelInfo, message, fields)
+}
+
+// Warn write a warn log
+func (l *Logger) Warn(message string, fields ...Field) {
+	l.log(LevelWarn, message, fields)
+}
+
+// Error write an error log
+func (l *Logger) Error(message string, fields ...Field) {
+	l.log(LevelError, message, fields)
+}
+
+// Fatal write an error log and exit the process
+func (l *Logger) Fatal(message string, fields ...Field) {
+	l.log(LevelError, message, fields)
+	os.Exit(1)
+}
+
+// log write a JSON line of time, level, message then the logger fields and
+// fields, in order. Unencodable values are written as their string
+func (l *Logger) log(level Level, message string, fields []Field) {
+	if level < l.level {
+		return
+	}
+
+	var b bytes.Buffer
+	b.WriteString(`{"time":`)
+	writeValue(&b, l.now().UTC().Format(time.RFC3339Nano))
+	b.WriteString(`,"level":`)
+	writeValue(&b, level.String())
+	b.WriteString(`,"message":`)
+	writeValue(&b, message)
+	for _, fieldList := range [][]Field{l.fields, fields} {
+		for _, field := range fieldList {
+			b.WriteByte(',')
+			writeValue(&b, field.Key)
+			b.WriteByte(':')
+			writeValue(&b, field.Value)
+		}
+	}
+	b.WriteString("}\n")
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.out.Write(b.Bytes())
+}
+
+func writeValue(b *bytes.Buffer, value interface{}) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		encoded, _ = json.Marshal(fmt.Sprint(value))
+	}
+	b.Write(encoded)
+}
+
+var defaultLogger = New(os.Stderr, LevelInfo)
+
+// SetDefault set the logger of contexts without logger
+func SetDefault(l *Logger) {
+	defaultLogger = l
+}
+
+// Default return the logger of contexts without logger
+func Default() *Logger {
+	return defaultLogger
+}
+
+type contextKey struct{}
+
+// NewContext return a copy of ctx carrying l
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext return the logger carried by ctx, or the default logger
+func FromContext(ctx context.Context) *Logger {
+	if l, ok := ctx.Value(contextKey{}).(*Logger); ok {
+		return l
+	}
+	return defaultLogger
+}
+
+// With return a copy of ctx carrying its logger with fields added
+func With(ctx context.Context, fields ...Field) context.Context {
+	return NewContext(ctx, FromContext(ctx).With(fields...))
+}
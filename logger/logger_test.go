@@ -0,0 +1,107 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/prabudzak/article/logger"
+)
+
+func decodeLines(t *testing.T, out *bytes.Buffer) []map[string]interface{} {
+	lines := []map[string]interface{}{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if line == "" {
+			continue
+		}
+
+		var decoded map[string]interface{}
+		err := json.Unmarshal([]byte(line), &decoded)
+		if err != nil {
+			t.Fatalf("log line %q is not JSON: %s", line, err)
+		}
+		lines = append(lines, decoded)
+	}
+	return lines
+}
+
+func TestLogger(t *testing.T) {
+	var out bytes.Buffer
+	log := logger.New(&out, logger.LevelInfo).With(logger.RequestID("req-1"), logger.Event("event_article_created"))
+
+	log.Debug("not written")
+	log.With(logger.Event("event_article_caching_failed")).Error("article not cached", logger.ArticleID(12), logger.Err(errors.New("connection refused")))
+
+	lines := decodeLines(t, &out)
+	if !assert.Len(t, lines, 1) {
+		return
+	}
+
+	line := lines[0]
+	assert.NotEmpty(t, line["time"])
+	assert.Equal(t, "error", line["level"])
+	assert.Equal(t, "article not cached", line["message"])
+	assert.Equal(t, "req-1", line["request_id"])
+	assert.Equal(t, "event_article_caching_failed", line["event"], "field replace the logger field of the same key")
+	assert.Equal(t, float64(12), line["article_id"])
+	assert.Equal(t, "connection refused", line["error"])
+	assert.Equal(t, 1, strings.Count(out.String(), `"event"`))
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		name          string
+		level         string
+		expectedLevel logger.Level
+		expectErr     bool
+	}{
+		{
+			name:          "blank level",
+			level:         "",
+			expectedLevel: logger.LevelInfo,
+			expectErr:     false,
+		},
+		{
+			name:          "named level",
+			level:         " Debug ",
+			expectedLevel: logger.LevelDebug,
+			expectErr:     false,
+		},
+		{
+			name:      "unknown level",
+			level:     "verbose",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			level, err := logger.ParseLevel(tc.level)
+			assert.Equal(t, tc.expectErr, err != nil)
+			if tc.expectErr {
+				return
+			}
+			assert.Equal(t, tc.expectedLevel, level)
+		})
+	}
+}
+
+func TestContext(t *testing.T) {
+	var out bytes.Buffer
+	log := logger.New(&out, logger.LevelDebug)
+
+	assert.Equal(t, logger.Default(), logger.FromContext(context.Background()))
+
+	ctx := logger.With(logger.NewContext(context.Background(), log), logger.RequestID("req-1"))
+	logger.FromContext(ctx).Info("request served")
+
+	lines := decodeLines(t, &out)
+	if assert.Len(t, lines, 1) {
+		assert.Equal(t, "req-1", lines[0]["request_id"])
+	}
+}
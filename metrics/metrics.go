@@ -0,0 +1,108 @@
+// Package metrics define the Prometheus metrics of the article service
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/prabudzak/article/service"
+)
+
+const namespace = "article"
+
+// Storage label values
+const (
+	StorageDatabase = "database"
+	StorageCache    = "cache"
+	StorageIndexer  = "indexer"
+)
+
+// resultOK is the result label value of successful calls. Failed calls are
+// labeled by their service error kind
+const resultOK = "ok"
+
+var (
+	// HTTPRequestDuration observe latency of handled requests by route
+	// pattern, method and response status code
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of handled HTTP requests by route, method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	// StorageRequests count storage calls by storage, operation and result
+	StorageRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "storage",
+		Name:      "requests_total",
+		Help:      "Storage calls by storage, operation and result, ok or the error kind.",
+	}, []string{"storage", "operation", "result"})
+
+	// StorageRequestDuration observe latency of storage calls by storage and
+	// operation
+	StorageRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "storage",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of storage calls by storage and operation.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"storage", "operation"})
+
+	// EventQueueDepth is the number of dispatched events waiting to be taken
+	// by the dispatcher
+	EventQueueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "event",
+		Name:      "queue_depth",
+		Help:      "Dispatched events waiting to be taken by the dispatcher.",
+	})
+
+	// EventsDispatched count dispatched events by event name
+	EventsDispatched = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "event",
+		Name:      "dispatched_total",
+		Help:      "Dispatched events by event name.",
+	}, []string{"event"})
+
+	// EventSubscriberFailures count subscriber calls returning an error by
+	// event name
+	EventSubscriberFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "event",
+		Name:      "subscriber_failures_total",
+		Help:      "Event subscriber calls returning an error by event name.",
+	}, []string{"event"})
+)
+
+// Handler return the handler exposing every registered metric
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ObserveRequest record a handled request of route pattern
+func ObserveRequest(route string, method string, status int, duration time.Duration) {
+	HTTPRequestDuration.WithLabelValues(route, method, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// StorageCall start timing a storage call, the returned function record the
+// call with its outcome once done
+func StorageCall(storage string, operation string) func(err error) {
+	start := time.Now()
+	return func(err error) {
+		result := resultOK
+		if err != nil {
+			result = string(service.KindOf(err))
+		}
+
+		StorageRequests.WithLabelValues(storage, operation, result).Inc()
+		StorageRequestDuration.WithLabelValues(storage, operation).Observe(time.Since(start).Seconds())
+	}
+}
@@ -2,12 +2,42 @@ package model
 
 import "time"
 
-// Article represent an article content
+// Article lifecycle status
+const (
+	ArticleStatusDraft     = "draft"
+	ArticleStatusScheduled = "scheduled"
+	ArticleStatusPublished = "published"
+	ArticleStatusArchived  = "archived"
+)
+
+// Article body format
+const (
+	BodyFormatPlain    = "plain"
+	BodyFormatMarkdown = "markdown"
+	BodyFormatHTML     = "html"
+)
+
+// Article represent an article content. Author and AuthorHandle are the
+// referenced author name and handle at the time the article is read. BodyHTML
+// is the sanitized HTML rendering of Body in its BodyFormat. Excerpt,
+// WordCount and ReadingTime, in minutes, are derived from the body text
 type Article struct {
-	ID        int       `json:"id"`
-	Title     string    `json:"title"`
-	Body      string    `json:"body"`
-	Author    string    `json:"author"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID           int        `json:"id"`
+	Title        string     `json:"title"`
+	Body         string     `json:"body"`
+	BodyFormat   string     `json:"body_format"`
+	BodyHTML     string     `json:"body_html,omitempty"`
+	Excerpt      string     `json:"excerpt"`
+	WordCount    int        `json:"word_count"`
+	ReadingTime  int        `json:"reading_time"`
+	AuthorID     int        `json:"author_id"`
+	Author       string     `json:"author"`
+	AuthorHandle string     `json:"author_handle"`
+	Language     string     `json:"language"`
+	Tags         []string   `json:"tags"`
+	Status       string     `json:"status"`
+	PublishAt    *time.Time `json:"publish_at,omitempty"`
+	Version      int        `json:"version"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
 }
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// MaxAuthorHandleLength represent the length limit of author handle in
+// characters
+const MaxAuthorHandleLength = 64
+
+// Author represent an article author
+type Author struct {
+	ID        int       `json:"id"`
+	Handle    string    `json:"handle"`
+	Name      string    `json:"name"`
+	Bio       string    `json:"bio"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AuthorHandle derive an author handle from author name or handle, so names
+// differing only in letter case or spacing resolve to the same author
+func AuthorHandle(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), "-")
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// IdempotencyRecord represent a request made with an idempotency key and,
+// once completed, the response to replay on its retries
+type IdempotencyRecord struct {
+	Key         string              `json:"key"`
+	RequestHash string              `json:"request_hash"`
+	Completed   bool                `json:"completed"`
+	StatusCode  int                 `json:"status_code,omitempty"`
+	Header      map[string][]string `json:"header,omitempty"`
+	Body        []byte              `json:"body,omitempty"`
+	CreatedAt   time.Time           `json:"created_at"`
+}
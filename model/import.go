@@ -0,0 +1,8 @@
+package model
+
+// ArticleImportResult represent the outcome of importing an article. Article
+// is the created article, or the rejected article when Err is not nil
+type ArticleImportResult struct {
+	Article Article `json:"article"`
+	Err     error   `json:"-"`
+}
@@ -1,12 +1,33 @@
 package model
 
-// ArticleSearchQuery represent article search query parameter
+// ArticleSearchQuery represent article search query parameter. Author may be
+// either author name or author handle. Fields, when given, are the JSON names
+// of the only article fields needed, so unneeded body may be left unread
 type ArticleSearchQuery struct {
 	Keyword    string
 	Author     string
+	Language   string
+	Tags       []string
+	TagMode    string
+	Status     string
+	Fields     []string
 	Pagination Pagination
 }
 
+// ArticleRelatedQuery represent related article query parameter
+type ArticleRelatedQuery struct {
+	ArticleID  int
+	SameAuthor bool
+	Author     string
+	Status     string
+	Pagination Pagination
+}
+
+// TagQuery represent tag count query parameter
+type TagQuery struct {
+	Limit int
+}
+
 // ArticleSearchResult represent article search query result
 type ArticleSearchResult struct {
 	IDs        []int
@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+// Principal role
+const (
+	RoleAuthor = "author"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+// Principal authentication method
+const (
+	AuthMethodAPIKey = "api_key"
+	AuthMethodJWT    = "jwt"
+)
+
+// Principal represent an authenticated caller. Subject identify the caller
+// within its authentication method. AuthorID is the author the caller writes
+// as, if any, which owns the articles of that author
+type Principal struct {
+	Subject  string `json:"subject"`
+	Name     string `json:"name"`
+	Role     string `json:"role"`
+	Method   string `json:"method"`
+	AuthorID int    `json:"author_id,omitempty"`
+}
+
+// APIKey represent a stored API key. Only the hash of the key is stored, the
+// key itself is shown once when created
+type APIKey struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	Role      string     `json:"role"`
+	AuthorID  int        `json:"author_id,omitempty"`
+	Hash      string     `json:"-"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
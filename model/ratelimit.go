@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// RateLimit represent a token bucket allowing bursts of Limit requests,
+// refilled at Limit requests per Period
+type RateLimit struct {
+	Limit  int           `json:"limit"`
+	Period time.Duration `json:"period"`
+}
+
+// RateLimitResult represent the outcome of taking a token from a bucket.
+// Reset is the time until the bucket is full again and RetryAfter the time
+// until the next token when the request is not allowed
+type RateLimitResult struct {
+	Allowed    bool          `json:"allowed"`
+	Limit      int           `json:"limit"`
+	Remaining  int           `json:"remaining"`
+	Reset      time.Duration `json:"reset"`
+	RetryAfter time.Duration `json:"retry_after"`
+}
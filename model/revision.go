@@ -0,0 +1,42 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ArticleRevision represent an immutable snapshot of an article written to
+// persistent storage
+type ArticleRevision struct {
+	ArticleID  int        `json:"article_id"`
+	Revision   int        `json:"revision"`
+	AuthorID   int        `json:"author_id"`
+	Title      string     `json:"title"`
+	Body       string     `json:"body"`
+	BodyFormat string     `json:"body_format"`
+	Language   string     `json:"language"`
+	Tags       []string   `json:"tags"`
+	Status     string     `json:"status"`
+	PublishAt  *time.Time `json:"publish_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Text render article revision content as plain text for diffing
+func (r ArticleRevision) Text() string {
+	return fmt.Sprintf("title: %s\nlanguage: %s\ntags: %s\nstatus: %s\n\n%s\n",
+		r.Title,
+		r.Language,
+		strings.Join(r.Tags, ", "),
+		r.Status,
+		r.Body,
+	)
+}
+
+// ArticleRevisionDiff represent unified diff between two article revisions
+type ArticleRevisionDiff struct {
+	ArticleID int    `json:"article_id"`
+	From      int    `json:"from"`
+	To        int    `json:"to"`
+	Diff      string `json:"diff"`
+}
@@ -0,0 +1,13 @@
+package model
+
+// Article tag search mode, default to any when unspecified
+const (
+	TagModeAny = "any"
+	TagModeAll = "all"
+)
+
+// TagCount represent number of articles tagged with a tag
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
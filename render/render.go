@@ -0,0 +1,86 @@
+// Package render convert article body of each body format to sanitized HTML
+// and to plain text, and summarize it
+package render
+
+import (
+	"bytes"
+	"html"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+
+	"github.com/prabudzak/article/model"
+)
+
+// markdown convert CommonMark with GitHub tables, strikethrough and autolinks.
+// Raw HTML in markdown is omitted by the renderer, then sanitized anyway
+var markdown = goldmark.New(
+	goldmark.WithExtensions(extension.Table, extension.Strikethrough, extension.Linkify),
+)
+
+// Article default article body format to plain, render its body to sanitized
+// HTML and derive its excerpt, word count and reading time from the plain text
+// of the body
+func Article(article model.Article) model.Article {
+	if article.BodyFormat == "" {
+		article.BodyFormat = model.BodyFormatPlain
+	}
+
+	article.BodyHTML = HTML(article.BodyFormat, article.Body)
+
+	body := article.Body
+	if article.BodyFormat != model.BodyFormatPlain {
+		body = text(article.BodyHTML)
+	}
+
+	article.Excerpt = Excerpt(body, ExcerptLength)
+	article.WordCount = WordCount(body)
+	article.ReadingTime = ReadingTime(article.WordCount)
+	return article
+}
+
+// HTML render body of given format to sanitized HTML. Plain body is escaped
+// into paragraphs, unknown format is rendered as plain
+func HTML(format string, body string) string {
+	switch format {
+	case model.BodyFormatMarkdown:
+		var b bytes.Buffer
+		err := markdown.Convert([]byte(body), &b)
+		if err != nil {
+			return plainHTML(body)
+		}
+		return Sanitize(b.String())
+	case model.BodyFormatHTML:
+		return Sanitize(body)
+	default:
+		return plainHTML(body)
+	}
+}
+
+// Text return the plain text of body of given format, without any markup
+func Text(format string, body string) string {
+	switch format {
+	case model.BodyFormatMarkdown, model.BodyFormatHTML:
+		return text(HTML(format, body))
+	default:
+		return body
+	}
+}
+
+// plainHTML escape plain text into paragraphs separated by blank lines, line
+// breaks kept
+func plainHTML(body string) string {
+	var b strings.Builder
+	for _, paragraph := range strings.Split(strings.Replace(body, "\r\n", "\n", -1), "\n\n") {
+		paragraph = strings.Trim(paragraph, "\n")
+		if strings.TrimSpace(paragraph) == "" {
+			continue
+		}
+
+		b.WriteString("<p>")
+		b.WriteString(strings.Replace(html.EscapeString(paragraph), "\n", "<br>\n", -1))
+		b.WriteString("</p>\n")
+	}
+	return b.String()
+}
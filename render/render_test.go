@@ -0,0 +1,131 @@
+package render_test
+
+import (
+	"testing"
+
+	"github.com/prabudzak/article/model"
+	"github.com/prabudzak/article/render"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		body     string
+		expected string
+	}{
+		{
+			name:     "plain text escaped into paragraphs",
+			format:   model.BodyFormatPlain,
+			body:     "a < b\nc\n\nd",
+			expected: "<p>a &lt; b<br>\nc</p>\n<p>d</p>\n",
+		},
+		{
+			name:     "unknown format rendered as plain",
+			format:   "",
+			body:     "<b>bold</b>",
+			expected: "<p>&lt;b&gt;bold&lt;/b&gt;</p>\n",
+		},
+		{
+			name:     "markdown rendered",
+			format:   model.BodyFormatMarkdown,
+			body:     "# Title\n\nSome *emphasis* and [link](https://example.com).",
+			expected: "<h1>Title</h1>\n<p>Some <em>emphasis</em> and <a href=\"https://example.com\" rel=\"nofollow noopener\">link</a>.</p>\n",
+		},
+		{
+			name:     "markdown raw html omitted",
+			format:   model.BodyFormatMarkdown,
+			body:     "hello <script>alert(1)</script>",
+			expected: "<p>hello alert(1)</p>\n",
+		},
+		{
+			name:     "markdown javascript link stripped",
+			format:   model.BodyFormatMarkdown,
+			body:     "[click](javascript:alert(1))",
+			expected: "<p><a>click</a></p>\n",
+		},
+		{
+			name:     "html script dropped with content",
+			format:   model.BodyFormatHTML,
+			body:     "<p>hello</p><script>alert(1)</script><style>p{}</style>",
+			expected: "<p>hello</p>",
+		},
+		{
+			name:     "html iframe dropped with content",
+			format:   model.BodyFormatHTML,
+			body:     "<iframe src=\"https://example.com\">fallback</iframe><p>after</p>",
+			expected: "<p>after</p>",
+		},
+		{
+			name:     "html event handler and style stripped",
+			format:   model.BodyFormatHTML,
+			body:     "<p onclick=\"alert(1)\" style=\"color:red\">text</p><img src=\"/a.png\" onerror=\"alert(1)\" alt=\"a\">",
+			expected: "<p>text</p><img src=\"/a.png\" alt=\"a\">",
+		},
+		{
+			name:     "html unsafe urls stripped",
+			format:   model.BodyFormatHTML,
+			body:     "<a href=\" javascript:alert(1)\">a</a><a href=\"java&#09;script:alert(1)\">b</a><img src=\"data:image/png;base64,AA\">",
+			expected: "<a>a</a><a>b</a><img>",
+		},
+		{
+			name:     "html unknown element unwrapped",
+			format:   model.BodyFormatHTML,
+			body:     "<form action=\"/x\"><p>kept <blink>text</blink></p></form>",
+			expected: "<p>kept text</p>",
+		},
+		{
+			name:     "html unclosed element closed",
+			format:   model.BodyFormatHTML,
+			body:     "<ul><li><strong>one</li></ul><em>two",
+			expected: "<ul><li><strong>one</strong></li></ul><em>two</em>",
+		},
+		{
+			name:     "html text escaped",
+			format:   model.BodyFormatHTML,
+			body:     "<p title=\"&quot;&gt;\">1 &lt; 2</p>",
+			expected: "<p>1 &lt; 2</p>",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, render.HTML(tc.format, tc.body))
+		})
+	}
+}
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		body     string
+		expected string
+	}{
+		{
+			name:     "plain text kept as is",
+			format:   model.BodyFormatPlain,
+			body:     "a <b>c</b>",
+			expected: "a <b>c</b>",
+		},
+		{
+			name:     "markdown markup removed",
+			format:   model.BodyFormatMarkdown,
+			body:     "# Title\n\n**bold** and `code`\n\n- one\n- two",
+			expected: "Title\nbold and code\none\ntwo",
+		},
+		{
+			name:     "html markup and script removed",
+			format:   model.BodyFormatHTML,
+			body:     "<h2>Title</h2><p>some <a href=\"/x\">link</a></p><script>secret()</script>",
+			expected: "Title\nsome link",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, render.Text(tc.format, tc.body))
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package render
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+
+	xhtml "golang.org/x/net/html"
+)
+
+// allowedTags map every element kept by the sanitizer to its allowed
+// attributes. Any other attribute, including event handlers and style, is
+// stripped
+var allowedTags = map[string][]string{
+	"a": {"href", "title"}, "img": {"src", "alt", "title"},
+	"p": nil, "br": nil, "hr": nil, "div": nil, "span": nil,
+	"h1": nil, "h2": nil, "h3": nil, "h4": nil, "h5": nil, "h6": nil,
+	"blockquote": nil, "pre": nil, "code": {"class"},
+	"em": nil, "strong": nil, "b": nil, "i": nil, "u": nil, "s": nil, "del": nil, "ins": nil,
+	"sub": nil, "sup": nil, "mark": nil, "abbr": {"title"},
+	"ul": nil, "ol": {"start"}, "li": nil, "dl": nil, "dt": nil, "dd": nil,
+	"table": nil, "thead": nil, "tbody": nil, "tfoot": nil, "tr": nil,
+	"th": {"align"}, "td": {"align"},
+}
+
+// droppedTags are elements removed together with their content, instead of
+// unwrapping their content as done for other disallowed elements
+var droppedTags = map[string]bool{
+	"script": true, "style": true, "iframe": true, "frame": true, "frameset": true,
+	"object": true, "embed": true, "applet": true, "noscript": true, "noembed": true,
+	"noframes": true, "template": true, "textarea": true, "select": true,
+	"title": true, "svg": true, "math": true, "xmp": true, "plaintext": true,
+}
+
+// voidTags are elements written without end tag
+var voidTags = map[string]bool{"br": true, "hr": true, "img": true}
+
+// blockTags separate the words of their content from surrounding text
+var blockTags = map[string]bool{
+	"p": true, "br": true, "hr": true, "div": true, "li": true, "dt": true, "dd": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"blockquote": true, "pre": true, "tr": true, "th": true, "td": true,
+}
+
+// urlSchemes are the allowed schemes of link and image URLs. URL without
+// scheme is relative and always allowed
+var urlSchemes = map[string]map[string]bool{
+	"href": {"http": true, "https": true, "mailto": true},
+	"src":  {"http": true, "https": true},
+}
+
+var (
+	codeClassPattern = regexp.MustCompile(`^language-[A-Za-z0-9+#_-]+$`)
+	alignPattern     = regexp.MustCompile(`^(left|center|right)$`)
+	startPattern     = regexp.MustCompile(`^[0-9]{1,9}$`)
+)
+
+// Sanitize strip every element and attribute not explicitly allowed from an
+// HTML fragment. Script, style, iframe and other embedding elements are removed
+// with their content, links and images only keep safe URLs. The result is well
+// formed, every opened element is closed
+func Sanitize(fragment string) string {
+	var b strings.Builder
+	var open []string
+	dropped, depth := "", 0
+
+	z := xhtml.NewTokenizer(strings.NewReader(fragment))
+	for {
+		tt := z.Next()
+		if tt == xhtml.ErrorToken {
+			break
+		}
+
+		token := z.Token()
+		if dropped != "" {
+			if token.Data == dropped && tt == xhtml.StartTagToken {
+				depth++
+			} else if token.Data == dropped && tt == xhtml.EndTagToken {
+				depth--
+				if depth == 0 {
+					dropped = ""
+				}
+			}
+			continue
+		}
+
+		switch tt {
+		case xhtml.TextToken:
+			b.WriteString(html.EscapeString(token.Data))
+		case xhtml.StartTagToken, xhtml.SelfClosingTagToken:
+			if droppedTags[token.Data] {
+				if tt == xhtml.StartTagToken {
+					dropped, depth = token.Data, 1
+				}
+				continue
+			}
+
+			attributes, ok := allowedTags[token.Data]
+			if !ok {
+				continue
+			}
+
+			writeStartTag(&b, token, attributes)
+			if !voidTags[token.Data] && tt == xhtml.StartTagToken {
+				open = append(open, token.Data)
+			}
+		case xhtml.EndTagToken:
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] != token.Data {
+					continue
+				}
+
+				for len(open) > i {
+					b.WriteString("</" + open[len(open)-1] + ">")
+					open = open[:len(open)-1]
+				}
+				break
+			}
+		}
+	}
+
+	for len(open) > 0 {
+		b.WriteString("</" + open[len(open)-1] + ">")
+		open = open[:len(open)-1]
+	}
+
+	return b.String()
+}
+
+// writeStartTag write the start tag of an allowed element with its allowed
+// and safe attributes only. Links are marked nofollow
+func writeStartTag(b *strings.Builder, token xhtml.Token, allowed []string) {
+	b.WriteString("<" + token.Data)
+
+	link := false
+	for _, attr := range token.Attr {
+		if attr.Namespace != "" || !contains(allowed, attr.Key) || !safeAttribute(attr.Key, attr.Val) {
+			continue
+		}
+
+		link = link || attr.Key == "href"
+		b.WriteString(" " + attr.Key + `="` + html.EscapeString(attr.Val) + `"`)
+	}
+
+	if link {
+		b.WriteString(` rel="nofollow noopener"`)
+	}
+
+	b.WriteString(">")
+}
+
+// safeAttribute report whether an allowed attribute value is safe to keep
+func safeAttribute(key string, value string) bool {
+	switch key {
+	case "href", "src":
+		return safeURL(value, urlSchemes[key])
+	case "class":
+		return codeClassPattern.MatchString(value)
+	case "align":
+		return alignPattern.MatchString(value)
+	case "start":
+		return startPattern.MatchString(value)
+	default:
+		return true
+	}
+}
+
+// safeURL report whether a URL is relative or of one of given schemes. URL
+// with control characters, which browsers ignore inside a scheme, does not
+// parse and is unsafe
+func safeURL(value string, schemes map[string]bool) bool {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return false
+	}
+
+	u, err := url.Parse(value)
+	if err != nil {
+		return false
+	}
+
+	return u.Scheme == "" || schemes[strings.ToLower(u.Scheme)]
+}
+
+// text extract the words of a sanitized HTML fragment as plain text
+func text(fragment string) string {
+	var b strings.Builder
+
+	z := xhtml.NewTokenizer(strings.NewReader(fragment))
+	for {
+		tt := z.Next()
+		if tt == xhtml.ErrorToken {
+			break
+		}
+
+		token := z.Token()
+		switch tt {
+		case xhtml.TextToken:
+			b.WriteString(token.Data)
+		case xhtml.StartTagToken, xhtml.EndTagToken, xhtml.SelfClosingTagToken:
+			if blockTags[token.Data] {
+				b.WriteString("\n")
+			}
+		}
+	}
+
+	lines := strings.Split(b.String(), "\n")
+	words := make([]string, 0, len(lines))
+	for _, line := range lines {
+		line = strings.Join(strings.Fields(line), " ")
+		if line != "" {
+			words = append(words, line)
+		}
+	}
+
+	return strings.Join(words, "\n")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package render
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Summary limits
+const (
+	// ExcerptLength is the approximate maximum number of characters of an
+	// article excerpt
+	ExcerptLength = 160
+	// WordsPerMinute is the reading speed used to estimate reading time
+	WordsPerMinute = 200
+)
+
+// Excerpt summarize plain text in at most maxLength characters. The excerpt
+// end at a sentence boundary when the leading sentences fill at least half of
+// it, otherwise at a word boundary followed by an ellipsis
+func Excerpt(text string, maxLength int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= maxLength {
+		return text
+	}
+
+	runes := []rune(text)
+	sentenceEnd := 0
+	for i := 0; i < maxLength; i++ {
+		if isSentenceEnd(runes[i]) && unicode.IsSpace(runes[i+1]) {
+			sentenceEnd = i + 1
+		}
+	}
+
+	if sentenceEnd >= maxLength/2 {
+		return string(runes[:sentenceEnd])
+	}
+
+	cut := maxLength - 1
+	for i := cut; i > 0; i-- {
+		if unicode.IsSpace(runes[i]) {
+			cut = i
+			break
+		}
+	}
+
+	return strings.TrimRightFunc(string(runes[:cut]), func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	}) + "…"
+}
+
+// WordCount count the words of plain text
+func WordCount(text string) int {
+	return len(strings.Fields(text))
+}
+
+// ReadingTime estimate the minutes needed to read given number of words,
+// at least a minute for any word
+func ReadingTime(words int) int {
+	if words <= 0 {
+		return 0
+	}
+	return (words + WordsPerMinute - 1) / WordsPerMinute
+}
+
+func isSentenceEnd(r rune) bool {
+	switch r {
+	case '.', '!', '?', '。', '！', '？':
+		return true
+	}
+	return false
+}
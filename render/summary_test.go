@@ -0,0 +1,71 @@
+package render_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prabudzak/article/model"
+	"github.com/prabudzak/article/render"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExcerpt(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{
+			name:     "short text kept whole",
+			text:     "A short\ntext.",
+			expected: "A short text.",
+		},
+		{
+			name:     "cut at the last fitting sentence",
+			text:     "First one. Second sentence is here. Third one does not fit at all.",
+			expected: "First one. Second sentence is here.",
+		},
+		{
+			name:     "cut at word boundary when leading sentences are too short",
+			text:     "Hi. This sentence is far too long to fit into the excerpt, so the excerpt is cut at a word boundary.",
+			expected: "Hi. This sentence is far too long to…",
+		},
+		{
+			name:     "cut long word",
+			text:     strings.Repeat("a", 50),
+			expected: strings.Repeat("a", 39) + "…",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, render.Excerpt(tc.text, 40))
+		})
+	}
+}
+
+func TestReadingTime(t *testing.T) {
+	assert.Equal(t, 0, render.ReadingTime(0))
+	assert.Equal(t, 1, render.ReadingTime(1))
+	assert.Equal(t, 1, render.ReadingTime(render.WordsPerMinute))
+	assert.Equal(t, 2, render.ReadingTime(render.WordsPerMinute+1))
+}
+
+func TestArticle(t *testing.T) {
+	article := render.Article(model.Article{
+		Body:       "# Title\n\nSome **bold** words. " + strings.Repeat("word ", 250),
+		BodyFormat: model.BodyFormatMarkdown,
+	})
+
+	assert.True(t, strings.HasPrefix(article.BodyHTML, "<h1>Title</h1>"))
+	assert.Equal(t, "Title Some bold words.", article.Excerpt[:22])
+	assert.True(t, len([]rune(article.Excerpt)) <= render.ExcerptLength)
+	assert.Equal(t, 254, article.WordCount)
+	assert.Equal(t, 2, article.ReadingTime)
+
+	plain := render.Article(model.Article{Body: "one two"})
+	assert.Equal(t, model.BodyFormatPlain, plain.BodyFormat)
+	assert.Equal(t, "one two", plain.Excerpt)
+	assert.Equal(t, 2, plain.WordCount)
+	assert.Equal(t, 1, plain.ReadingTime)
+}
@@ -3,27 +3,84 @@ package elasticsearch
 import (
 	"context"
 	"errors"
-	"log"
+	"fmt"
 	"strconv"
 
 	"github.com/olivere/elastic"
 
+	"github.com/prabudzak/article/logger"
 	"github.com/prabudzak/article/model"
+	"github.com/prabudzak/article/render"
+	"github.com/prabudzak/article/service"
 )
 
+// languageFields map article language code to its language analyzed sub
+// field of title and body in index mapping
+var languageFields = map[string]string{
+	"en": "english",
+	"id": "indonesian",
+}
+
 // ArticleIndexer represent article indexer elasticsearch implementation
 type ArticleIndexer struct {
 	client *elastic.Client
 
-	indexName string
+	indexName       string
+	fuzziness       string
+	defaultLanguage string
+}
+
+// Option represent article indexer configuration option
+type Option func(a *ArticleIndexer)
+
+// WithFuzziness set keyword search fuzziness, accept "AUTO" or maximum edit
+// distance "0", "1", "2". Default to "AUTO"
+func WithFuzziness(fuzziness string) Option {
+	return func(a *ArticleIndexer) {
+		if fuzziness != "" {
+			a.fuzziness = fuzziness
+		}
+	}
+}
+
+// WithDefaultLanguage set language analyzer used to search keyword when
+// search query has no language. Default to "en"
+func WithDefaultLanguage(language string) Option {
+	return func(a *ArticleIndexer) {
+		if _, ok := languageFields[language]; ok {
+			a.defaultLanguage = language
+		}
+	}
 }
 
 // NewArticleIndexer create a new instance of elasticsearch implementation article indexer
-func NewArticleIndexer(client *elastic.Client, indexName string) *ArticleIndexer {
-	return &ArticleIndexer{
-		client:    client,
-		indexName: indexName,
+func NewArticleIndexer(client *elastic.Client, indexName string, options ...Option) *ArticleIndexer {
+	indexer := &ArticleIndexer{
+		client:          client,
+		indexName:       indexName,
+		fuzziness:       "AUTO",
+		defaultLanguage: "en",
 	}
+
+	for _, option := range options {
+		option(indexer)
+	}
+
+	return indexer
+}
+
+// CheckHealth report whether the cluster is reachable and the article index
+// is searchable, which it is unless its health is red
+func (a *ArticleIndexer) CheckHealth(ctx context.Context) error {
+	health, err := a.client.ClusterHealth().Index(a.indexName).Do(ctx)
+	if err != nil {
+		return err
+	}
+
+	if health.Status == "red" {
+		return fmt.Errorf("article index health is %s", health.Status)
+	}
+	return nil
 }
 
 // Index put an index for a given article
@@ -36,16 +93,66 @@ func (a *ArticleIndexer) Index(ctx context.Context, article model.Article) error
 		Index(a.indexName).
 		Type("article").
 		Id(strconv.FormatInt(int64(article.ID), 10)).
-		BodyJson(article).
+		BodyJson(document(article)).
 		Do(ctx)
 	if err != nil {
-		log.Println(err)
-		return err
+		logger.FromContext(ctx).Error("article not indexed", logger.ArticleID(article.ID), logger.Err(err))
+		return wrapError(err)
 	}
 
 	return nil
 }
 
+// document return the indexed document of an article. Only the plain text of
+// article body is indexed, so markup never match a keyword search
+func document(article model.Article) model.Article {
+	article.Body = render.Text(article.BodyFormat, article.Body)
+	article.BodyHTML = ""
+	return article
+}
+
+// IndexBatch put indexes for given articles with a single bulk request. The
+// error at the position of an article report its failure, if any
+func (a *ArticleIndexer) IndexBatch(ctx context.Context, articles []model.Article) ([]error, error) {
+	if len(articles) == 0 {
+		return nil, nil
+	}
+
+	bulk := a.client.Bulk()
+	for _, article := range articles {
+		if article.ID == 0 {
+			return nil, errors.New("article id is invalid")
+		}
+
+		bulk.Add(elastic.NewBulkIndexRequest().
+			Index(a.indexName).
+			Type("article").
+			Id(strconv.FormatInt(int64(article.ID), 10)).
+			Doc(document(article)))
+	}
+
+	result, err := bulk.Do(ctx)
+	if err != nil {
+		logger.FromContext(ctx).Error("articles not indexed", logger.Int("count", len(articles)), logger.Err(err))
+		return nil, wrapError(err)
+	}
+
+	errs := make([]error, len(articles))
+	for i, item := range result.Items {
+		if i >= len(errs) {
+			break
+		}
+
+		for _, response := range item {
+			if response.Error != nil {
+				errs[i] = fmt.Errorf("%s: %s", response.Error.Type, response.Error.Reason)
+			}
+		}
+	}
+
+	return errs, nil
+}
+
 // Remove delete an article index by id
 func (a *ArticleIndexer) Remove(ctx context.Context, id int) error {
 	if id == 0 {
@@ -58,8 +165,8 @@ func (a *ArticleIndexer) Remove(ctx context.Context, id int) error {
 		Id(strconv.FormatInt(int64(id), 10)).
 		Do(ctx)
 	if err != nil {
-		log.Println(err)
-		return err
+		logger.FromContext(ctx).Error("article index not removed", logger.ArticleID(id), logger.Err(err))
+		return wrapError(err)
 	}
 
 	return nil
@@ -74,11 +181,32 @@ func (a *ArticleIndexer) Search(ctx context.Context, query model.ArticleSearchQu
 	}
 
 	if query.Author != "" {
-		q.Filter(elastic.NewTermQuery("author", query.Author))
+		q.Filter(elastic.NewTermQuery("author_handle", query.Author))
+	}
+
+	if query.Status != "" {
+		q.Filter(elastic.NewTermQuery("status", query.Status))
+	}
+
+	if query.Language != "" {
+		q.Filter(elastic.NewTermQuery("language", query.Language))
+	}
+
+	if len(query.Tags) > 0 && query.TagMode == model.TagModeAll {
+		for _, tag := range query.Tags {
+			q.Filter(elastic.NewTermQuery("tags", tag))
+		}
+	} else if len(query.Tags) > 0 {
+		tags := make([]interface{}, 0, len(query.Tags))
+		for _, tag := range query.Tags {
+			tags = append(tags, tag)
+		}
+		q.Filter(elastic.NewTermsQuery("tags", tags...))
 	}
 
 	if query.Keyword != "" {
-		q.Must(elastic.NewMultiMatchQuery(query.Keyword, "title", "body"))
+		q.Must(elastic.NewMultiMatchQuery(query.Keyword, a.keywordFields(query.Language)...).
+			Fuzziness(a.fuzziness))
 	}
 
 	sort := elastic.NewFieldSort("created_at").Desc()
@@ -93,15 +221,76 @@ func (a *ArticleIndexer) Search(ctx context.Context, query model.ArticleSearchQu
 		FetchSource(false).
 		Do(ctx)
 	if err != nil {
-		log.Println(err)
-		return nil, err
+		logger.FromContext(ctx).Error("articles not searched", logger.Err(err))
+		return nil, wrapError(err)
+	}
+
+	ids := []int{}
+	for _, hit := range result.Hits.Hits {
+		id, err := strconv.ParseInt(hit.Id, 10, 32)
+		if err != nil {
+			logger.FromContext(ctx).Error("articles not searched", logger.Err(err))
+			return nil, err
+		}
+
+		ids = append(ids, int(id))
+	}
+
+	return ids, nil
+}
+
+// Related search articles similar in title and body to the given article,
+// excluding the article itself
+func (a *ArticleIndexer) Related(ctx context.Context, query model.ArticleRelatedQuery) ([]int, error) {
+	if query.ArticleID == 0 {
+		return nil, errors.New("article id is invalid")
+	}
+
+	if query.Pagination.Limit <= 0 || query.Pagination.Limit > 100 {
+		query.Pagination.Limit = 5
+	}
+
+	id := strconv.FormatInt(int64(query.ArticleID), 10)
+
+	like := elastic.NewMoreLikeThisQueryItem().
+		Index(a.indexName).
+		Type("article").
+		Id(id)
+
+	q := elastic.NewBoolQuery()
+	q.Must(elastic.NewMoreLikeThisQuery().
+		Field("title", "body").
+		LikeItems(like).
+		MinTermFreq(1).
+		MinDocFreq(1))
+	q.MustNot(elastic.NewIdsQuery("article").Ids(id))
+
+	if query.Status != "" {
+		q.Filter(elastic.NewTermQuery("status", query.Status))
+	}
+
+	if query.Author != "" {
+		q.Filter(elastic.NewTermQuery("author_handle", query.Author))
+	}
+
+	result, err := a.client.Search().
+		Index(a.indexName).
+		Type("article").
+		Query(q).
+		From(query.Pagination.Offset).
+		Size(query.Pagination.Limit).
+		FetchSource(false).
+		Do(ctx)
+	if err != nil {
+		logger.FromContext(ctx).Error("related articles not searched", logger.ArticleID(query.ArticleID), logger.Err(err))
+		return nil, wrapError(err)
 	}
 
 	ids := []int{}
 	for _, hit := range result.Hits.Hits {
 		id, err := strconv.ParseInt(hit.Id, 10, 32)
 		if err != nil {
-			log.Println(err)
+			logger.FromContext(ctx).Error("related articles not searched", logger.ArticleID(query.ArticleID), logger.Err(err))
 			return nil, err
 		}
 
@@ -110,3 +299,65 @@ func (a *ArticleIndexer) Search(ctx context.Context, query model.ArticleSearchQu
 
 	return ids, nil
 }
+
+// CountTags count number of indexed published articles for each tag, sorted
+// by the most used tag
+func (a *ArticleIndexer) CountTags(ctx context.Context, query model.TagQuery) ([]model.TagCount, error) {
+	if query.Limit <= 0 || query.Limit > 1000 {
+		query.Limit = 100
+	}
+
+	agg := elastic.NewTermsAggregation().
+		Field("tags").
+		Size(query.Limit)
+
+	result, err := a.client.Search().
+		Index(a.indexName).
+		Type("article").
+		Query(elastic.NewTermQuery("status", model.ArticleStatusPublished)).
+		Aggregation("tags", agg).
+		Size(0).
+		Do(ctx)
+	if err != nil {
+		logger.FromContext(ctx).Error("tags not counted", logger.Err(err))
+		return nil, wrapError(err)
+	}
+
+	tags := []model.TagCount{}
+
+	terms, ok := result.Aggregations.Terms("tags")
+	if !ok {
+		return tags, nil
+	}
+
+	for _, bucket := range terms.Buckets {
+		tag, ok := bucket.Key.(string)
+		if !ok {
+			continue
+		}
+
+		tags = append(tags, model.TagCount{Tag: tag, Count: int(bucket.DocCount)})
+	}
+
+	return tags, nil
+}
+
+// keywordFields return title and body fields searched by keyword, including
+// the fields analyzed by the given language analyzer
+func (a *ArticleIndexer) keywordFields(language string) []string {
+	field, ok := languageFields[language]
+	if !ok {
+		field = languageFields[a.defaultLanguage]
+	}
+
+	return []string{"title", "body", "title." + field, "body." + field}
+}
+
+// wrapError mark connection failure to elasticsearch cluster as unavailable
+// service error
+func wrapError(err error) error {
+	if elastic.IsConnErr(err) || elastic.IsTimeout(err) {
+		return service.Unavailable(err)
+	}
+	return err
+}
@@ -0,0 +1,238 @@
+// Package instrumented decorate article storages with call metrics and trace
+// spans
+package instrumented
+
+import (
+	"context"
+	"time"
+
+	"github.com/prabudzak/article/metrics"
+	"github.com/prabudzak/article/model"
+	"github.com/prabudzak/article/service"
+	"github.com/prabudzak/article/service/article"
+	"github.com/prabudzak/article/tracing"
+	"go.opentelemetry.io/otel/label"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// storageKey is the span attribute of the called storage
+const storageKey = label.Key("article.storage")
+
+// call record an operation of storage, traced as a span named after both.
+// The returned function end the call with its error. Not found is an expected
+// outcome, such as a cache miss, so it does not fail the span
+func call(ctx context.Context, storage string, operation string) (context.Context, func(err error)) {
+	done := metrics.StorageCall(storage, operation)
+	ctx, span := tracing.Start(ctx, storage+"."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(storageKey.String(storage)),
+	)
+
+	return ctx, func(err error) {
+		if service.KindOf(err) == service.KindNotFound {
+			tracing.End(span, nil)
+		} else {
+			tracing.End(span, err)
+		}
+		done(err)
+	}
+}
+
+// Database represent article database instrumenting decorator
+type Database struct {
+	next article.Database
+}
+
+// NewDatabase create a new instance of article database recording the calls
+// to next
+func NewDatabase(next article.Database) *Database {
+	return &Database{
+		next: next,
+	}
+}
+
+// GenerateID generate a new id to be assigned to an article
+func (d *Database) GenerateID(ctx context.Context) (int, error) {
+	ctx, done := call(ctx, metrics.StorageDatabase, "generate_id")
+	id, err := d.next.GenerateID(ctx)
+	done(err)
+	return id, err
+}
+
+// GenerateIDs generate a block of count consecutive new ids
+func (d *Database) GenerateIDs(ctx context.Context, count int) ([]int, error) {
+	ctx, done := call(ctx, metrics.StorageDatabase, "generate_ids")
+	ids, err := d.next.GenerateIDs(ctx, count)
+	done(err)
+	return ids, err
+}
+
+// Create write a new article to database
+func (d *Database) Create(ctx context.Context, article model.Article) error {
+	ctx, done := call(ctx, metrics.StorageDatabase, "create")
+	err := d.next.Create(ctx, article)
+	done(err)
+	return err
+}
+
+// CreateBatch write many new articles to database at once
+func (d *Database) CreateBatch(ctx context.Context, articles []model.Article) error {
+	ctx, done := call(ctx, metrics.StorageDatabase, "create_batch")
+	err := d.next.CreateBatch(ctx, articles)
+	done(err)
+	return err
+}
+
+// Update overwrite an article in database
+func (d *Database) Update(ctx context.Context, article model.Article) error {
+	ctx, done := call(ctx, metrics.StorageDatabase, "update")
+	err := d.next.Update(ctx, article)
+	done(err)
+	return err
+}
+
+// Get retrieve an article by id from database
+func (d *Database) Get(ctx context.Context, id int) (model.Article, error) {
+	ctx, done := call(ctx, metrics.StorageDatabase, "get")
+	article, err := d.next.Get(ctx, id)
+	done(err)
+	return article, err
+}
+
+// ListScheduledDue retrieve ids of scheduled articles due at now
+func (d *Database) ListScheduledDue(ctx context.Context, now time.Time, limit int) ([]int, error) {
+	ctx, done := call(ctx, metrics.StorageDatabase, "list_scheduled_due")
+	ids, err := d.next.ListScheduledDue(ctx, now, limit)
+	done(err)
+	return ids, err
+}
+
+// Iterate call fn with every article matching query. The recorded latency
+// include the time spent by fn
+func (d *Database) Iterate(ctx context.Context, query model.ArticleSearchQuery, fn func(article model.Article) error) error {
+	ctx, done := call(ctx, metrics.StorageDatabase, "iterate")
+	err := d.next.Iterate(ctx, query, fn)
+	done(err)
+	return err
+}
+
+// LastUpdated retrieve the latest update time of any article
+func (d *Database) LastUpdated(ctx context.Context) (time.Time, error) {
+	ctx, done := call(ctx, metrics.StorageDatabase, "last_updated")
+	updated, err := d.next.LastUpdated(ctx)
+	done(err)
+	return updated, err
+}
+
+// ListRevisions retrieve every revision of an article
+func (d *Database) ListRevisions(ctx context.Context, articleID int) ([]model.ArticleRevision, error) {
+	ctx, done := call(ctx, metrics.StorageDatabase, "list_revisions")
+	revisions, err := d.next.ListRevisions(ctx, articleID)
+	done(err)
+	return revisions, err
+}
+
+// GetRevision retrieve a revision of an article
+func (d *Database) GetRevision(ctx context.Context, articleID int, revision int) (model.ArticleRevision, error) {
+	ctx, done := call(ctx, metrics.StorageDatabase, "get_revision")
+	articleRevision, err := d.next.GetRevision(ctx, articleID, revision)
+	done(err)
+	return articleRevision, err
+}
+
+// Cache represent article cache instrumenting decorator
+type Cache struct {
+	next article.Cache
+}
+
+// NewCache create a new instance of article cache recording the calls to next
+func NewCache(next article.Cache) *Cache {
+	return &Cache{
+		next: next,
+	}
+}
+
+// Cache write article to cache storage
+func (c *Cache) Cache(ctx context.Context, article model.Article) error {
+	ctx, done := call(ctx, metrics.StorageCache, "cache")
+	err := c.next.Cache(ctx, article)
+	done(err)
+	return err
+}
+
+// Get retrieve an article by id from cache storage
+func (c *Cache) Get(ctx context.Context, id int) (model.Article, error) {
+	ctx, done := call(ctx, metrics.StorageCache, "get")
+	article, err := c.next.Get(ctx, id)
+	done(err)
+	return article, err
+}
+
+// GetSummary retrieve an article by id without its body from cache storage
+func (c *Cache) GetSummary(ctx context.Context, id int) (model.Article, error) {
+	ctx, done := call(ctx, metrics.StorageCache, "get_summary")
+	article, err := c.next.GetSummary(ctx, id)
+	done(err)
+	return article, err
+}
+
+// Indexer represent article indexer instrumenting decorator
+type Indexer struct {
+	next article.Indexer
+}
+
+// NewIndexer create a new instance of article indexer recording the calls to
+// next
+func NewIndexer(next article.Indexer) *Indexer {
+	return &Indexer{
+		next: next,
+	}
+}
+
+// Index write an article to the search index
+func (i *Indexer) Index(ctx context.Context, article model.Article) error {
+	ctx, done := call(ctx, metrics.StorageIndexer, "index")
+	err := i.next.Index(ctx, article)
+	done(err)
+	return err
+}
+
+// IndexBatch write many articles to the search index at once
+func (i *Indexer) IndexBatch(ctx context.Context, articles []model.Article) ([]error, error) {
+	ctx, done := call(ctx, metrics.StorageIndexer, "index_batch")
+	errs, err := i.next.IndexBatch(ctx, articles)
+	done(err)
+	return errs, err
+}
+
+// Remove delete an article from the search index
+func (i *Indexer) Remove(ctx context.Context, id int) error {
+	ctx, done := call(ctx, metrics.StorageIndexer, "remove")
+	err := i.next.Remove(ctx, id)
+	done(err)
+	return err
+}
+
+// Search retrieve ids of articles matching query
+func (i *Indexer) Search(ctx context.Context, query model.ArticleSearchQuery) ([]int, error) {
+	ctx, done := call(ctx, metrics.StorageIndexer, "search")
+	ids, err := i.next.Search(ctx, query)
+	done(err)
+	return ids, err
+}
+
+// Related retrieve ids of articles related to an article
+func (i *Indexer) Related(ctx context.Context, query model.ArticleRelatedQuery) ([]int, error) {
+	ctx, done := call(ctx, metrics.StorageIndexer, "related")
+	ids, err := i.next.Related(ctx, query)
+	done(err)
+	return ids, err
+}
+
+// CountTags count articles by tag
+func (i *Indexer) CountTags(ctx context.Context, query model.TagQuery) ([]model.TagCount, error) {
+	ctx, done := call(ctx, metrics.StorageIndexer, "count_tags")
+	counts, err := i.next.CountTags(ctx, query)
+	done(err)
+	return counts, err
+}
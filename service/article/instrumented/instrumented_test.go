@@ -0,0 +1,106 @@
+package instrumented_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/oteltest"
+
+	"github.com/prabudzak/article/metrics"
+	"github.com/prabudzak/article/model"
+	"github.com/prabudzak/article/service"
+	"github.com/prabudzak/article/service/article/instrumented"
+	"github.com/prabudzak/article/service/article/mock"
+)
+
+func TestStorageCall(t *testing.T) {
+	tests := []struct {
+		name           string
+		storage        string
+		operation      string
+		call           func(ctrl *gomock.Controller, err error) error
+		err            error
+		expectedResult string
+		expectedStatus codes.Code
+	}{
+		{
+			name:      "database call succeeded",
+			storage:   metrics.StorageDatabase,
+			operation: "get",
+			call: func(ctrl *gomock.Controller, err error) error {
+				database := mock.NewMockDatabase(ctrl)
+				database.EXPECT().Get(gomock.Any(), 1).Return(model.Article{ID: 1}, err)
+				_, err = instrumented.NewDatabase(database).Get(context.Background(), 1)
+				return err
+			},
+			expectedResult: "ok",
+		},
+		{
+			name:      "cache miss",
+			storage:   metrics.StorageCache,
+			operation: "get_summary",
+			call: func(ctrl *gomock.Controller, err error) error {
+				cache := mock.NewMockCache(ctrl)
+				cache.EXPECT().GetSummary(gomock.Any(), 1).Return(model.Article{}, err)
+				_, err = instrumented.NewCache(cache).GetSummary(context.Background(), 1)
+				return err
+			},
+			err:            service.ErrArticleNotFound,
+			expectedResult: "not_found",
+		},
+		{
+			name:      "indexer unavailable",
+			storage:   metrics.StorageIndexer,
+			operation: "index",
+			call: func(ctrl *gomock.Controller, err error) error {
+				indexer := mock.NewMockIndexer(ctrl)
+				indexer.EXPECT().Index(gomock.Any(), gomock.Any()).Return(err)
+				return instrumented.NewIndexer(indexer).Index(context.Background(), model.Article{ID: 1})
+			},
+			err:            service.Unavailable(assert.AnError),
+			expectedResult: "unavailable",
+			expectedStatus: codes.Error,
+		},
+		{
+			name:      "database failure",
+			storage:   metrics.StorageDatabase,
+			operation: "update",
+			call: func(ctrl *gomock.Controller, err error) error {
+				database := mock.NewMockDatabase(ctrl)
+				database.EXPECT().Update(gomock.Any(), gomock.Any()).Return(err)
+				return instrumented.NewDatabase(database).Update(context.Background(), model.Article{ID: 1})
+			},
+			err:            assert.AnError,
+			expectedResult: "internal",
+			expectedStatus: codes.Error,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			recorder := &oteltest.StandardSpanRecorder{}
+			otel.SetTracerProvider(oteltest.NewTracerProvider(oteltest.WithSpanRecorder(recorder)))
+
+			requests := metrics.StorageRequests.WithLabelValues(tc.storage, tc.operation, tc.expectedResult)
+			before := testutil.ToFloat64(requests)
+
+			err := tc.call(ctrl, tc.err)
+			assert.Equal(t, tc.err, err)
+			assert.Equal(t, float64(1), testutil.ToFloat64(requests)-before)
+
+			spans := recorder.Completed()
+			if assert.Len(t, spans, 1) {
+				assert.Equal(t, tc.storage+"."+tc.operation, spans[0].Name())
+				assert.Equal(t, tc.expectedStatus, spans[0].StatusCode())
+			}
+		})
+	}
+}
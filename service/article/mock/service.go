@@ -9,6 +9,7 @@ import (
 	gomock "github.com/golang/mock/gomock"
 	model "github.com/prabudzak/article/model"
 	reflect "reflect"
+	time "time"
 )
 
 // MockDatabase is a mock of Database interface
@@ -49,6 +50,21 @@ func (mr *MockDatabaseMockRecorder) GenerateID(ctx interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateID", reflect.TypeOf((*MockDatabase)(nil).GenerateID), ctx)
 }
 
+// GenerateIDs mocks base method
+func (m *MockDatabase) GenerateIDs(ctx context.Context, count int) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateIDs", ctx, count)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GenerateIDs indicates an expected call of GenerateIDs
+func (mr *MockDatabaseMockRecorder) GenerateIDs(ctx, count interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateIDs", reflect.TypeOf((*MockDatabase)(nil).GenerateIDs), ctx, count)
+}
+
 // Create mocks base method
 func (m *MockDatabase) Create(ctx context.Context, article model.Article) error {
 	m.ctrl.T.Helper()
@@ -63,6 +79,34 @@ func (mr *MockDatabaseMockRecorder) Create(ctx, article interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockDatabase)(nil).Create), ctx, article)
 }
 
+// CreateBatch mocks base method
+func (m *MockDatabase) CreateBatch(ctx context.Context, articles []model.Article) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBatch", ctx, articles)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateBatch indicates an expected call of CreateBatch
+func (mr *MockDatabaseMockRecorder) CreateBatch(ctx, articles interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBatch", reflect.TypeOf((*MockDatabase)(nil).CreateBatch), ctx, articles)
+}
+
+// Update mocks base method
+func (m *MockDatabase) Update(ctx context.Context, article model.Article) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, article)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update
+func (mr *MockDatabaseMockRecorder) Update(ctx, article interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockDatabase)(nil).Update), ctx, article)
+}
+
 // Get mocks base method
 func (m *MockDatabase) Get(ctx context.Context, id int) (model.Article, error) {
 	m.ctrl.T.Helper()
@@ -78,6 +122,80 @@ func (mr *MockDatabaseMockRecorder) Get(ctx, id interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockDatabase)(nil).Get), ctx, id)
 }
 
+// ListScheduledDue mocks base method
+func (m *MockDatabase) ListScheduledDue(ctx context.Context, now time.Time, limit int) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListScheduledDue", ctx, now, limit)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListScheduledDue indicates an expected call of ListScheduledDue
+func (mr *MockDatabaseMockRecorder) ListScheduledDue(ctx, now, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListScheduledDue", reflect.TypeOf((*MockDatabase)(nil).ListScheduledDue), ctx, now, limit)
+}
+
+// LastUpdated mocks base method
+func (m *MockDatabase) LastUpdated(ctx context.Context) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LastUpdated", ctx)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LastUpdated indicates an expected call of LastUpdated
+func (mr *MockDatabaseMockRecorder) LastUpdated(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LastUpdated", reflect.TypeOf((*MockDatabase)(nil).LastUpdated), ctx)
+}
+
+// Iterate mocks base method
+func (m *MockDatabase) Iterate(ctx context.Context, query model.ArticleSearchQuery, fn func(model.Article) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Iterate", ctx, query, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Iterate indicates an expected call of Iterate
+func (mr *MockDatabaseMockRecorder) Iterate(ctx, query, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Iterate", reflect.TypeOf((*MockDatabase)(nil).Iterate), ctx, query, fn)
+}
+
+// ListRevisions mocks base method
+func (m *MockDatabase) ListRevisions(ctx context.Context, articleID int) ([]model.ArticleRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRevisions", ctx, articleID)
+	ret0, _ := ret[0].([]model.ArticleRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRevisions indicates an expected call of ListRevisions
+func (mr *MockDatabaseMockRecorder) ListRevisions(ctx, articleID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRevisions", reflect.TypeOf((*MockDatabase)(nil).ListRevisions), ctx, articleID)
+}
+
+// GetRevision mocks base method
+func (m *MockDatabase) GetRevision(ctx context.Context, articleID, revision int) (model.ArticleRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRevision", ctx, articleID, revision)
+	ret0, _ := ret[0].(model.ArticleRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRevision indicates an expected call of GetRevision
+func (mr *MockDatabaseMockRecorder) GetRevision(ctx, articleID, revision interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRevision", reflect.TypeOf((*MockDatabase)(nil).GetRevision), ctx, articleID, revision)
+}
+
 // MockCache is a mock of Cache interface
 type MockCache struct {
 	ctrl     *gomock.Controller
@@ -130,6 +248,21 @@ func (mr *MockCacheMockRecorder) Get(ctx, id interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockCache)(nil).Get), ctx, id)
 }
 
+// GetSummary mocks base method
+func (m *MockCache) GetSummary(ctx context.Context, id int) (model.Article, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSummary", ctx, id)
+	ret0, _ := ret[0].(model.Article)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSummary indicates an expected call of GetSummary
+func (mr *MockCacheMockRecorder) GetSummary(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSummary", reflect.TypeOf((*MockCache)(nil).GetSummary), ctx, id)
+}
+
 // MockIndexer is a mock of Indexer interface
 type MockIndexer struct {
 	ctrl     *gomock.Controller
@@ -167,6 +300,21 @@ func (mr *MockIndexerMockRecorder) Index(ctx, article interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Index", reflect.TypeOf((*MockIndexer)(nil).Index), ctx, article)
 }
 
+// IndexBatch mocks base method
+func (m *MockIndexer) IndexBatch(ctx context.Context, articles []model.Article) ([]error, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IndexBatch", ctx, articles)
+	ret0, _ := ret[0].([]error)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IndexBatch indicates an expected call of IndexBatch
+func (mr *MockIndexerMockRecorder) IndexBatch(ctx, articles interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IndexBatch", reflect.TypeOf((*MockIndexer)(nil).IndexBatch), ctx, articles)
+}
+
 // Remove mocks base method
 func (m *MockIndexer) Remove(ctx context.Context, id int) error {
 	m.ctrl.T.Helper()
@@ -195,3 +343,86 @@ func (mr *MockIndexerMockRecorder) Search(ctx, query interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockIndexer)(nil).Search), ctx, query)
 }
+
+// Related mocks base method
+func (m *MockIndexer) Related(ctx context.Context, query model.ArticleRelatedQuery) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Related", ctx, query)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Related indicates an expected call of Related
+func (mr *MockIndexerMockRecorder) Related(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Related", reflect.TypeOf((*MockIndexer)(nil).Related), ctx, query)
+}
+
+// CountTags mocks base method
+func (m *MockIndexer) CountTags(ctx context.Context, query model.TagQuery) ([]model.TagCount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountTags", ctx, query)
+	ret0, _ := ret[0].([]model.TagCount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountTags indicates an expected call of CountTags
+func (mr *MockIndexerMockRecorder) CountTags(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountTags", reflect.TypeOf((*MockIndexer)(nil).CountTags), ctx, query)
+}
+
+// MockAuthorResolver is a mock of AuthorResolver interface
+type MockAuthorResolver struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuthorResolverMockRecorder
+}
+
+// MockAuthorResolverMockRecorder is the mock recorder for MockAuthorResolver
+type MockAuthorResolverMockRecorder struct {
+	mock *MockAuthorResolver
+}
+
+// NewMockAuthorResolver creates a new mock instance
+func NewMockAuthorResolver(ctrl *gomock.Controller) *MockAuthorResolver {
+	mock := &MockAuthorResolver{ctrl: ctrl}
+	mock.recorder = &MockAuthorResolverMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockAuthorResolver) EXPECT() *MockAuthorResolverMockRecorder {
+	return m.recorder
+}
+
+// GetAuthor mocks base method
+func (m *MockAuthorResolver) GetAuthor(ctx context.Context, handle string) (model.Author, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuthor", ctx, handle)
+	ret0, _ := ret[0].(model.Author)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuthor indicates an expected call of GetAuthor
+func (mr *MockAuthorResolverMockRecorder) GetAuthor(ctx, handle interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuthor", reflect.TypeOf((*MockAuthorResolver)(nil).GetAuthor), ctx, handle)
+}
+
+// GetOrCreateAuthor mocks base method
+func (m *MockAuthorResolver) GetOrCreateAuthor(ctx context.Context, name string) (model.Author, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrCreateAuthor", ctx, name)
+	ret0, _ := ret[0].(model.Author)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrCreateAuthor indicates an expected call of GetOrCreateAuthor
+func (mr *MockAuthorResolverMockRecorder) GetOrCreateAuthor(ctx, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrCreateAuthor", reflect.TypeOf((*MockAuthorResolver)(nil).GetOrCreateAuthor), ctx, name)
+}
@@ -3,14 +3,21 @@ package mysql
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
-	"log"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/prabudzak/article/logger"
 	"github.com/prabudzak/article/model"
 	"github.com/prabudzak/article/service"
+	"github.com/prabudzak/article/service/mysqlerr"
 )
 
+// likeEscaper escape LIKE pattern wildcards of a literal text
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 // ArticleDatabase represent article database mysql implementation
 type ArticleDatabase struct {
 	db *sql.DB
@@ -23,46 +30,99 @@ func NewArticleDatabase(db *sql.DB) *ArticleDatabase {
 	}
 }
 
+// CheckHealth report whether the database is reachable
+func (a *ArticleDatabase) CheckHealth(ctx context.Context) error {
+	return a.db.PingContext(ctx)
+}
+
 // GenerateID generate a new id to be assigned to an article
 func (a *ArticleDatabase) GenerateID(ctx context.Context) (int, error) {
 	var id int
 
 	trx, err := a.db.BeginTx(ctx, &sql.TxOptions{})
 	if err != nil {
-		log.Println(err)
-		return 0, err
+		logger.FromContext(ctx).Error("article id not generated", logger.Err(err))
+		return 0, mysqlerr.Wrap(err)
 	}
 
 	_, err = trx.ExecContext(ctx, "UPDATE article_seq SET num = num + 1")
 	if err != nil {
-		log.Println(err)
+		logger.FromContext(ctx).Error("article id not generated", logger.Err(err))
 		trx.Rollback()
-		return 0, err
+		return 0, mysqlerr.Wrap(err)
 	}
 
 	row := trx.QueryRowContext(ctx, "SELECT num FROM article_seq LIMIT 1")
 	err = row.Scan(&id)
 	if err != nil {
-		log.Println(err)
+		logger.FromContext(ctx).Error("article id not generated", logger.Err(err))
 		trx.Rollback()
-		return 0, err
+		return 0, mysqlerr.Wrap(err)
 	}
 
 	err = trx.Commit()
 	if err != nil {
-		log.Println(err)
-		return 0, err
+		logger.FromContext(ctx).Error("article id not generated", logger.Err(err))
+		return 0, mysqlerr.Wrap(err)
 	}
 
 	return id, nil
 }
 
+// GenerateIDs generate a block of count consecutive new ids to be assigned
+// to articles
+func (a *ArticleDatabase) GenerateIDs(ctx context.Context, count int) ([]int, error) {
+	var last int
+
+	if count <= 0 {
+		return nil, errors.New("id count is invalid")
+	}
+
+	trx, err := a.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		logger.FromContext(ctx).Error("article ids not generated", logger.Int("count", count), logger.Err(err))
+		return nil, mysqlerr.Wrap(err)
+	}
+
+	_, err = trx.ExecContext(ctx, "UPDATE article_seq SET num = num + ?", count)
+	if err != nil {
+		logger.FromContext(ctx).Error("article ids not generated", logger.Int("count", count), logger.Err(err))
+		trx.Rollback()
+		return nil, mysqlerr.Wrap(err)
+	}
+
+	row := trx.QueryRowContext(ctx, "SELECT num FROM article_seq LIMIT 1")
+	err = row.Scan(&last)
+	if err != nil {
+		logger.FromContext(ctx).Error("article ids not generated", logger.Int("count", count), logger.Err(err))
+		trx.Rollback()
+		return nil, mysqlerr.Wrap(err)
+	}
+
+	err = trx.Commit()
+	if err != nil {
+		logger.FromContext(ctx).Error("article ids not generated", logger.Int("count", count), logger.Err(err))
+		return nil, mysqlerr.Wrap(err)
+	}
+
+	ids := make([]int, 0, count)
+	for id := last - count + 1; id <= last; id++ {
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
 // Create write a new article to database
 func (a *ArticleDatabase) Create(ctx context.Context, article model.Article) error {
 	if article.ID == 0 {
 		return errors.New("article id is invalid")
 	}
 
+	if article.AuthorID == 0 {
+		return errors.New("article author id is invalid")
+	}
+
 	if article.CreatedAt.IsZero() {
 		article.CreatedAt = time.Now().UTC()
 	}
@@ -71,39 +131,669 @@ func (a *ArticleDatabase) Create(ctx context.Context, article model.Article) err
 		article.UpdatedAt = article.CreatedAt
 	}
 
-	_, err := a.db.QueryContext(ctx, "INSERT INTO article (id, author, title, body, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)",
+	if article.Status == "" {
+		article.Status = model.ArticleStatusPublished
+	}
+
+	if article.Version == 0 {
+		article.Version = 1
+	}
+
+	trx, err := a.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		logger.FromContext(ctx).Error("article not created", logger.ArticleID(article.ID), logger.Err(err))
+		return mysqlerr.Wrap(err)
+	}
+
+	_, err = trx.ExecContext(ctx, "INSERT INTO article (id, author_id, language, title, body, body_format, body_html, excerpt, word_count, reading_time, status, publish_at, version, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
 		article.ID,
-		article.Author,
+		article.AuthorID,
+		article.Language,
 		article.Title,
 		article.Body,
+		article.BodyFormat,
+		article.BodyHTML,
+		article.Excerpt,
+		article.WordCount,
+		article.ReadingTime,
+		article.Status,
+		article.PublishAt,
+		article.Version,
 		article.CreatedAt,
 		article.UpdatedAt,
 	)
+	if err != nil {
+		logger.FromContext(ctx).Error("article not created", logger.ArticleID(article.ID), logger.Err(err))
+		trx.Rollback()
+		return mysqlerr.Wrap(err)
+	}
+
+	err = a.insertTags(ctx, trx, article.ID, article.Tags)
+	if err != nil {
+		logger.FromContext(ctx).Error("article not created", logger.ArticleID(article.ID), logger.Err(err))
+		trx.Rollback()
+		return mysqlerr.Wrap(err)
+	}
+
+	err = a.insertRevision(ctx, trx, article)
+	if err != nil {
+		logger.FromContext(ctx).Error("article not created", logger.ArticleID(article.ID), logger.Err(err))
+		trx.Rollback()
+		return mysqlerr.Wrap(err)
+	}
+
+	err = trx.Commit()
+	if err != nil {
+		logger.FromContext(ctx).Error("article not created", logger.ArticleID(article.ID), logger.Err(err))
+		return mysqlerr.Wrap(err)
+	}
+
+	return nil
+}
+
+// CreateBatch write new articles to database with multi row inserts, all or
+// none of them. Inserts are split into statements of at most maxInsertBytes
+// of values, so each statement fit in max_allowed_packet
+func (a *ArticleDatabase) CreateBatch(ctx context.Context, articles []model.Article) error {
+	if len(articles) == 0 {
+		return nil
+	}
+
+	articleInsert := newBatchInsert("INSERT INTO article (id, author_id, language, title, body, body_format, body_html, excerpt, word_count, reading_time, status, publish_at, version, created_at, updated_at) VALUES ",
+		"(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	revisionInsert := newBatchInsert("INSERT INTO article_revision (article_id, revision, author_id, language, title, body, body_format, tags, status, publish_at, created_at) VALUES ",
+		"(?, 1, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	tagInsert := newBatchInsert("INSERT INTO article_tag (article_id, tag) VALUES ", "(?, ?)")
+
+	for _, article := range articles {
+		if article.ID == 0 {
+			return errors.New("article id is invalid")
+		}
+
+		if article.AuthorID == 0 {
+			return errors.New("article author id is invalid")
+		}
+
+		if article.CreatedAt.IsZero() {
+			article.CreatedAt = time.Now().UTC()
+		}
+
+		if article.UpdatedAt.IsZero() {
+			article.UpdatedAt = article.CreatedAt
+		}
+
+		if article.Status == "" {
+			article.Status = model.ArticleStatusPublished
+		}
 
+		if article.Version == 0 {
+			article.Version = 1
+		}
+
+		articleInsert.add(
+			article.ID,
+			article.AuthorID,
+			article.Language,
+			article.Title,
+			article.Body,
+			article.BodyFormat,
+			article.BodyHTML,
+			article.Excerpt,
+			article.WordCount,
+			article.ReadingTime,
+			article.Status,
+			article.PublishAt,
+			article.Version,
+			article.CreatedAt,
+			article.UpdatedAt,
+		)
+
+		tags := article.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+		jsonedTags, _ := json.Marshal(tags)
+
+		revisionInsert.add(
+			article.ID,
+			article.AuthorID,
+			article.Language,
+			article.Title,
+			article.Body,
+			article.BodyFormat,
+			string(jsonedTags),
+			article.Status,
+			article.PublishAt,
+			article.UpdatedAt,
+		)
+
+		for _, tag := range article.Tags {
+			tagInsert.add(article.ID, tag)
+		}
+	}
+
+	trx, err := a.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		logger.FromContext(ctx).Error("articles not created", logger.Int("count", len(articles)), logger.Err(err))
+		return mysqlerr.Wrap(err)
+	}
+
+	for _, insert := range []*batchInsert{articleInsert, tagInsert, revisionInsert} {
+		err = insert.exec(ctx, trx)
+		if err != nil {
+			logger.FromContext(ctx).Error("articles not created", logger.Int("count", len(articles)), logger.Err(err))
+			trx.Rollback()
+			return mysqlerr.Wrap(err)
+		}
+	}
+
+	err = trx.Commit()
 	if err != nil {
-		log.Println(err)
-		return err
+		logger.FromContext(ctx).Error("articles not created", logger.Int("count", len(articles)), logger.Err(err))
+		return mysqlerr.Wrap(err)
+	}
+
+	return nil
+}
+
+// maxInsertBytes represent the size limit of the values of a multi row insert
+// statement, well below the 4MiB default max_allowed_packet
+const maxInsertBytes = 1 << 20
+
+// batchInsert represent multi row insert statements of a table, split once
+// the values of a statement exceed maxInsertBytes
+type batchInsert struct {
+	prefix      string
+	placeholder string
+	statements  []batchStatement
+}
+
+type batchStatement struct {
+	placeholders []string
+	args         []interface{}
+	size         int
+}
+
+func newBatchInsert(prefix string, placeholder string) *batchInsert {
+	return &batchInsert{
+		prefix:      prefix,
+		placeholder: placeholder,
+	}
+}
+
+// add append a row of given values, starting a new statement when the row
+// does not fit in the current one. A row larger than maxInsertBytes is
+// inserted by a statement of its own
+func (b *batchInsert) add(args ...interface{}) {
+	size := len(b.placeholder)
+	for _, arg := range args {
+		switch value := arg.(type) {
+		case string:
+			size += len(value)
+		case []byte:
+			size += len(value)
+		default:
+			size += 32
+		}
+	}
+
+	last := len(b.statements) - 1
+	if last < 0 || b.statements[last].size+size > maxInsertBytes {
+		b.statements = append(b.statements, batchStatement{})
+		last++
 	}
 
+	statement := &b.statements[last]
+	statement.placeholders = append(statement.placeholders, b.placeholder)
+	statement.args = append(statement.args, args...)
+	statement.size += size
+}
+
+// exec run every insert statement in given transaction
+func (b *batchInsert) exec(ctx context.Context, trx *sql.Tx) error {
+	for _, statement := range b.statements {
+		_, err := trx.ExecContext(ctx, b.prefix+strings.Join(statement.placeholders, ", "), statement.args...)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// Update overwrite an existing article and its tags in database and increment
+// its version. The article version must be the currently stored version,
+// otherwise the article is left untouched and version conflict is returned
+func (a *ArticleDatabase) Update(ctx context.Context, article model.Article) error {
+	if article.ID == 0 {
+		return errors.New("article id is invalid")
+	}
+
+	if article.UpdatedAt.IsZero() {
+		article.UpdatedAt = time.Now().UTC()
+	}
+
+	trx, err := a.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		logger.FromContext(ctx).Error("article not updated", logger.ArticleID(article.ID), logger.Err(err))
+		return mysqlerr.Wrap(err)
+	}
+
+	result, err := trx.ExecContext(ctx, "UPDATE article SET author_id = ?, language = ?, title = ?, body = ?, body_format = ?, body_html = ?, excerpt = ?, word_count = ?, reading_time = ?, status = ?, publish_at = ?, updated_at = ?, version = version + 1 "+
+		"WHERE id = ? AND version = ?",
+		article.AuthorID,
+		article.Language,
+		article.Title,
+		article.Body,
+		article.BodyFormat,
+		article.BodyHTML,
+		article.Excerpt,
+		article.WordCount,
+		article.ReadingTime,
+		article.Status,
+		article.PublishAt,
+		article.UpdatedAt,
+		article.ID,
+		article.Version,
+	)
+	if err != nil {
+		logger.FromContext(ctx).Error("article not updated", logger.ArticleID(article.ID), logger.Err(err))
+		trx.Rollback()
+		return mysqlerr.Wrap(err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		logger.FromContext(ctx).Error("article not updated", logger.ArticleID(article.ID), logger.Err(err))
+		trx.Rollback()
+		return mysqlerr.Wrap(err)
+	}
+
+	if affected == 0 {
+		var count int
+		err = trx.QueryRowContext(ctx, "SELECT COUNT(*) FROM article WHERE id = ?", article.ID).Scan(&count)
+		trx.Rollback()
+		if err != nil {
+			logger.FromContext(ctx).Error("article not updated", logger.ArticleID(article.ID), logger.Err(err))
+			return mysqlerr.Wrap(err)
+		}
+
+		if count == 0 {
+			return service.ErrArticleNotFound
+		}
+		return service.ErrArticleVersionConflict
+	}
+
+	_, err = trx.ExecContext(ctx, "DELETE FROM article_tag WHERE article_id = ?", article.ID)
+	if err != nil {
+		logger.FromContext(ctx).Error("article not updated", logger.ArticleID(article.ID), logger.Err(err))
+		trx.Rollback()
+		return mysqlerr.Wrap(err)
+	}
+
+	err = a.insertTags(ctx, trx, article.ID, article.Tags)
+	if err != nil {
+		logger.FromContext(ctx).Error("article not updated", logger.ArticleID(article.ID), logger.Err(err))
+		trx.Rollback()
+		return mysqlerr.Wrap(err)
+	}
+
+	err = a.insertRevision(ctx, trx, article)
+	if err != nil {
+		logger.FromContext(ctx).Error("article not updated", logger.ArticleID(article.ID), logger.Err(err))
+		trx.Rollback()
+		return mysqlerr.Wrap(err)
+	}
+
+	err = trx.Commit()
+	if err != nil {
+		logger.FromContext(ctx).Error("article not updated", logger.ArticleID(article.ID), logger.Err(err))
+		return mysqlerr.Wrap(err)
+	}
+
+	return nil
+}
+
+// ListScheduledDue list ids of scheduled articles with publish time at or
+// before the given time, the earliest first
+func (a *ArticleDatabase) ListScheduledDue(ctx context.Context, now time.Time, limit int) ([]int, error) {
+	rows, err := a.db.QueryContext(ctx, "SELECT id FROM article WHERE status = ? AND publish_at <= ? ORDER BY publish_at LIMIT ?",
+		model.ArticleStatusScheduled,
+		now,
+		limit,
+	)
+	if err != nil {
+		logger.FromContext(ctx).Error("scheduled articles not listed", logger.Err(err))
+		return nil, mysqlerr.Wrap(err)
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		err = rows.Scan(&id)
+		if err != nil {
+			logger.FromContext(ctx).Error("scheduled articles not listed", logger.Err(err))
+			return nil, mysqlerr.Wrap(err)
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// LastUpdated retrieve the latest update time of any article, zero when there
+// is none
+func (a *ArticleDatabase) LastUpdated(ctx context.Context) (time.Time, error) {
+	var updated sql.NullTime
+	err := a.db.QueryRowContext(ctx, "SELECT MAX(updated_at) FROM article").Scan(&updated)
+	if err != nil {
+		logger.FromContext(ctx).Error("article last update not retrieved", logger.Err(err))
+		return time.Time{}, mysqlerr.Wrap(err)
+	}
+
+	return updated.Time, nil
+}
+
+// iterateBatchSize is the number of articles read from database at a time
+// while iterating articles
+const iterateBatchSize = 500
+
+// Iterate call fn with every article matching the search query, in id order.
+// Articles are read in batches of iterateBatchSize keyed by the last read id,
+// so neither the whole result nor a long running query is held. Keyword match
+// title or body literally. Iteration stop at the first error returned by fn,
+// which is returned as is
+func (a *ArticleDatabase) Iterate(ctx context.Context, query model.ArticleSearchQuery, fn func(article model.Article) error) error {
+	var conditions []string
+	var args []interface{}
+
+	if query.Status != "" {
+		conditions = append(conditions, "ar.status = ?")
+		args = append(args, query.Status)
+	}
+
+	if query.Author != "" {
+		conditions = append(conditions, "au.handle = ?")
+		args = append(args, query.Author)
+	}
+
+	if query.Language != "" {
+		conditions = append(conditions, "ar.language = ?")
+		args = append(args, query.Language)
+	}
+
+	if query.Keyword != "" {
+		keyword := "%" + likeEscaper.Replace(query.Keyword) + "%"
+		conditions = append(conditions, "(ar.title LIKE ? OR ar.body LIKE ?)")
+		args = append(args, keyword, keyword)
+	}
+
+	if len(query.Tags) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(query.Tags)), ", ")
+		condition := "ar.id IN (SELECT article_id FROM article_tag WHERE tag IN (" + placeholders + ")"
+		if query.TagMode == model.TagModeAll {
+			condition += " GROUP BY article_id HAVING COUNT(DISTINCT tag) = " + strconv.Itoa(len(query.Tags))
+		}
+		conditions = append(conditions, condition+")")
+		for _, tag := range query.Tags {
+			args = append(args, tag)
+		}
+	}
+
+	statement := "SELECT ar.id, ar.author_id, au.name, au.handle, ar.language, ar.title, ar.body, ar.body_format, ar.body_html, ar.excerpt, ar.word_count, ar.reading_time, ar.status, ar.publish_at, ar.version, ar.created_at, ar.updated_at " +
+		"FROM article ar JOIN author au ON au.id = ar.author_id WHERE ar.id > ?"
+	for _, condition := range conditions {
+		statement += " AND " + condition
+	}
+	statement += " ORDER BY ar.id LIMIT ?"
+
+	lastID := 0
+	for {
+		articles, err := a.listAfter(ctx, statement, append(append([]interface{}{lastID}, args...), iterateBatchSize))
+		if err != nil {
+			logger.FromContext(ctx).Error("articles not iterated", logger.Int("after_id", lastID), logger.Err(err))
+			return mysqlerr.Wrap(err)
+		}
+
+		for _, article := range articles {
+			err = fn(article)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(articles) < iterateBatchSize {
+			return nil
+		}
+		lastID = articles[len(articles)-1].ID
+	}
+}
+
+// listAfter list a batch of articles with their tags
+func (a *ArticleDatabase) listAfter(ctx context.Context, statement string, args []interface{}) ([]model.Article, error) {
+	rows, err := a.db.QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, mysqlerr.Wrap(err)
+	}
+	defer rows.Close()
+
+	articles := []model.Article{}
+	positions := map[int]int{}
+	for rows.Next() {
+		var article model.Article
+		var bodyHTML sql.NullString
+		err = rows.Scan(&article.ID, &article.AuthorID, &article.Author, &article.AuthorHandle, &article.Language, &article.Title, &article.Body,
+			&article.BodyFormat, &bodyHTML, &article.Excerpt, &article.WordCount, &article.ReadingTime, &article.Status, &article.PublishAt, &article.Version, &article.CreatedAt, &article.UpdatedAt)
+		if err != nil {
+			return nil, mysqlerr.Wrap(err)
+		}
+
+		article.BodyHTML = bodyHTML.String
+		article.Tags = []string{}
+		positions[article.ID] = len(articles)
+		articles = append(articles, article)
+	}
+
+	err = rows.Err()
+	if err != nil || len(articles) == 0 {
+		return articles, mysqlerr.Wrap(err)
+	}
+
+	ids := make([]interface{}, 0, len(articles))
+	for _, article := range articles {
+		ids = append(ids, article.ID)
+	}
+
+	tagRows, err := a.db.QueryContext(ctx, "SELECT article_id, tag FROM article_tag WHERE article_id IN ("+
+		strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")+") ORDER BY tag", ids...)
+	if err != nil {
+		return nil, mysqlerr.Wrap(err)
+	}
+	defer tagRows.Close()
+
+	for tagRows.Next() {
+		var id int
+		var tag string
+		err = tagRows.Scan(&id, &tag)
+		if err != nil {
+			return nil, mysqlerr.Wrap(err)
+		}
+
+		article := &articles[positions[id]]
+		article.Tags = append(article.Tags, tag)
+	}
+
+	return articles, tagRows.Err()
+}
+
+// insertTags write article tags to article tag join table
+func (a *ArticleDatabase) insertTags(ctx context.Context, trx *sql.Tx, articleID int, tags []string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, 0, len(tags))
+	args := make([]interface{}, 0, len(tags)*2)
+	for _, tag := range tags {
+		placeholders = append(placeholders, "(?, ?)")
+		args = append(args, articleID, tag)
+	}
+
+	_, err := trx.ExecContext(ctx, "INSERT INTO article_tag (article_id, tag) VALUES "+strings.Join(placeholders, ", "), args...)
+	return mysqlerr.Wrap(err)
+}
+
+// insertRevision write an immutable snapshot of the written article as its
+// next revision
+func (a *ArticleDatabase) insertRevision(ctx context.Context, trx *sql.Tx, article model.Article) error {
+	var revision int
+
+	row := trx.QueryRowContext(ctx, "SELECT COALESCE(MAX(revision), 0) + 1 FROM article_revision WHERE article_id = ? FOR UPDATE", article.ID)
+	err := row.Scan(&revision)
+	if err != nil {
+		return mysqlerr.Wrap(err)
+	}
+
+	tags := article.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	jsonedTags, _ := json.Marshal(tags)
+
+	_, err = trx.ExecContext(ctx, "INSERT INTO article_revision (article_id, revision, author_id, language, title, body, body_format, tags, status, publish_at, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		article.ID,
+		revision,
+		article.AuthorID,
+		article.Language,
+		article.Title,
+		article.Body,
+		article.BodyFormat,
+		string(jsonedTags),
+		article.Status,
+		article.PublishAt,
+		article.UpdatedAt,
+	)
+	return mysqlerr.Wrap(err)
+}
+
 // Get retrieve an article by in from database
 func (a *ArticleDatabase) Get(ctx context.Context, id int) (model.Article, error) {
 	var article model.Article
+	var bodyHTML sql.NullString
 
 	if id == 0 {
 		return article, errors.New("id parameter is invalid")
 	}
 
-	row := a.db.QueryRowContext(ctx, "SELECT id, author, title, body, created_at, updated_at FROM article WHERE id = ?", id)
-	err := row.Scan(&article.ID, &article.Author, &article.Title, &article.Body, &article.CreatedAt, &article.UpdatedAt)
+	row := a.db.QueryRowContext(ctx, "SELECT ar.id, ar.author_id, au.name, au.handle, ar.language, ar.title, ar.body, ar.body_format, ar.body_html, ar.excerpt, ar.word_count, ar.reading_time, ar.status, ar.publish_at, ar.version, ar.created_at, ar.updated_at "+
+		"FROM article ar JOIN author au ON au.id = ar.author_id WHERE ar.id = ?", id)
+	err := row.Scan(&article.ID, &article.AuthorID, &article.Author, &article.AuthorHandle, &article.Language, &article.Title, &article.Body,
+		&article.BodyFormat, &bodyHTML, &article.Excerpt, &article.WordCount, &article.ReadingTime, &article.Status, &article.PublishAt, &article.Version, &article.CreatedAt, &article.UpdatedAt)
 	if err == sql.ErrNoRows {
 		return article, service.ErrArticleNotFound
 	} else if err != nil {
-		log.Println(err)
-		return article, err
+		logger.FromContext(ctx).Error("article not retrieved", logger.ArticleID(id), logger.Err(err))
+		return article, mysqlerr.Wrap(err)
+	}
+	article.BodyHTML = bodyHTML.String
+
+	article.Tags, err = a.getTags(ctx, id)
+	if err != nil {
+		logger.FromContext(ctx).Error("article not retrieved", logger.ArticleID(id), logger.Err(err))
+		return article, mysqlerr.Wrap(err)
 	}
 
 	return article, nil
 }
+
+// getTags retrieve tags of an article sorted by name
+func (a *ArticleDatabase) getTags(ctx context.Context, articleID int) ([]string, error) {
+	rows, err := a.db.QueryContext(ctx, "SELECT tag FROM article_tag WHERE article_id = ? ORDER BY tag", articleID)
+	if err != nil {
+		return nil, mysqlerr.Wrap(err)
+	}
+	defer rows.Close()
+
+	tags := []string{}
+	for rows.Next() {
+		var tag string
+		err = rows.Scan(&tag)
+		if err != nil {
+			return nil, mysqlerr.Wrap(err)
+		}
+
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
+
+// ListRevisions retrieve all revisions of an article, the latest first
+func (a *ArticleDatabase) ListRevisions(ctx context.Context, articleID int) ([]model.ArticleRevision, error) {
+	if articleID == 0 {
+		return nil, errors.New("article id parameter is invalid")
+	}
+
+	rows, err := a.db.QueryContext(ctx, "SELECT article_id, revision, author_id, language, title, body, body_format, tags, status, publish_at, created_at "+
+		"FROM article_revision WHERE article_id = ? ORDER BY revision DESC", articleID)
+	if err != nil {
+		logger.FromContext(ctx).Error("article revisions not listed", logger.ArticleID(articleID), logger.Err(err))
+		return nil, mysqlerr.Wrap(err)
+	}
+	defer rows.Close()
+
+	revisions := []model.ArticleRevision{}
+	for rows.Next() {
+		revision, err := scanRevision(rows)
+		if err != nil {
+			logger.FromContext(ctx).Error("article revisions not listed", logger.ArticleID(articleID), logger.Err(err))
+			return nil, mysqlerr.Wrap(err)
+		}
+
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, rows.Err()
+}
+
+// GetRevision retrieve an article revision by revision number
+func (a *ArticleDatabase) GetRevision(ctx context.Context, articleID int, revision int) (model.ArticleRevision, error) {
+	if articleID == 0 || revision == 0 {
+		return model.ArticleRevision{}, errors.New("revision parameter is invalid")
+	}
+
+	row := a.db.QueryRowContext(ctx, "SELECT article_id, revision, author_id, language, title, body, body_format, tags, status, publish_at, created_at "+
+		"FROM article_revision WHERE article_id = ? AND revision = ?", articleID, revision)
+	result, err := scanRevision(row)
+	if err == sql.ErrNoRows {
+		return result, service.ErrRevisionNotFound
+	} else if err != nil {
+		logger.FromContext(ctx).Error("article revision not retrieved", logger.ArticleID(articleID), logger.Int("revision", revision), logger.Err(err))
+		return result, mysqlerr.Wrap(err)
+	}
+
+	return result, nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanRevision(row scanner) (model.ArticleRevision, error) {
+	var revision model.ArticleRevision
+	var tags string
+
+	err := row.Scan(&revision.ArticleID, &revision.Revision, &revision.AuthorID, &revision.Language, &revision.Title, &revision.Body,
+		&revision.BodyFormat, &tags, &revision.Status, &revision.PublishAt, &revision.CreatedAt)
+	if err != nil {
+		return revision, mysqlerr.Wrap(err)
+	}
+
+	err = json.Unmarshal([]byte(tags), &revision.Tags)
+	if err != nil {
+		return revision, mysqlerr.Wrap(err)
+	}
+
+	return revision, nil
+}
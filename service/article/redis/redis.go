@@ -5,14 +5,33 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 
 	"github.com/go-redis/redis"
+	"github.com/prabudzak/article/logger"
 	"github.com/prabudzak/article/model"
 	"github.com/prabudzak/article/service"
 )
 
-const articleKey = "20210130/articles/%d"
+// articleKey is the hash of a cached article. Its summary field hold the
+// article without body, its body field hold the body, so articles can be read
+// without their body
+const articleKey = "20210207/articles/%d"
+
+// legacyArticleKeys match cached articles of previous cache layouts, which
+// are neither read nor expired
+var legacyArticleKeys = []string{"20210130/articles/*"}
+
+// Article cache hash fields
+const (
+	summaryField = "summary"
+	bodyField    = "body"
+)
+
+// articleBody represent the cached body of an article
+type articleBody struct {
+	Body     string `json:"body"`
+	BodyHTML string `json:"body_html"`
+}
 
 // ArticleCache represent article cache redis implementation
 type ArticleCache struct {
@@ -26,19 +45,31 @@ func NewArticleCache(redisClient *redis.Client) *ArticleCache {
 	}
 }
 
+// CheckHealth report whether the cache is reachable
+func (a *ArticleCache) CheckHealth(ctx context.Context) error {
+	return a.client.WithContext(ctx).Ping().Err()
+}
+
 // Cache write article to cache storage
 func (a *ArticleCache) Cache(ctx context.Context, article model.Article) error {
 	if article.ID == 0 {
 		return errors.New("article id is invalid")
 	}
 
+	jsonedBody, _ := json.Marshal(articleBody{Body: article.Body, BodyHTML: article.BodyHTML})
+
+	article.Body = ""
+	article.BodyHTML = ""
 	jsoned, _ := json.Marshal(article)
 
 	key := fmt.Sprintf(articleKey, article.ID)
-	err := a.client.Set(key, jsoned, 0).Err()
+	err := a.client.HMSet(key, map[string]interface{}{
+		summaryField: jsoned,
+		bodyField:    jsonedBody,
+	}).Err()
 	if err != nil {
-		log.Println(err)
-		return err
+		logger.FromContext(ctx).Error("article not cached", logger.ArticleID(article.ID), logger.Err(err))
+		return service.Unavailable(err)
 	}
 
 	return nil
@@ -53,17 +84,59 @@ func (a *ArticleCache) Get(ctx context.Context, id int) (model.Article, error) {
 	}
 
 	key := fmt.Sprintf(articleKey, id)
-	result, err := a.client.Get(key).Result()
-	if err == redis.Nil {
+	result, err := a.client.HMGet(key, summaryField, bodyField).Result()
+	if err != nil {
+		logger.FromContext(ctx).Error("cached article not retrieved", logger.ArticleID(id), logger.Err(err))
+		return article, service.Unavailable(err)
+	}
+
+	jsoned, ok := result[0].(string)
+	jsonedBody, bodyOk := result[1].(string)
+	if !ok || !bodyOk {
 		return article, service.ErrArticleNotFound
-	} else if err != nil {
-		log.Println(err)
+	}
+
+	article, err = unmarshalArticle(ctx, id, jsoned)
+	if err != nil {
 		return article, err
 	}
 
-	err = json.Unmarshal([]byte(result), &article)
+	var body articleBody
+	err = json.Unmarshal([]byte(jsonedBody), &body)
+	if err != nil {
+		logger.FromContext(ctx).Error("cached article not retrieved", logger.ArticleID(id), logger.Err(err))
+		return article, err
+	}
+
+	article.Body = body.Body
+	article.BodyHTML = body.BodyHTML
+	return article, nil
+}
+
+// GetSummary retrieve an article by id without its body from cache storage
+func (a *ArticleCache) GetSummary(ctx context.Context, id int) (model.Article, error) {
+	if id == 0 {
+		return model.Article{}, errors.New("id parameter is invalid")
+	}
+
+	key := fmt.Sprintf(articleKey, id)
+	result, err := a.client.HGet(key, summaryField).Result()
+	if err == redis.Nil {
+		return model.Article{}, service.ErrArticleNotFound
+	} else if err != nil {
+		logger.FromContext(ctx).Error("cached article summary not retrieved", logger.ArticleID(id), logger.Err(err))
+		return model.Article{}, service.Unavailable(err)
+	}
+
+	return unmarshalArticle(ctx, id, result)
+}
+
+func unmarshalArticle(ctx context.Context, id int, jsoned string) (model.Article, error) {
+	var article model.Article
+
+	err := json.Unmarshal([]byte(jsoned), &article)
 	if err != nil {
-		log.Println(err)
+		logger.FromContext(ctx).Error("cached article not decoded", logger.ArticleID(id), logger.Err(err))
 		return article, err
 	}
 
@@ -73,3 +146,37 @@ func (a *ArticleCache) Get(ctx context.Context, id int) (model.Article, error) {
 
 	return article, nil
 }
+
+// RemoveLegacy delete cached articles of previous cache layouts, returning the
+// number of deleted keys
+func (a *ArticleCache) RemoveLegacy(ctx context.Context) (int, error) {
+	client := a.client.WithContext(ctx)
+
+	removed := 0
+	for _, pattern := range legacyArticleKeys {
+		var cursor uint64
+		for {
+			keys, next, err := client.Scan(cursor, pattern, 1000).Result()
+			if err != nil {
+				logger.FromContext(ctx).Error("legacy cached articles not removed", logger.Err(err))
+				return removed, service.Unavailable(err)
+			}
+
+			if len(keys) > 0 {
+				deleted, err := client.Del(keys...).Result()
+				if err != nil {
+					logger.FromContext(ctx).Error("legacy cached articles not removed", logger.Err(err))
+					return removed, service.Unavailable(err)
+				}
+				removed += int(deleted)
+			}
+
+			cursor = next
+			if cursor == 0 {
+				break
+			}
+		}
+	}
+
+	return removed, nil
+}
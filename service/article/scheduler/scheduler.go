@@ -0,0 +1,82 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prabudzak/article/logger"
+	"github.com/prabudzak/article/service/policy"
+)
+
+// Publisher represent scheduled article publisher
+type Publisher interface {
+	PublishDueArticle(ctx context.Context, now time.Time) (int, error)
+}
+
+// Scheduler periodically publish scheduled articles which are due
+type Scheduler struct {
+	publisher Publisher
+	interval  time.Duration
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// NewScheduler create a new scheduler publishing due articles every interval
+func NewScheduler(publisher Publisher, interval time.Duration) *Scheduler {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	return &Scheduler{
+		publisher: publisher,
+		interval:  interval,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// Start run the scheduler in background until stopped
+func (s *Scheduler) Start() {
+	go s.start()
+}
+
+// Stop stop a started scheduler and wait until the running publish is done
+func (s *Scheduler) Stop() {
+	s.once.Do(func() {
+		close(s.stop)
+	})
+	<-s.done
+}
+
+func (s *Scheduler) start() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case now := <-ticker.C:
+			s.publish(now)
+		}
+	}
+}
+
+func (s *Scheduler) publish(now time.Time) {
+	ctx, cancel := context.WithTimeout(policy.WithSystem(context.Background()), s.interval)
+	defer cancel()
+
+	n, err := s.publisher.PublishDueArticle(ctx, now.UTC())
+	if err != nil {
+		logger.FromContext(ctx).Error("scheduled articles not published", logger.Err(err))
+	}
+
+	if n > 0 {
+		logger.FromContext(ctx).Info("scheduled articles published", logger.Int("count", n))
+	}
+}
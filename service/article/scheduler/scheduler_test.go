@@ -0,0 +1,50 @@
+package scheduler_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prabudzak/article/service/article/scheduler"
+	"github.com/stretchr/testify/assert"
+)
+
+type publisher struct {
+	mutex     sync.Mutex
+	callCount int
+}
+
+func (p *publisher) PublishDueArticle(ctx context.Context, now time.Time) (int, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.callCount++
+	return 1, nil
+}
+
+func (p *publisher) count() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.callCount
+}
+
+func TestScheduler(t *testing.T) {
+	pub := &publisher{}
+
+	s := scheduler.NewScheduler(pub, 2*time.Millisecond)
+	s.Start()
+
+	// wait until scheduler run a few times
+	time.Sleep(15 * time.Millisecond)
+	s.Stop()
+	s.Stop()
+
+	count := pub.count()
+	assert.True(t, count > 0)
+
+	// wait to make sure scheduler no longer run
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, count, pub.count())
+}
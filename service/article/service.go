@@ -3,11 +3,16 @@ package article
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
+	"github.com/prabudzak/article/diff"
 	"github.com/prabudzak/article/event"
 	"github.com/prabudzak/article/model"
+	"github.com/prabudzak/article/render"
 	"github.com/prabudzak/article/service"
+	"github.com/prabudzak/article/service/policy"
+	"github.com/prabudzak/article/validation"
 )
 
 //go:generate mockgen -package=mock -source=service.go -destination=mock/service.go
@@ -15,21 +20,39 @@ import (
 // Database represent article persistent storage
 type Database interface {
 	GenerateID(ctx context.Context) (int, error)
+	GenerateIDs(ctx context.Context, count int) ([]int, error)
 	Create(ctx context.Context, article model.Article) error
+	CreateBatch(ctx context.Context, articles []model.Article) error
+	Update(ctx context.Context, article model.Article) error
 	Get(ctx context.Context, id int) (model.Article, error)
+	ListScheduledDue(ctx context.Context, now time.Time, limit int) ([]int, error)
+	LastUpdated(ctx context.Context) (time.Time, error)
+	Iterate(ctx context.Context, query model.ArticleSearchQuery, fn func(article model.Article) error) error
+	ListRevisions(ctx context.Context, articleID int) ([]model.ArticleRevision, error)
+	GetRevision(ctx context.Context, articleID int, revision int) (model.ArticleRevision, error)
 }
 
 // Cache represent article cache storage
 type Cache interface {
 	Cache(ctx context.Context, article model.Article) error
 	Get(ctx context.Context, id int) (model.Article, error)
+	GetSummary(ctx context.Context, id int) (model.Article, error)
 }
 
 // Indexer represent article indexer
 type Indexer interface {
 	Index(ctx context.Context, article model.Article) error
+	IndexBatch(ctx context.Context, articles []model.Article) ([]error, error)
 	Remove(ctx context.Context, id int) error
 	Search(ctx context.Context, query model.ArticleSearchQuery) ([]int, error)
+	Related(ctx context.Context, query model.ArticleRelatedQuery) ([]int, error)
+	CountTags(ctx context.Context, query model.TagQuery) ([]model.TagCount, error)
+}
+
+// AuthorResolver represent article author lookup
+type AuthorResolver interface {
+	GetAuthor(ctx context.Context, handle string) (model.Author, error)
+	GetOrCreateAuthor(ctx context.Context, name string) (model.Author, error)
 }
 
 // Service represent article service implementation
@@ -37,68 +60,712 @@ type Service struct {
 	database Database
 	cache    Cache
 	indexer  Indexer
+	author   AuthorResolver
 }
 
 // NewArticleService create a new article service instance
-func NewArticleService(database Database, cache Cache, indexer Indexer) *Service {
+func NewArticleService(database Database, cache Cache, indexer Indexer, author AuthorResolver) *Service {
 	return &Service{
 		database: database,
 		cache:    cache,
 		indexer:  indexer,
+		author:   author,
 	}
 }
 
+// scheduledBatchSize is the maximum number of due scheduled articles published
+// in a single run
+const scheduledBatchSize = 100
+
+// maxImportSize is the maximum number of articles imported in a single call
+const maxImportSize = 1000
+
+// reindexBatchSize is the number of articles written to the index per bulk
+// request when reindexing
+const reindexBatchSize = 500
+
 // CreateArticle write a new article and dispatch article created event if
-// successfully written
-func (s *Service) CreateArticle(ctx context.Context, article model.Article) error {
-	if article.Author == "" {
-		return errors.New("article author is blank")
+// successfully written. Article without status is published immediately
+func (s *Service) CreateArticle(ctx context.Context, article model.Article) (model.Article, error) {
+	article, err := newArticle(article)
+	if err != nil {
+		return model.Article{}, err
+	}
+
+	// authors own the articles of the author they are bound to. The author is
+	// only created once the caller is allowed to create the article
+	article.AuthorID, err = s.existingAuthorID(ctx, article.Author)
+	if err != nil {
+		return model.Article{}, err
 	}
-	if article.Title == "" {
-		return errors.New("article title is blank")
+
+	err = policy.Authorize(ctx, policy.ActionCreateArticle, article)
+	if err != nil {
+		return model.Article{}, err
 	}
-	if article.Body == "" {
-		return errors.New("article body is blank")
+
+	author, err := s.author.GetOrCreateAuthor(ctx, article.Author)
+	if err != nil {
+		return model.Article{}, err
 	}
 
 	id, err := s.database.GenerateID(ctx)
 	if err != nil {
-		return err
+		return model.Article{}, err
 	}
 
-	article.ID = id
-	article.CreatedAt = time.Now().UTC()
+	article = assignArticle(article, author, id, time.Now().UTC())
 
 	err = s.indexer.Index(ctx, article)
 	if err != nil {
-		return err
+		return model.Article{}, err
 	}
 
 	err = s.database.Create(ctx, article)
 	if err != nil {
 		event.Dispatch(ctx, event.ArticleCreateFailed{Article: article})
-		return err
+		return model.Article{}, err
 	}
 
 	event.Dispatch(ctx, event.ArticleCreated{Article: article})
-	return nil
+	return article, nil
+}
+
+// existingAuthorID return the id of the author of given name, or zero when no
+// such author exist yet
+func (s *Service) existingAuthorID(ctx context.Context, name string) (int, error) {
+	author, err := s.author.GetAuthor(ctx, name)
+	if errors.Is(err, service.ErrAuthorNotFound) {
+		return 0, nil
+	}
+	return author.ID, err
+}
+
+// ImportArticles write many new articles at once, allocating their ids in a
+// single block, then indexing and writing them in bulk. Each article is
+// imported or rejected on its own, reported by the result at its position
+func (s *Service) ImportArticles(ctx context.Context, articles []model.Article) ([]model.ArticleImportResult, error) {
+	if len(articles) > maxImportSize {
+		return nil, service.InvalidArgument(fmt.Errorf("import exceed %d articles", maxImportSize))
+	}
+
+	results := make([]model.ArticleImportResult, len(articles))
+	resolved := make([]model.Author, len(articles))
+	authors := map[string]model.Author{}
+	accepted := []int{}
+
+	for i, article := range articles {
+		article, err := newArticle(article)
+		results[i].Article = article
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+
+		handle := model.AuthorHandle(article.Author)
+		author, ok := authors[handle]
+		if ok {
+			article.AuthorID = author.ID
+		} else {
+			article.AuthorID, err = s.existingAuthorID(ctx, article.Author)
+			if err != nil {
+				results[i].Err = err
+				continue
+			}
+		}
+
+		err = policy.Authorize(ctx, policy.ActionCreateArticle, article)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+
+		if !ok {
+			author, err = s.author.GetOrCreateAuthor(ctx, article.Author)
+			if err != nil {
+				results[i].Err = err
+				continue
+			}
+			authors[handle] = author
+		}
+
+		resolved[i] = author
+		accepted = append(accepted, i)
+	}
+
+	if len(accepted) == 0 {
+		return results, nil
+	}
+
+	ids, err := s.database.GenerateIDs(ctx, len(accepted))
+	if err != nil {
+		failImport(results, accepted, err)
+		return results, nil
+	}
+
+	now := time.Now().UTC()
+	batch := make([]model.Article, 0, len(accepted))
+	for n, i := range accepted {
+		results[i].Article = assignArticle(results[i].Article, resolved[i], ids[n], now)
+		batch = append(batch, results[i].Article)
+	}
+
+	indexErrs, err := s.indexer.IndexBatch(ctx, batch)
+	if err != nil {
+		failImport(results, accepted, err)
+		return results, nil
+	}
+
+	indexed := make([]int, 0, len(accepted))
+	batch = batch[:0]
+	for n, i := range accepted {
+		if indexErrs[n] != nil {
+			results[i].Err = indexErrs[n]
+			continue
+		}
+
+		indexed = append(indexed, i)
+		batch = append(batch, results[i].Article)
+	}
+
+	if len(indexed) == 0 {
+		return results, nil
+	}
+
+	err = s.database.CreateBatch(ctx, batch)
+	if err != nil {
+		failImport(results, indexed, err)
+		for _, article := range batch {
+			event.Dispatch(ctx, event.ArticleCreateFailed{Article: article})
+		}
+		return results, nil
+	}
+
+	for _, article := range batch {
+		event.Dispatch(ctx, event.ArticleCreated{Article: article})
+	}
+
+	return results, nil
+}
+
+// newArticle validate a new article, default its status to published and
+// render its body
+func newArticle(article model.Article) (model.Article, error) {
+	err := validation.Article(article)
+	if err != nil {
+		return article, err
+	}
+
+	if article.Status == "" {
+		article.Status = model.ArticleStatusPublished
+	}
+
+	return render.Article(article), nil
+}
+
+// assignArticle assign author, id and creation time to a new article. A
+// published article is published at its creation time
+func assignArticle(article model.Article, author model.Author, id int, now time.Time) model.Article {
+	article.AuthorID = author.ID
+	article.Author = author.Name
+	article.AuthorHandle = author.Handle
+
+	article.ID = id
+	article.Version = 1
+	article.CreatedAt = now
+	article.UpdatedAt = now
+
+	if article.Status == model.ArticleStatusPublished {
+		publishAt := now
+		article.PublishAt = &publishAt
+	}
+
+	return article
+}
+
+// failImport reject imported articles at given positions with the same error
+func failImport(results []model.ArticleImportResult, positions []int, err error) {
+	for _, i := range positions {
+		results[i].Err = err
+	}
+}
+
+// GetArticle retrieve an article by id from database, so its version is the
+// one updates are checked against. Unpublished articles are not found unless
+// the principal of ctx may read them
+func (s *Service) GetArticle(ctx context.Context, id int) (model.Article, error) {
+	return s.getReadableArticle(ctx, id)
+}
+
+// UpdateArticle overwrite title, body, language and tags of an existing
+// article, then re-index and re-cache the article. Body format is kept unless
+// given. When article version is given, the update is rejected with version
+// conflict error unless it is the currently stored version
+func (s *Service) UpdateArticle(ctx context.Context, article model.Article) (model.Article, error) {
+	err := validation.ArticleContent(article)
+	if err != nil {
+		return article, err
+	}
+
+	current, err := s.database.Get(ctx, article.ID)
+	if err != nil {
+		return current, err
+	}
+
+	err = policy.Authorize(ctx, policy.ActionUpdateArticle, current)
+	if err != nil {
+		return model.Article{}, err
+	}
+
+	if article.Version != 0 && article.Version != current.Version {
+		return current, service.ErrArticleVersionConflict
+	}
+
+	current.Title = article.Title
+	current.Body = article.Body
+	if article.BodyFormat != "" {
+		current.BodyFormat = article.BodyFormat
+	}
+	current.Language = article.Language
+	current.Tags = article.Tags
+	current.UpdatedAt = time.Now().UTC()
+	current = render.Article(current)
+
+	err = s.database.Update(ctx, current)
+	if err != nil {
+		return current, err
+	}
+	current.Version++
+
+	err = s.indexer.Index(ctx, current)
+	if err != nil {
+		return current, err
+	}
+
+	event.Dispatch(ctx, event.ArticleUpdated{Article: current})
+	return current, nil
+}
+
+// PublishArticle publish a draft or scheduled article immediately and
+// dispatch article published event. Publishing a published article only
+// re-index the article
+func (s *Service) PublishArticle(ctx context.Context, id int) (model.Article, error) {
+	article, err := s.database.Get(ctx, id)
+	if err != nil {
+		return article, err
+	}
+
+	err = policy.Authorize(ctx, policy.ActionPublishArticle, article)
+	if err != nil {
+		return model.Article{}, err
+	}
+
+	if article.Status == model.ArticleStatusArchived {
+		return article, service.ErrArticleArchived
+	}
+
+	published := article.Status != model.ArticleStatusPublished
+	if published {
+		now := time.Now().UTC()
+		if article.Status != model.ArticleStatusScheduled || article.PublishAt == nil || article.PublishAt.After(now) {
+			article.PublishAt = &now
+		}
+		article.Status = model.ArticleStatusPublished
+		article.UpdatedAt = now
+
+		err = s.database.Update(ctx, article)
+		if err != nil {
+			return article, err
+		}
+		article.Version++
+	}
+
+	err = s.indexer.Index(ctx, article)
+	if err != nil {
+		return article, err
+	}
+
+	if published {
+		event.Dispatch(ctx, event.ArticlePublished{Article: article})
+	}
+	return article, nil
+}
+
+// ArchiveArticle withdraw an article from readers, keeping it stored, and
+// dispatch article archived event. Archived articles are neither searched nor
+// published again. Archiving an archived article only re-index the article
+func (s *Service) ArchiveArticle(ctx context.Context, id int) (model.Article, error) {
+	article, err := s.database.Get(ctx, id)
+	if err != nil {
+		return article, err
+	}
+
+	err = policy.Authorize(ctx, policy.ActionArchiveArticle, article)
+	if err != nil {
+		return model.Article{}, err
+	}
+
+	archived := article.Status != model.ArticleStatusArchived
+	if archived {
+		article.Status = model.ArticleStatusArchived
+		article.UpdatedAt = time.Now().UTC()
+
+		err = s.database.Update(ctx, article)
+		if err != nil {
+			return article, err
+		}
+		article.Version++
+	}
+
+	err = s.indexer.Index(ctx, article)
+	if err != nil {
+		return article, err
+	}
+
+	if archived {
+		event.Dispatch(ctx, event.ArticleArchived{Article: article})
+	}
+	return article, nil
 }
 
-// SearchArticle search list of article from given parameter
+// PublishDueArticle publish scheduled articles which publish time is at or
+// before the given time and return the number of published articles
+func (s *Service) PublishDueArticle(ctx context.Context, now time.Time) (int, error) {
+	ids, err := s.database.ListScheduledDue(ctx, now, scheduledBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var lastErr error
+	published := 0
+	for _, id := range ids {
+		_, err = s.PublishArticle(ctx, id)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		published++
+	}
+
+	return published, lastErr
+}
+
+// SearchArticle search list of article from given parameter. Only published
+// articles are searched unless query status is given. Article body is only
+// retrieved when query fields need it
 func (s *Service) SearchArticle(ctx context.Context, query model.ArticleSearchQuery) ([]model.Article, error) {
+	query.Author = model.AuthorHandle(query.Author)
+	if query.Status == "" {
+		query.Status = model.ArticleStatusPublished
+	}
+
 	ids, err := s.indexer.Search(ctx, query)
 	if err != nil {
 		return nil, err
 	}
 
+	return s.getCachedArticles(ctx, ids, needBody(query.Fields))
+}
+
+// needBody report whether any of given article fields is read from article
+// body. No field means every field
+func needBody(fields []string) bool {
+	if len(fields) == 0 {
+		return true
+	}
+
+	for _, field := range fields {
+		if field == "body" || field == "body_html" {
+			return true
+		}
+	}
+	return false
+}
+
+// ExportArticle stream every article matching the search query to fn, in id
+// order, straight from database. Only published articles are exported unless
+// query status is given, which require an editor. Query pagination is ignored
+func (s *Service) ExportArticle(ctx context.Context, query model.ArticleSearchQuery, fn func(article model.Article) error) error {
+	query.Author = model.AuthorHandle(query.Author)
+	if query.Status == "" {
+		query.Status = model.ArticleStatusPublished
+	}
+
+	// unpublished articles are exported to editors only, the articles of
+	// every author
+	if query.Status != model.ArticleStatusPublished {
+		err := policy.Authorize(ctx, policy.ActionReadArticle, model.Article{})
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.database.Iterate(ctx, query, fn)
+}
+
+// ReindexArticle write every stored article of every status to the index
+// again, in id order, so documents indexed before a change of the index
+// mapping carry every field queries filter on. It return the number of
+// reindexed articles, and stop at the first article the index reject
+func (s *Service) ReindexArticle(ctx context.Context) (int, error) {
+	err := policy.Authorize(ctx, policy.ActionReindex, model.Article{})
+	if err != nil {
+		return 0, err
+	}
+
+	reindexed := 0
+	batch := make([]model.Article, 0, reindexBatchSize)
+	flush := func() error {
+		errs, err := s.indexer.IndexBatch(ctx, batch)
+		if err != nil {
+			return err
+		}
+
+		for i, err := range errs {
+			if err != nil {
+				return fmt.Errorf("article %d not reindexed: %w", batch[i].ID, err)
+			}
+		}
+
+		reindexed += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	err = s.database.Iterate(ctx, model.ArticleSearchQuery{}, func(article model.Article) error {
+		batch = append(batch, article)
+		if len(batch) < reindexBatchSize {
+			return nil
+		}
+		return flush()
+	})
+	if err != nil {
+		return reindexed, err
+	}
+
+	if len(batch) > 0 {
+		err = flush()
+	}
+
+	return reindexed, err
+}
+
+// RelatedArticle search list of published article similar to the given
+// article, excluding the article itself
+func (s *Service) RelatedArticle(ctx context.Context, query model.ArticleRelatedQuery) ([]model.Article, error) {
+	source, err := s.getArticle(ctx, query.ArticleID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = readable(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+
+	query.Status = model.ArticleStatusPublished
+
+	query.Author = ""
+	if query.SameAuthor {
+		query.Author = source.AuthorHandle
+	}
+
+	ids, err := s.indexer.Related(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getCachedArticles(ctx, ids, true)
+}
+
+// LastModified return the latest update time of any article. Every change of
+// an article, including one making it enter or leave a list, advance it
+func (s *Service) LastModified(ctx context.Context) (time.Time, error) {
+	return s.database.LastUpdated(ctx)
+}
+
+// ListTag list article tags with the number of articles tagged by them
+func (s *Service) ListTag(ctx context.Context, query model.TagQuery) ([]model.TagCount, error) {
+	return s.indexer.CountTags(ctx, query)
+}
+
+// ListArticleRevision list all revisions of an article, the latest first
+func (s *Service) ListArticleRevision(ctx context.Context, id int) ([]model.ArticleRevision, error) {
+	_, err := s.getReadableArticle(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions, err := s.database.ListRevisions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(revisions) == 0 {
+		return nil, service.ErrArticleNotFound
+	}
+
+	return revisions, nil
+}
+
+// GetArticleRevision retrieve a revision of an article
+func (s *Service) GetArticleRevision(ctx context.Context, id int, revision int) (model.ArticleRevision, error) {
+	_, err := s.getReadableArticle(ctx, id)
+	if err != nil {
+		return model.ArticleRevision{}, err
+	}
+
+	return s.database.GetRevision(ctx, id, revision)
+}
+
+// DiffArticleRevision produce unified diff from one article revision to
+// another. Revision 0 represent the empty article before its first revision
+func (s *Service) DiffArticleRevision(ctx context.Context, id int, from int, to int) (model.ArticleRevisionDiff, error) {
+	result := model.ArticleRevisionDiff{ArticleID: id, From: from, To: to}
+
+	_, err := s.getReadableArticle(ctx, id)
+	if err != nil {
+		return result, err
+	}
+
+	toRevision, err := s.database.GetRevision(ctx, id, to)
+	if err != nil {
+		return result, err
+	}
+
+	fromText := ""
+	if from > 0 {
+		fromRevision, err := s.database.GetRevision(ctx, id, from)
+		if err != nil {
+			return result, err
+		}
+		fromText = fromRevision.Text()
+	}
+
+	result.Diff = diff.Unified(
+		fmt.Sprintf("article/%d/revision/%d", id, from),
+		fmt.Sprintf("article/%d/revision/%d", id, to),
+		fromText,
+		toRevision.Text(),
+	)
+	return result, nil
+}
+
+// RestoreArticleRevision overwrite article content with the content of one of
+// its revision, written as a new revision, then re-index and re-cache the
+// article. Article status and author are kept as is
+func (s *Service) RestoreArticleRevision(ctx context.Context, id int, revision int) (model.Article, error) {
+	article, err := s.database.Get(ctx, id)
+	if err != nil {
+		return article, err
+	}
+
+	err = policy.Authorize(ctx, policy.ActionRestoreArticle, article)
+	if err != nil {
+		return model.Article{}, err
+	}
+
+	restored, err := s.database.GetRevision(ctx, id, revision)
+	if err != nil {
+		return article, err
+	}
+
+	article.Title = restored.Title
+	article.Body = restored.Body
+	article.BodyFormat = restored.BodyFormat
+	article.Language = restored.Language
+	article.Tags = restored.Tags
+	article.UpdatedAt = time.Now().UTC()
+	article = render.Article(article)
+
+	err = s.database.Update(ctx, article)
+	if err != nil {
+		return article, err
+	}
+	article.Version++
+
+	err = s.indexer.Index(ctx, article)
+	if err != nil {
+		return article, err
+	}
+
+	event.Dispatch(ctx, event.ArticleUpdated{Article: article})
+	return article, nil
+}
+
+// getArticle retrieve an article from cache, falling back to database when
+// the article is not cached yet
+func (s *Service) getArticle(ctx context.Context, id int) (model.Article, error) {
+	article, err := s.cache.Get(ctx, id)
+	if err == nil {
+		return article, nil
+	}
+
+	article, err = s.database.Get(ctx, id)
+	if err != nil {
+		return article, err
+	}
+
+	event.Dispatch(ctx, event.ArticleCreated{Article: article})
+	return article, nil
+}
+
+// getReadableArticle retrieve an article by id from database, unless it is
+// unpublished and the principal of ctx may not read it
+func (s *Service) getReadableArticle(ctx context.Context, id int) (model.Article, error) {
+	article, err := s.database.Get(ctx, id)
+	if err != nil {
+		return model.Article{}, err
+	}
+
+	err = readable(ctx, article)
+	if err != nil {
+		return model.Article{}, err
+	}
+
+	return article, nil
+}
+
+// readable return article not found error unless article is published or the
+// principal of ctx may read it, so unpublished articles are not disclosed to
+// anyone else, not even their existence
+func readable(ctx context.Context, article model.Article) error {
+	if article.Status == model.ArticleStatusPublished {
+		return nil
+	}
+
+	if policy.Authorize(ctx, policy.ActionReadArticle, article) != nil {
+		return service.ErrArticleNotFound
+	}
+
+	return nil
+}
+
+// getCachedArticles retrieve articles by ids from cache, with or without their
+// body, skipping and dispatching article not found event for articles missing
+// from cache. Articles are read from database while the cache fails, so an
+// unavailable cache slow lists down rather than empty them
+func (s *Service) getCachedArticles(ctx context.Context, ids []int, withBody bool) ([]model.Article, error) {
+	get := s.cache.Get
+	if !withBody {
+		get = s.cache.GetSummary
+	}
+
 	articles := []model.Article{}
 	for _, id := range ids {
-		article, err := s.cache.Get(ctx, id)
-		if err == service.ErrArticleNotFound {
+		article, err := get(ctx, id)
+		if errors.Is(err, service.ErrArticleNotFound) {
 			event.Dispatch(ctx, event.ArticleNotFound{ArticleID: id})
 			continue
 		} else if err != nil {
+			article, err = s.database.Get(ctx, id)
+		}
+
+		if errors.Is(err, service.ErrArticleNotFound) {
 			continue
+		} else if err != nil {
+			return nil, err
 		}
 
 		articles = append(articles, article)
@@ -134,6 +801,12 @@ func (s *Service) SubscriberCacheArticle(ctx context.Context, e event.Event) err
 	switch message := e.(type) {
 	case event.ArticleCreated:
 		article = message.Article
+	case event.ArticlePublished:
+		article = message.Article
+	case event.ArticleArchived:
+		article = message.Article
+	case event.ArticleUpdated:
+		article = message.Article
 	default:
 		return errors.New("subscribed to unprocessable event")
 	}
@@ -2,14 +2,17 @@ package article_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/prabudzak/article/model"
 	"github.com/prabudzak/article/service"
 	"github.com/prabudzak/article/service/article"
 	"github.com/prabudzak/article/service/article/mock"
+	"github.com/prabudzak/article/service/policy"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -17,6 +20,7 @@ type dependency struct {
 	database *mock.MockDatabase
 	cache    *mock.MockCache
 	indexer  *mock.MockIndexer
+	author   *mock.MockAuthorResolver
 }
 
 func initialize(ctrl *gomock.Controller) dependency {
@@ -24,13 +28,17 @@ func initialize(ctrl *gomock.Controller) dependency {
 		database: mock.NewMockDatabase(ctrl),
 		cache:    mock.NewMockCache(ctrl),
 		indexer:  mock.NewMockIndexer(ctrl),
+		author:   mock.NewMockAuthorResolver(ctrl),
 	}
 }
 
 func TestCreateArticle(t *testing.T) {
+	publishAt := time.Now().Add(time.Hour)
+
 	tests := []struct {
 		name            string
 		article         model.Article
+		getAuthorErr    error
 		dbGenerateIDErr error
 		indexErr        error
 		dbCreateErr     error
@@ -70,6 +78,57 @@ func TestCreateArticle(t *testing.T) {
 			},
 			expectError: true,
 		},
+		{
+			name: "success, as draft",
+			article: model.Article{
+				Author: "John Doe",
+				Title:  "A Valid Title",
+				Body:   "A very interesting content",
+				Status: model.ArticleStatusDraft,
+			},
+			expectError: false,
+		},
+		{
+			name: "success, scheduled",
+			article: model.Article{
+				Author:    "John Doe",
+				Title:     "A Valid Title",
+				Body:      "A very interesting content",
+				Status:    model.ArticleStatusScheduled,
+				PublishAt: &publishAt,
+			},
+			expectError: false,
+		},
+		{
+			name: "scheduled without publish time",
+			article: model.Article{
+				Author: "John Doe",
+				Title:  "A Valid Title",
+				Body:   "A very interesting content",
+				Status: model.ArticleStatusScheduled,
+			},
+			expectError: true,
+		},
+		{
+			name: "invalid status",
+			article: model.Article{
+				Author: "John Doe",
+				Title:  "A Valid Title",
+				Body:   "A very interesting content",
+				Status: "deleted",
+			},
+			expectError: true,
+		},
+		{
+			name: "unable to resolve article author",
+			article: model.Article{
+				Author: "John Doe",
+				Title:  "A Valid Title",
+				Body:   "A very interesting content",
+			},
+			getAuthorErr: assert.AnError,
+			expectError:  true,
+		},
 		{
 			name: "unable to assign article id",
 			article: model.Article{
@@ -108,25 +167,387 @@ func TestCreateArticle(t *testing.T) {
 			defer ctrl.Finish()
 
 			dep := initialize(ctrl)
+			dep.author.EXPECT().GetAuthor(gomock.Any(), tc.article.Author).AnyTimes().Return(model.Author{ID: 7, Handle: "john-doe", Name: "John Doe"}, nil)
+			dep.author.EXPECT().GetOrCreateAuthor(gomock.Any(), tc.article.Author).AnyTimes().Return(model.Author{ID: 7, Handle: "john-doe", Name: "John Doe"}, tc.getAuthorErr)
 			dep.database.EXPECT().GenerateID(gomock.Any()).AnyTimes().Return(123, tc.dbGenerateIDErr)
 			dep.indexer.EXPECT().Index(gomock.Any(), gomock.Any()).AnyTimes().Return(tc.indexErr)
 			dep.database.EXPECT().Create(gomock.Any(), gomock.Any()).AnyTimes().Return(tc.dbCreateErr)
 
-			articleService := article.NewArticleService(dep.database, dep.cache, dep.indexer)
+			articleService := article.NewArticleService(dep.database, dep.cache, dep.indexer, dep.author)
 
-			err := articleService.CreateArticle(context.Background(), tc.article)
+			created, err := articleService.CreateArticle(policy.WithSystem(context.Background()), tc.article)
 			assert.Equal(t, tc.expectError, err != nil)
+			if !tc.expectError {
+				assert.Equal(t, 123, created.ID)
+				assert.Equal(t, 7, created.AuthorID)
+				assert.Equal(t, "john-doe", created.AuthorHandle)
+				assert.Equal(t, 1, created.Version)
+				assert.False(t, created.CreatedAt.IsZero())
+			}
 		})
 	}
 
 }
 
+func TestCreateArticleBodyFormat(t *testing.T) {
+	tests := []struct {
+		name               string
+		bodyFormat         string
+		body               string
+		expectedBodyFormat string
+		expectedBodyHTML   string
+	}{
+		{
+			name:               "plain by default",
+			body:               "a <b>c</b>",
+			expectedBodyFormat: model.BodyFormatPlain,
+			expectedBodyHTML:   "<p>a &lt;b&gt;c&lt;/b&gt;</p>\n",
+		},
+		{
+			name:               "markdown rendered",
+			bodyFormat:         model.BodyFormatMarkdown,
+			body:               "# Title\n\n**bold**",
+			expectedBodyFormat: model.BodyFormatMarkdown,
+			expectedBodyHTML:   "<h1>Title</h1>\n<p><strong>bold</strong></p>\n",
+		},
+		{
+			name:               "html sanitized",
+			bodyFormat:         model.BodyFormatHTML,
+			body:               "<p onclick=\"x()\">text</p><script>x()</script>",
+			expectedBodyFormat: model.BodyFormatHTML,
+			expectedBodyHTML:   "<p>text</p>",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dep := initialize(ctrl)
+			dep.author.EXPECT().GetAuthor(gomock.Any(), "John Doe").Return(model.Author{}, service.ErrAuthorNotFound)
+			dep.author.EXPECT().GetOrCreateAuthor(gomock.Any(), "John Doe").Return(model.Author{ID: 7, Handle: "john-doe", Name: "John Doe"}, nil)
+			dep.database.EXPECT().GenerateID(gomock.Any()).Return(123, nil)
+			dep.indexer.EXPECT().Index(gomock.Any(), gomock.Any()).Return(nil)
+			dep.database.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, a model.Article) error {
+				assert.Equal(t, tc.expectedBodyHTML, a.BodyHTML)
+				return nil
+			})
+
+			articleService := article.NewArticleService(dep.database, dep.cache, dep.indexer, dep.author)
+
+			created, err := articleService.CreateArticle(policy.WithSystem(context.Background()), model.Article{
+				Author:     "John Doe",
+				Title:      "A Valid Title",
+				Body:       tc.body,
+				BodyFormat: tc.bodyFormat,
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, tc.body, created.Body)
+			assert.Equal(t, tc.expectedBodyFormat, created.BodyFormat)
+			assert.Equal(t, tc.expectedBodyHTML, created.BodyHTML)
+		})
+	}
+}
+
+func TestImportArticles(t *testing.T) {
+	valid := func(author string) model.Article {
+		return model.Article{
+			Author: author,
+			Title:  "A Valid Title",
+			Body:   "A very interesting content",
+		}
+	}
+
+	tests := []struct {
+		name             string
+		articles         []model.Article
+		getAuthorErr     error
+		dbGenerateIDsErr error
+		indexErrs        []error
+		indexErr         error
+		dbCreateErr      error
+		expectedIDs      []int
+		expectedFailed   []bool
+		expectErr        bool
+	}{
+		{
+			name:           "all articles imported",
+			articles:       []model.Article{valid("John Doe"), valid("John Doe"), valid("Jane Doe")},
+			indexErrs:      []error{nil, nil, nil},
+			expectedIDs:    []int{100, 101, 102},
+			expectedFailed: []bool{false, false, false},
+		},
+		{
+			name:           "invalid article rejected alone",
+			articles:       []model.Article{valid("John Doe"), {Author: "John Doe"}, valid("Jane Doe")},
+			indexErrs:      []error{nil, nil},
+			expectedIDs:    []int{100, 0, 101},
+			expectedFailed: []bool{false, true, false},
+		},
+		{
+			name:           "article failed to index rejected alone",
+			articles:       []model.Article{valid("John Doe"), valid("Jane Doe")},
+			indexErrs:      []error{assert.AnError, nil},
+			expectedIDs:    []int{100, 101},
+			expectedFailed: []bool{true, false},
+		},
+		{
+			name:           "unable to resolve author",
+			articles:       []model.Article{valid("John Doe")},
+			getAuthorErr:   assert.AnError,
+			expectedIDs:    []int{0},
+			expectedFailed: []bool{true},
+		},
+		{
+			name:             "unable to generate ids",
+			articles:         []model.Article{valid("John Doe"), valid("Jane Doe")},
+			dbGenerateIDsErr: assert.AnError,
+			expectedIDs:      []int{0, 0},
+			expectedFailed:   []bool{true, true},
+		},
+		{
+			name:           "unable to bulk index",
+			articles:       []model.Article{valid("John Doe"), valid("Jane Doe")},
+			indexErr:       assert.AnError,
+			expectedIDs:    []int{100, 101},
+			expectedFailed: []bool{true, true},
+		},
+		{
+			name:           "unable to write articles to database",
+			articles:       []model.Article{valid("John Doe"), valid("Jane Doe")},
+			indexErrs:      []error{nil, nil},
+			dbCreateErr:    assert.AnError,
+			expectedIDs:    []int{100, 101},
+			expectedFailed: []bool{true, true},
+		},
+		{
+			name:      "too many articles",
+			articles:  make([]model.Article, 1001),
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dep := initialize(ctrl)
+			dep.author.EXPECT().GetAuthor(gomock.Any(), gomock.Any()).AnyTimes().Return(model.Author{}, service.ErrAuthorNotFound)
+			dep.author.EXPECT().GetOrCreateAuthor(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+				func(ctx context.Context, name string) (model.Author, error) {
+					return model.Author{ID: len(name), Name: name, Handle: model.AuthorHandle(name)}, tc.getAuthorErr
+				})
+			dep.database.EXPECT().GenerateIDs(gomock.Any(), gomock.Any()).MaxTimes(1).DoAndReturn(
+				func(ctx context.Context, count int) ([]int, error) {
+					ids := []int{}
+					for i := 0; i < count; i++ {
+						ids = append(ids, 100+i)
+					}
+					return ids, tc.dbGenerateIDsErr
+				})
+			dep.indexer.EXPECT().IndexBatch(gomock.Any(), gomock.Any()).MaxTimes(1).Return(tc.indexErrs, tc.indexErr)
+			dep.database.EXPECT().CreateBatch(gomock.Any(), gomock.Any()).MaxTimes(1).DoAndReturn(
+				func(ctx context.Context, articles []model.Article) error {
+					for _, article := range articles {
+						assert.NotZero(t, article.ID)
+						assert.NotZero(t, article.AuthorID)
+						assert.Equal(t, model.ArticleStatusPublished, article.Status)
+					}
+					return tc.dbCreateErr
+				})
+
+			articleService := article.NewArticleService(dep.database, dep.cache, dep.indexer, dep.author)
+
+			results, err := articleService.ImportArticles(policy.WithSystem(context.Background()), tc.articles)
+			assert.Equal(t, tc.expectErr, err != nil)
+			if tc.expectErr {
+				return
+			}
+
+			assert.Len(t, results, len(tc.articles))
+			for i, result := range results {
+				assert.Equal(t, tc.expectedIDs[i], result.Article.ID, "article %d", i)
+				assert.Equal(t, tc.expectedFailed[i], result.Err != nil, "article %d", i)
+			}
+		})
+	}
+}
+
+func TestExportArticle(t *testing.T) {
+	tests := []struct {
+		name          string
+		principal     *model.Principal
+		query         model.ArticleSearchQuery
+		dbIterateErr  error
+		expectedQuery model.ArticleSearchQuery
+		expectedIDs   []int
+		expectErr     bool
+	}{
+		{
+			name:          "published articles exported by default",
+			query:         model.ArticleSearchQuery{Author: "John Doe"},
+			expectedQuery: model.ArticleSearchQuery{Author: "john-doe", Status: model.ArticleStatusPublished},
+			expectedIDs:   []int{1, 2},
+		},
+		{
+			name:          "articles of given status exported to editor",
+			principal:     &model.Principal{Name: "Jane Roe", Role: model.RoleEditor},
+			query:         model.ArticleSearchQuery{Status: model.ArticleStatusDraft},
+			expectedQuery: model.ArticleSearchQuery{Status: model.ArticleStatusDraft},
+			expectedIDs:   []int{1, 2},
+		},
+		{
+			name:        "draft articles not exported to author",
+			principal:   &model.Principal{Name: "John Doe", Role: model.RoleAuthor, AuthorID: 1},
+			query:       model.ArticleSearchQuery{Status: model.ArticleStatusDraft},
+			expectedIDs: []int{},
+			expectErr:   true,
+		},
+		{
+			name:        "draft articles not exported without principal",
+			query:       model.ArticleSearchQuery{Status: model.ArticleStatusDraft},
+			expectedIDs: []int{},
+			expectErr:   true,
+		},
+		{
+			name:          "unable to iterate articles",
+			dbIterateErr:  assert.AnError,
+			expectedQuery: model.ArticleSearchQuery{Status: model.ArticleStatusPublished},
+			expectedIDs:   []int{1, 2},
+			expectErr:     true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dep := initialize(ctrl)
+			dep.database.EXPECT().Iterate(gomock.Any(), tc.expectedQuery, gomock.Any()).MaxTimes(1).DoAndReturn(
+				func(ctx context.Context, query model.ArticleSearchQuery, fn func(model.Article) error) error {
+					fn(model.Article{ID: 1})
+					fn(model.Article{ID: 2})
+					return tc.dbIterateErr
+				})
+
+			articleService := article.NewArticleService(dep.database, dep.cache, dep.indexer, dep.author)
+
+			ctx := context.Background()
+			if tc.principal != nil {
+				ctx = service.WithPrincipal(ctx, *tc.principal)
+			}
+
+			ids := []int{}
+			err := articleService.ExportArticle(ctx, tc.query, func(article model.Article) error {
+				ids = append(ids, article.ID)
+				return nil
+			})
+			assert.Equal(t, tc.expectErr, err != nil)
+			assert.Equal(t, tc.expectedIDs, ids)
+		})
+	}
+}
+
+func TestReindexArticle(t *testing.T) {
+	tests := []struct {
+		name              string
+		ctx               context.Context
+		stored            int
+		dbIterateErr      error
+		indexBatchErr     error
+		indexItemErr      error
+		expectedBatches   int
+		expectedReindexed int
+		expectErr         bool
+	}{
+		{
+			name:              "every article reindexed in batches",
+			ctx:               policy.WithSystem(context.Background()),
+			stored:            1001,
+			expectedBatches:   3,
+			expectedReindexed: 1001,
+		},
+		{
+			name:            "no article",
+			ctx:             policy.WithSystem(context.Background()),
+			expectedBatches: 0,
+		},
+		{
+			name:      "editor may not reindex",
+			ctx:       service.WithPrincipal(context.Background(), model.Principal{Role: model.RoleEditor}),
+			stored:    10,
+			expectErr: true,
+		},
+		{
+			name:              "unable to iterate articles",
+			ctx:               policy.WithSystem(context.Background()),
+			stored:            600,
+			dbIterateErr:      assert.AnError,
+			expectedBatches:   1,
+			expectedReindexed: 500,
+			expectErr:         true,
+		},
+		{
+			name:            "unable to index articles",
+			ctx:             policy.WithSystem(context.Background()),
+			stored:          10,
+			indexBatchErr:   assert.AnError,
+			expectedBatches: 1,
+			expectErr:       true,
+		},
+		{
+			name:            "article rejected by index",
+			ctx:             policy.WithSystem(context.Background()),
+			stored:          10,
+			indexItemErr:    errors.New("mapper_parsing_exception: failed to parse"),
+			expectedBatches: 1,
+			expectErr:       true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dep := initialize(ctrl)
+			dep.database.EXPECT().Iterate(gomock.Any(), model.ArticleSearchQuery{}, gomock.Any()).MaxTimes(1).DoAndReturn(
+				func(ctx context.Context, query model.ArticleSearchQuery, fn func(model.Article) error) error {
+					for id := 1; id <= tc.stored; id++ {
+						err := fn(model.Article{ID: id, Status: model.ArticleStatusDraft})
+						if err != nil {
+							return err
+						}
+					}
+					return tc.dbIterateErr
+				})
+			dep.indexer.EXPECT().IndexBatch(gomock.Any(), gomock.Any()).Times(tc.expectedBatches).DoAndReturn(
+				func(ctx context.Context, articles []model.Article) ([]error, error) {
+					assert.LessOrEqual(t, len(articles), 500)
+					errs := make([]error, len(articles))
+					errs[len(errs)-1] = tc.indexItemErr
+					return errs, tc.indexBatchErr
+				})
+
+			articleService := article.NewArticleService(dep.database, dep.cache, dep.indexer, dep.author)
+
+			reindexed, err := articleService.ReindexArticle(tc.ctx)
+			assert.Equal(t, tc.expectErr, err != nil)
+			assert.Equal(t, tc.expectedReindexed, reindexed)
+		})
+	}
+}
+
 func TestSearchArticle(t *testing.T) {
 	tests := []struct {
 		name                   string
 		indexSearchArticleIDs  []int
 		indexSearchErr         error
 		cacheGetErr            error
+		dbGetErr               error
 		expectedArticlesLength int
 		expectErr              bool
 	}{
@@ -149,12 +570,19 @@ func TestSearchArticle(t *testing.T) {
 			expectErr:             true,
 		},
 		{
-			name:                   "not all indexed article returned, cache error",
+			name:                   "all indexed article returned from database, cache error",
 			indexSearchArticleIDs:  []int{1, 2, 3},
 			cacheGetErr:            assert.AnError,
-			expectedArticlesLength: 0,
+			expectedArticlesLength: 3,
 			expectErr:              false,
 		},
+		{
+			name:                  "unable to get articles, cache and database error",
+			indexSearchArticleIDs: []int{1, 2, 3},
+			cacheGetErr:           assert.AnError,
+			dbGetErr:              assert.AnError,
+			expectErr:             true,
+		},
 	}
 
 	for _, tc := range tests {
@@ -163,7 +591,7 @@ func TestSearchArticle(t *testing.T) {
 			defer ctrl.Finish()
 
 			dep := initialize(ctrl)
-			dep.indexer.EXPECT().Search(gomock.Any(), gomock.Any()).AnyTimes().Return(tc.indexSearchArticleIDs, tc.indexSearchErr)
+			dep.indexer.EXPECT().Search(gomock.Any(), model.ArticleSearchQuery{Status: model.ArticleStatusPublished}).AnyTimes().Return(tc.indexSearchArticleIDs, tc.indexSearchErr)
 			dep.cache.EXPECT().Get(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(func(ctx context.Context, id int) (model.Article, error) {
 				// simulate  condition all article with id > 100 not found
 				if id > 100 {
@@ -177,8 +605,11 @@ func TestSearchArticle(t *testing.T) {
 					Author: fmt.Sprintf("author%d", id),
 				}, tc.cacheGetErr
 			})
+			dep.database.EXPECT().Get(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(func(ctx context.Context, id int) (model.Article, error) {
+				return model.Article{ID: id, Title: fmt.Sprintf("title %d", id)}, tc.dbGetErr
+			})
 
-			articleService := article.NewArticleService(dep.database, dep.cache, dep.indexer)
+			articleService := article.NewArticleService(dep.database, dep.cache, dep.indexer, dep.author)
 
 			articles, err := articleService.SearchArticle(context.Background(), model.ArticleSearchQuery{})
 			assert.Equal(t, tc.expectErr, err != nil)
@@ -186,3 +617,795 @@ func TestSearchArticle(t *testing.T) {
 		})
 	}
 }
+
+func TestSearchArticleFields(t *testing.T) {
+	tests := []struct {
+		name         string
+		fields       []string
+		expectedBody string
+	}{
+		{
+			name:         "whole articles",
+			expectedBody: "body",
+		},
+		{
+			name:         "fields with body",
+			fields:       []string{"id", "body"},
+			expectedBody: "body",
+		},
+		{
+			name:   "fields without body",
+			fields: []string{"id", "title"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dep := initialize(ctrl)
+			dep.indexer.EXPECT().Search(gomock.Any(), gomock.Any()).Return([]int{1}, nil)
+			dep.cache.EXPECT().Get(gomock.Any(), 1).AnyTimes().Return(model.Article{ID: 1, Title: "title", Body: "body"}, nil)
+			dep.cache.EXPECT().GetSummary(gomock.Any(), 1).AnyTimes().Return(model.Article{ID: 1, Title: "title"}, nil)
+
+			articleService := article.NewArticleService(dep.database, dep.cache, dep.indexer, dep.author)
+
+			articles, err := articleService.SearchArticle(context.Background(), model.ArticleSearchQuery{Fields: tc.fields})
+			assert.NoError(t, err)
+			assert.Len(t, articles, 1)
+			assert.Equal(t, tc.expectedBody, articles[0].Body)
+		})
+	}
+}
+
+func TestRelatedArticle(t *testing.T) {
+	tests := []struct {
+		name                   string
+		query                  model.ArticleRelatedQuery
+		cacheGetSourceErr      error
+		dbGetSourceErr         error
+		indexRelatedArticleIDs []int
+		indexRelatedErr        error
+		expectedAuthor         string
+		expectedArticlesLength int
+		expectErr              bool
+	}{
+		{
+			name:                   "related articles returned",
+			query:                  model.ArticleRelatedQuery{ArticleID: 1},
+			indexRelatedArticleIDs: []int{2, 3, 4},
+			expectedArticlesLength: 3,
+			expectErr:              false,
+		},
+		{
+			name:                   "related articles returned, restricted to same author",
+			query:                  model.ArticleRelatedQuery{ArticleID: 1, SameAuthor: true},
+			indexRelatedArticleIDs: []int{2, 3},
+			expectedAuthor:         "author1",
+			expectedArticlesLength: 2,
+			expectErr:              false,
+		},
+		{
+			name:                   "related articles returned, source article retrieved from database",
+			query:                  model.ArticleRelatedQuery{ArticleID: 1},
+			cacheGetSourceErr:      service.ErrArticleNotFound,
+			indexRelatedArticleIDs: []int{2, 3},
+			expectedArticlesLength: 2,
+			expectErr:              false,
+		},
+		{
+			name:                   "not all related article returned, article not found in cache",
+			query:                  model.ArticleRelatedQuery{ArticleID: 1},
+			indexRelatedArticleIDs: []int{2, 300},
+			expectedArticlesLength: 1,
+			expectErr:              false,
+		},
+		{
+			name:              "source article not found",
+			query:             model.ArticleRelatedQuery{ArticleID: 1},
+			cacheGetSourceErr: service.ErrArticleNotFound,
+			dbGetSourceErr:    service.ErrArticleNotFound,
+			expectErr:         true,
+		},
+		{
+			name:            "unable to search related articles",
+			query:           model.ArticleRelatedQuery{ArticleID: 1},
+			indexRelatedErr: assert.AnError,
+			expectErr:       true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dep := initialize(ctrl)
+			dep.database.EXPECT().Get(gomock.Any(), tc.query.ArticleID).AnyTimes().Return(model.Article{
+				ID:           tc.query.ArticleID,
+				Author:       fmt.Sprintf("author%d", tc.query.ArticleID),
+				AuthorHandle: fmt.Sprintf("author%d", tc.query.ArticleID),
+				Status:       model.ArticleStatusPublished,
+			}, tc.dbGetSourceErr)
+			dep.cache.EXPECT().Get(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(func(ctx context.Context, id int) (model.Article, error) {
+				if id == tc.query.ArticleID && tc.cacheGetSourceErr != nil {
+					return model.Article{}, tc.cacheGetSourceErr
+				}
+
+				// simulate  condition all article with id > 100 not found
+				if id > 100 {
+					return model.Article{}, service.ErrArticleNotFound
+				}
+
+				return model.Article{
+					ID:           id,
+					Title:        fmt.Sprintf("title %d", id),
+					Body:         fmt.Sprintf("body %d", id),
+					Author:       fmt.Sprintf("author%d", id),
+					AuthorHandle: fmt.Sprintf("author%d", id),
+					Status:       model.ArticleStatusPublished,
+				}, nil
+			})
+			dep.indexer.EXPECT().Related(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(func(ctx context.Context, query model.ArticleRelatedQuery) ([]int, error) {
+				assert.Equal(t, tc.expectedAuthor, query.Author)
+				return tc.indexRelatedArticleIDs, tc.indexRelatedErr
+			})
+
+			articleService := article.NewArticleService(dep.database, dep.cache, dep.indexer, dep.author)
+
+			articles, err := articleService.RelatedArticle(context.Background(), tc.query)
+			assert.Equal(t, tc.expectErr, err != nil)
+			assert.Len(t, articles, tc.expectedArticlesLength)
+		})
+	}
+}
+
+func TestPublishArticle(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name           string
+		article        model.Article
+		dbGetErr       error
+		dbUpdateErr    error
+		indexErr       error
+		expectUpdate   bool
+		expectErr      bool
+		expectedStatus string
+	}{
+		{
+			name:           "draft article published",
+			article:        model.Article{ID: 1, Status: model.ArticleStatusDraft},
+			expectUpdate:   true,
+			expectedStatus: model.ArticleStatusPublished,
+		},
+		{
+			name:           "due scheduled article published",
+			article:        model.Article{ID: 1, Status: model.ArticleStatusScheduled, PublishAt: &past},
+			expectUpdate:   true,
+			expectedStatus: model.ArticleStatusPublished,
+		},
+		{
+			name:           "not yet due scheduled article published",
+			article:        model.Article{ID: 1, Status: model.ArticleStatusScheduled, PublishAt: &future},
+			expectUpdate:   true,
+			expectedStatus: model.ArticleStatusPublished,
+		},
+		{
+			name:           "published article re-indexed",
+			article:        model.Article{ID: 1, Status: model.ArticleStatusPublished, PublishAt: &past},
+			expectUpdate:   false,
+			expectedStatus: model.ArticleStatusPublished,
+		},
+		{
+			name:      "archived article",
+			article:   model.Article{ID: 1, Status: model.ArticleStatusArchived},
+			expectErr: true,
+		},
+		{
+			name:      "article not found",
+			article:   model.Article{ID: 1},
+			dbGetErr:  service.ErrArticleNotFound,
+			expectErr: true,
+		},
+		{
+			name:         "unable to update article",
+			article:      model.Article{ID: 1, Status: model.ArticleStatusDraft},
+			dbUpdateErr:  assert.AnError,
+			expectUpdate: true,
+			expectErr:    true,
+		},
+		{
+			name:         "unable to index article",
+			article:      model.Article{ID: 1, Status: model.ArticleStatusDraft},
+			indexErr:     assert.AnError,
+			expectUpdate: true,
+			expectErr:    true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			updateTimes := 0
+			if tc.expectUpdate {
+				updateTimes = 1
+			}
+
+			dep := initialize(ctrl)
+			dep.database.EXPECT().Get(gomock.Any(), tc.article.ID).Return(tc.article, tc.dbGetErr)
+			dep.database.EXPECT().Update(gomock.Any(), gomock.Any()).Times(updateTimes).DoAndReturn(func(ctx context.Context, a model.Article) error {
+				assert.Equal(t, model.ArticleStatusPublished, a.Status)
+				assert.False(t, a.PublishAt.After(time.Now()))
+				return tc.dbUpdateErr
+			})
+			dep.indexer.EXPECT().Index(gomock.Any(), gomock.Any()).AnyTimes().Return(tc.indexErr)
+
+			articleService := article.NewArticleService(dep.database, dep.cache, dep.indexer, dep.author)
+
+			published, err := articleService.PublishArticle(policy.WithSystem(context.Background()), tc.article.ID)
+			assert.Equal(t, tc.expectErr, err != nil)
+			if !tc.expectErr {
+				assert.Equal(t, tc.expectedStatus, published.Status)
+			}
+		})
+	}
+}
+
+func TestArchiveArticle(t *testing.T) {
+	tests := []struct {
+		name         string
+		ctx          context.Context
+		article      model.Article
+		dbGetErr     error
+		dbUpdateErr  error
+		indexErr     error
+		expectUpdate bool
+		expectErr    bool
+	}{
+		{
+			name:         "published article archived",
+			ctx:          policy.WithSystem(context.Background()),
+			article:      model.Article{ID: 1, Status: model.ArticleStatusPublished, Version: 2},
+			expectUpdate: true,
+		},
+		{
+			name:         "draft article archived",
+			ctx:          policy.WithSystem(context.Background()),
+			article:      model.Article{ID: 1, Status: model.ArticleStatusDraft, Version: 2},
+			expectUpdate: true,
+		},
+		{
+			name:    "archived article re-indexed",
+			ctx:     policy.WithSystem(context.Background()),
+			article: model.Article{ID: 1, Status: model.ArticleStatusArchived, Version: 2},
+		},
+		{
+			name:         "article of other author",
+			ctx:          service.WithPrincipal(context.Background(), model.Principal{Role: model.RoleAuthor, AuthorID: 2}),
+			article:      model.Article{ID: 1, AuthorID: 1, Status: model.ArticleStatusPublished},
+			expectUpdate: false,
+			expectErr:    true,
+		},
+		{
+			name:      "article not found",
+			ctx:       policy.WithSystem(context.Background()),
+			article:   model.Article{ID: 1},
+			dbGetErr:  service.ErrArticleNotFound,
+			expectErr: true,
+		},
+		{
+			name:         "unable to update article",
+			ctx:          policy.WithSystem(context.Background()),
+			article:      model.Article{ID: 1, Status: model.ArticleStatusPublished},
+			dbUpdateErr:  assert.AnError,
+			expectUpdate: true,
+			expectErr:    true,
+		},
+		{
+			name:         "unable to index article",
+			ctx:          policy.WithSystem(context.Background()),
+			article:      model.Article{ID: 1, Status: model.ArticleStatusPublished},
+			indexErr:     assert.AnError,
+			expectUpdate: true,
+			expectErr:    true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			updateTimes := 0
+			if tc.expectUpdate {
+				updateTimes = 1
+			}
+
+			dep := initialize(ctrl)
+			dep.database.EXPECT().Get(gomock.Any(), tc.article.ID).Return(tc.article, tc.dbGetErr)
+			dep.database.EXPECT().Update(gomock.Any(), gomock.Any()).Times(updateTimes).DoAndReturn(func(ctx context.Context, a model.Article) error {
+				assert.Equal(t, model.ArticleStatusArchived, a.Status)
+				return tc.dbUpdateErr
+			})
+			dep.indexer.EXPECT().Index(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(func(ctx context.Context, a model.Article) error {
+				assert.Equal(t, model.ArticleStatusArchived, a.Status)
+				return tc.indexErr
+			})
+
+			articleService := article.NewArticleService(dep.database, dep.cache, dep.indexer, dep.author)
+
+			archived, err := articleService.ArchiveArticle(tc.ctx, tc.article.ID)
+			assert.Equal(t, tc.expectErr, err != nil)
+			if !tc.expectErr {
+				assert.Equal(t, model.ArticleStatusArchived, archived.Status)
+				assert.Equal(t, tc.article.Version+updateTimes, archived.Version)
+			}
+		})
+	}
+}
+
+func TestPublishDueArticle(t *testing.T) {
+	tests := []struct {
+		name              string
+		dueArticleIDs     []int
+		dbListErr         error
+		expectedPublished int
+		expectErr         bool
+	}{
+		{
+			name:              "due articles published",
+			dueArticleIDs:     []int{1, 2, 3},
+			expectedPublished: 3,
+		},
+		{
+			name:              "some due articles failed to publish",
+			dueArticleIDs:     []int{1, 200, 3},
+			expectedPublished: 2,
+			expectErr:         true,
+		},
+		{
+			name:      "unable to list due articles",
+			dbListErr: assert.AnError,
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			now := time.Now()
+
+			dep := initialize(ctrl)
+			dep.database.EXPECT().ListScheduledDue(gomock.Any(), now, gomock.Any()).Return(tc.dueArticleIDs, tc.dbListErr)
+			dep.database.EXPECT().Get(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(func(ctx context.Context, id int) (model.Article, error) {
+				// simulate condition all article with id > 100 not found
+				if id > 100 {
+					return model.Article{}, service.ErrArticleNotFound
+				}
+
+				publishAt := now.Add(-time.Minute)
+				return model.Article{ID: id, Status: model.ArticleStatusScheduled, PublishAt: &publishAt}, nil
+			})
+			dep.database.EXPECT().Update(gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+			dep.indexer.EXPECT().Index(gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+
+			articleService := article.NewArticleService(dep.database, dep.cache, dep.indexer, dep.author)
+
+			published, err := articleService.PublishDueArticle(policy.WithSystem(context.Background()), now)
+			assert.Equal(t, tc.expectErr, err != nil)
+			assert.Equal(t, tc.expectedPublished, published)
+		})
+	}
+}
+
+func TestDiffArticleRevision(t *testing.T) {
+	tests := []struct {
+		name         string
+		from         int
+		to           int
+		dbGetErr     error
+		expectedDiff string
+		expectErr    bool
+	}{
+		{
+			name: "diff between revisions",
+			from: 1,
+			to:   2,
+			expectedDiff: "--- article/1/revision/1\n+++ article/1/revision/2\n" +
+				"@@ -1,6 +1,6 @@\n" +
+				"-title: title 1\n" +
+				"+title: title 2\n" +
+				" language: en\n" +
+				" tags: go\n" +
+				" status: published\n" +
+				" \n" +
+				"-body 1\n" +
+				"+body 2\n",
+		},
+		{
+			name: "diff against empty article",
+			from: 0,
+			to:   1,
+			expectedDiff: "--- article/1/revision/0\n+++ article/1/revision/1\n" +
+				"@@ -0,0 +1,6 @@\n" +
+				"+title: title 1\n" +
+				"+language: en\n" +
+				"+tags: go\n" +
+				"+status: published\n" +
+				"+\n" +
+				"+body 1\n",
+		},
+		{
+			name:      "revision not found",
+			from:      1,
+			to:        2,
+			dbGetErr:  service.ErrRevisionNotFound,
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dep := initialize(ctrl)
+			dep.database.EXPECT().Get(gomock.Any(), 1).Return(model.Article{ID: 1, Status: model.ArticleStatusPublished}, nil)
+			dep.database.EXPECT().GetRevision(gomock.Any(), 1, gomock.Any()).AnyTimes().DoAndReturn(func(ctx context.Context, id int, revision int) (model.ArticleRevision, error) {
+				return model.ArticleRevision{
+					ArticleID: id,
+					Revision:  revision,
+					Title:     fmt.Sprintf("title %d", revision),
+					Body:      fmt.Sprintf("body %d", revision),
+					Language:  "en",
+					Tags:      []string{"go"},
+					Status:    model.ArticleStatusPublished,
+				}, tc.dbGetErr
+			})
+
+			articleService := article.NewArticleService(dep.database, dep.cache, dep.indexer, dep.author)
+
+			result, err := articleService.DiffArticleRevision(context.Background(), 1, tc.from, tc.to)
+			assert.Equal(t, tc.expectErr, err != nil)
+			assert.Equal(t, tc.expectedDiff, result.Diff)
+		})
+	}
+}
+
+func TestRestoreArticleRevision(t *testing.T) {
+	tests := []struct {
+		name           string
+		dbGetErr       error
+		dbRevisionErr  error
+		dbUpdateErr    error
+		indexErr       error
+		expectUpdate   bool
+		expectErr      bool
+		expectedResult model.Article
+	}{
+		{
+			name:         "revision restored",
+			expectUpdate: true,
+			expectedResult: model.Article{
+				ID:          1,
+				AuthorID:    7,
+				Title:       "old title",
+				Body:        "old *body*",
+				BodyFormat:  model.BodyFormatMarkdown,
+				BodyHTML:    "<p>old <em>body</em></p>\n",
+				Excerpt:     "old body",
+				WordCount:   2,
+				ReadingTime: 1,
+				Tags:        []string{"old"},
+				Status:      model.ArticleStatusPublished,
+				Version:     3,
+			},
+		},
+		{
+			name:      "article not found",
+			dbGetErr:  service.ErrArticleNotFound,
+			expectErr: true,
+		},
+		{
+			name:          "revision not found",
+			dbRevisionErr: service.ErrRevisionNotFound,
+			expectErr:     true,
+		},
+		{
+			name:         "unable to update article",
+			dbUpdateErr:  assert.AnError,
+			expectUpdate: true,
+			expectErr:    true,
+		},
+		{
+			name:         "unable to index article",
+			indexErr:     assert.AnError,
+			expectUpdate: true,
+			expectErr:    true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			updateTimes := 0
+			if tc.expectUpdate {
+				updateTimes = 1
+			}
+
+			dep := initialize(ctrl)
+			dep.database.EXPECT().Get(gomock.Any(), 1).Return(model.Article{
+				ID:       1,
+				AuthorID: 7,
+				Title:    "new title",
+				Body:     "new body",
+				Tags:     []string{"new"},
+				Status:   model.ArticleStatusPublished,
+				Version:  2,
+			}, tc.dbGetErr)
+			dep.database.EXPECT().GetRevision(gomock.Any(), 1, 2).MaxTimes(1).Return(model.ArticleRevision{
+				ArticleID:  1,
+				Revision:   2,
+				AuthorID:   8,
+				Title:      "old title",
+				Body:       "old *body*",
+				BodyFormat: model.BodyFormatMarkdown,
+				Tags:       []string{"old"},
+				Status:     model.ArticleStatusDraft,
+			}, tc.dbRevisionErr)
+			dep.database.EXPECT().Update(gomock.Any(), gomock.Any()).Times(updateTimes).Return(tc.dbUpdateErr)
+			dep.indexer.EXPECT().Index(gomock.Any(), gomock.Any()).AnyTimes().Return(tc.indexErr)
+
+			articleService := article.NewArticleService(dep.database, dep.cache, dep.indexer, dep.author)
+
+			restored, err := articleService.RestoreArticleRevision(policy.WithSystem(context.Background()), 1, 2)
+			assert.Equal(t, tc.expectErr, err != nil)
+			if !tc.expectErr {
+				restored.UpdatedAt = time.Time{}
+				assert.Equal(t, tc.expectedResult, restored)
+			}
+		})
+	}
+}
+
+func TestGetArticle(t *testing.T) {
+	author := model.Principal{Subject: "1", Name: "John Doe", Role: model.RoleAuthor, AuthorID: 1}
+	otherAuthor := model.Principal{Subject: "2", Name: "Richard Roe", Role: model.RoleAuthor, AuthorID: 2}
+	editor := model.Principal{Subject: "3", Name: "Jane Roe", Role: model.RoleEditor}
+
+	tests := []struct {
+		name        string
+		principal   *model.Principal
+		status      string
+		dbGetErr    error
+		expectedErr error
+	}{
+		{name: "published article read anonymously", status: model.ArticleStatusPublished},
+		{name: "draft article hidden from anonymous", status: model.ArticleStatusDraft, expectedErr: service.ErrArticleNotFound},
+		{name: "scheduled article hidden from anonymous", status: model.ArticleStatusScheduled, expectedErr: service.ErrArticleNotFound},
+		{name: "draft article read by its author", principal: &author, status: model.ArticleStatusDraft},
+		{name: "draft article hidden from other author", principal: &otherAuthor, status: model.ArticleStatusDraft, expectedErr: service.ErrArticleNotFound},
+		{name: "draft article read by editor", principal: &editor, status: model.ArticleStatusDraft},
+		{name: "article not found", dbGetErr: service.ErrArticleNotFound, expectedErr: service.ErrArticleNotFound},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// the article is read from database only, the cache may be stale
+			dep := initialize(ctrl)
+			dep.database.EXPECT().Get(gomock.Any(), 1).Times(2).Return(model.Article{ID: 1, AuthorID: 1, Status: tc.status, Version: 3}, tc.dbGetErr)
+			dep.database.EXPECT().ListRevisions(gomock.Any(), 1).MaxTimes(1).Return([]model.ArticleRevision{{ArticleID: 1, Revision: 1}}, nil)
+
+			articleService := article.NewArticleService(dep.database, dep.cache, dep.indexer, dep.author)
+
+			ctx := context.Background()
+			if tc.principal != nil {
+				ctx = service.WithPrincipal(ctx, *tc.principal)
+			}
+
+			got, err := articleService.GetArticle(ctx, 1)
+			assert.True(t, errors.Is(err, tc.expectedErr))
+			if tc.expectedErr == nil {
+				assert.Equal(t, 3, got.Version)
+			}
+
+			_, err = articleService.ListArticleRevision(ctx, 1)
+			assert.True(t, errors.Is(err, tc.expectedErr), "revisions are as visible as their article")
+		})
+	}
+}
+
+func TestUpdateArticle(t *testing.T) {
+	tests := []struct {
+		name            string
+		article         model.Article
+		dbGetErr        error
+		dbUpdateErr     error
+		indexErr        error
+		expectUpdate    bool
+		expectErr       error
+		expectedVersion int
+	}{
+		{
+			name:            "article updated",
+			article:         model.Article{ID: 1, Title: "new title", Body: "new body"},
+			expectUpdate:    true,
+			expectedVersion: 4,
+		},
+		{
+			name:            "article updated, with matching version",
+			article:         model.Article{ID: 1, Title: "new title", Body: "new body", Version: 3},
+			expectUpdate:    true,
+			expectedVersion: 4,
+		},
+		{
+			name:      "stale version",
+			article:   model.Article{ID: 1, Title: "new title", Body: "new body", Version: 2},
+			expectErr: service.ErrArticleVersionConflict,
+		},
+		{
+			name:         "concurrently modified",
+			article:      model.Article{ID: 1, Title: "new title", Body: "new body", Version: 3},
+			dbUpdateErr:  service.ErrArticleVersionConflict,
+			expectUpdate: true,
+			expectErr:    service.ErrArticleVersionConflict,
+		},
+		{
+			name:      "article not found",
+			article:   model.Article{ID: 1, Title: "new title", Body: "new body"},
+			dbGetErr:  service.ErrArticleNotFound,
+			expectErr: service.ErrArticleNotFound,
+		},
+		{
+			name:         "unable to index article",
+			article:      model.Article{ID: 1, Title: "new title", Body: "new body"},
+			indexErr:     assert.AnError,
+			expectUpdate: true,
+			expectErr:    assert.AnError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			updateTimes := 0
+			if tc.expectUpdate {
+				updateTimes = 1
+			}
+
+			dep := initialize(ctrl)
+			dep.database.EXPECT().Get(gomock.Any(), 1).Return(model.Article{ID: 1, Title: "old title", Body: "old body", Version: 3}, tc.dbGetErr)
+			dep.database.EXPECT().Update(gomock.Any(), gomock.Any()).Times(updateTimes).DoAndReturn(func(ctx context.Context, a model.Article) error {
+				assert.Equal(t, 3, a.Version)
+				assert.Equal(t, "new title", a.Title)
+				return tc.dbUpdateErr
+			})
+			dep.indexer.EXPECT().Index(gomock.Any(), gomock.Any()).AnyTimes().Return(tc.indexErr)
+
+			articleService := article.NewArticleService(dep.database, dep.cache, dep.indexer, dep.author)
+
+			updated, err := articleService.UpdateArticle(policy.WithSystem(context.Background()), tc.article)
+			assert.Equal(t, tc.expectErr, err)
+			if tc.expectErr == nil {
+				assert.Equal(t, tc.expectedVersion, updated.Version)
+			}
+		})
+	}
+}
+
+func TestArticlePermission(t *testing.T) {
+	author := model.Principal{Subject: "1", Name: "John Doe", Role: model.RoleAuthor, AuthorID: 1}
+	otherAuthor := model.Principal{Subject: "2", Name: "Richard Roe", Role: model.RoleAuthor, AuthorID: 2}
+	namesake := model.Principal{Subject: "4", Name: "John Doe", Role: model.RoleAuthor}
+	editor := model.Principal{Subject: "3", Name: "Jane Roe", Role: model.RoleEditor}
+
+	stored := model.Article{ID: 1, AuthorID: 1, Author: "John Doe", AuthorHandle: "john-doe", Title: "old title", Body: "old body", Status: model.ArticleStatusDraft, Version: 3}
+	revision := model.ArticleRevision{ArticleID: 1, Revision: 1, Title: "first title", Body: "first body"}
+
+	actions := map[string]func(s *article.Service, ctx context.Context) error{
+		"create": func(s *article.Service, ctx context.Context) error {
+			_, err := s.CreateArticle(ctx, model.Article{Author: "John Doe", Title: "A Valid Title", Body: "A very interesting content"})
+			return err
+		},
+		"create as new author": func(s *article.Service, ctx context.Context) error {
+			_, err := s.CreateArticle(ctx, model.Article{Author: "Richard Roe", Title: "A Valid Title", Body: "A very interesting content"})
+			return err
+		},
+		"update": func(s *article.Service, ctx context.Context) error {
+			_, err := s.UpdateArticle(ctx, model.Article{ID: 1, Title: "new title", Body: "new body"})
+			return err
+		},
+		"publish": func(s *article.Service, ctx context.Context) error {
+			_, err := s.PublishArticle(ctx, 1)
+			return err
+		},
+		"restore": func(s *article.Service, ctx context.Context) error {
+			_, err := s.RestoreArticleRevision(ctx, 1, 1)
+			return err
+		},
+	}
+
+	tests := []struct {
+		name         string
+		principal    *model.Principal
+		system       bool
+		action       string
+		expectDenied bool
+		expectedKind service.Kind
+	}{
+		{name: "author create own article", principal: &author, action: "create", expectDenied: false},
+		{name: "author create article of other author", principal: &otherAuthor, action: "create", expectDenied: true},
+		{name: "author create article of new author", principal: &otherAuthor, action: "create as new author", expectDenied: true},
+		{name: "author update own article", principal: &author, action: "update", expectDenied: false},
+		{name: "author update article of other author", principal: &otherAuthor, action: "update", expectDenied: true},
+		{name: "author named as author without author id update article", principal: &namesake, action: "update", expectDenied: true},
+		{name: "author publish article of other author", principal: &otherAuthor, action: "publish", expectDenied: true},
+		{name: "author restore article of other author", principal: &otherAuthor, action: "restore", expectDenied: true},
+		{name: "editor create article of other author", principal: &editor, action: "create", expectDenied: false},
+		{name: "editor create article of new author", principal: &editor, action: "create as new author", expectDenied: false},
+		{name: "editor update article of other author", principal: &editor, action: "update", expectDenied: false},
+		{name: "editor publish article of other author", principal: &editor, action: "publish", expectDenied: false},
+		{name: "editor restore article of other author", principal: &editor, action: "restore", expectDenied: false},
+		{name: "internal update without principal", system: true, action: "update", expectDenied: false},
+		{name: "update without principal", action: "update", expectDenied: true, expectedKind: service.KindUnauthenticated},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			writeTimes := 1
+			if tc.expectDenied {
+				writeTimes = 0
+			}
+
+			dep := initialize(ctrl)
+			dep.database.EXPECT().Get(gomock.Any(), 1).AnyTimes().Return(stored, nil)
+			dep.database.EXPECT().GetRevision(gomock.Any(), 1, 1).AnyTimes().Return(revision, nil)
+			dep.database.EXPECT().GenerateID(gomock.Any()).AnyTimes().Return(2, nil)
+			dep.database.EXPECT().Create(gomock.Any(), gomock.Any()).MaxTimes(writeTimes).Return(nil)
+			dep.database.EXPECT().Update(gomock.Any(), gomock.Any()).MaxTimes(writeTimes).Return(nil)
+			dep.author.EXPECT().GetAuthor(gomock.Any(), "John Doe").AnyTimes().Return(model.Author{ID: 1, Handle: "john-doe", Name: "John Doe"}, nil)
+			dep.author.EXPECT().GetOrCreateAuthor(gomock.Any(), "John Doe").MaxTimes(writeTimes).Return(model.Author{ID: 1, Handle: "john-doe", Name: "John Doe"}, nil)
+			dep.author.EXPECT().GetAuthor(gomock.Any(), "Richard Roe").AnyTimes().Return(model.Author{}, service.ErrAuthorNotFound)
+			dep.author.EXPECT().GetOrCreateAuthor(gomock.Any(), "Richard Roe").MaxTimes(writeTimes).Return(model.Author{ID: 2, Handle: "richard-roe", Name: "Richard Roe"}, nil)
+			dep.indexer.EXPECT().Index(gomock.Any(), gomock.Any()).MaxTimes(writeTimes).Return(nil)
+
+			articleService := article.NewArticleService(dep.database, dep.cache, dep.indexer, dep.author)
+
+			ctx := context.Background()
+			if tc.system {
+				ctx = policy.WithSystem(ctx)
+			}
+			if tc.principal != nil {
+				ctx = service.WithPrincipal(ctx, *tc.principal)
+			}
+
+			err := actions[tc.action](articleService, ctx)
+			if tc.expectDenied {
+				expectedKind := tc.expectedKind
+				if expectedKind == "" {
+					expectedKind = service.KindPermissionDenied
+				}
+				assert.Equal(t, expectedKind, service.KindOf(err))
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
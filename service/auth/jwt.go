@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// clockSkew is the tolerated clock difference with token issuers
+const clockSkew = 30 * time.Second
+
+type tokenHeader struct {
+	Algorithm string `json:"alg"`
+}
+
+type tokenClaims struct {
+	Subject   string   `json:"sub"`
+	Name      string   `json:"name"`
+	Role      string   `json:"role"`
+	AuthorID  int      `json:"author_id"`
+	Issuer    string   `json:"iss"`
+	Audience  audience `json:"aud"`
+	ExpiresAt float64  `json:"exp"`
+	NotBefore float64  `json:"nbf"`
+}
+
+// audience represent aud claim, either a single audience or a list
+type audience []string
+
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var single string
+	if json.Unmarshal(b, &single) == nil {
+		*a = audience{single}
+		return nil
+	}
+
+	var list []string
+	err := json.Unmarshal(b, &list)
+	*a = list
+	return err
+}
+
+func (a audience) contains(value string) bool {
+	for _, v := range a {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyToken verify the signature and validity of a compact serialized JWT
+// and return its claims. The algorithm must be one a key is configured for,
+// so a token can not pick a weaker algorithm than the issuer signs with
+func (s *Service) verifyToken(token string) (tokenClaims, error) {
+	var claims tokenClaims
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return claims, errors.New("token is malformed")
+	}
+
+	var header tokenHeader
+	err := decodeSegment(parts[0], &header)
+	if err != nil {
+		return claims, errors.New("token header is malformed")
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return claims, errors.New("token signature is malformed")
+	}
+
+	signed := []byte(parts[0] + "." + parts[1])
+	switch {
+	case header.Algorithm == "HS256" && s.hmacKey != nil:
+		mac := hmac.New(sha256.New, s.hmacKey)
+		mac.Write(signed)
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return claims, errors.New("token signature is invalid")
+		}
+	case header.Algorithm == "RS256" && s.rsaKey != nil:
+		digest := sha256.Sum256(signed)
+		if rsa.VerifyPKCS1v15(s.rsaKey, crypto.SHA256, digest[:], signature) != nil {
+			return claims, errors.New("token signature is invalid")
+		}
+	default:
+		return claims, fmt.Errorf("token algorithm %q is not accepted", header.Algorithm)
+	}
+
+	err = decodeSegment(parts[1], &claims)
+	if err != nil {
+		return claims, errors.New("token claims are malformed")
+	}
+
+	now := s.now()
+	switch {
+	case claims.Subject == "":
+		return claims, errors.New("token subject is blank")
+	case claims.ExpiresAt == 0:
+		return claims, errors.New("token has no expiration")
+	case now.Add(-clockSkew).After(unixTime(claims.ExpiresAt)):
+		return claims, errors.New("token is expired")
+	case claims.NotBefore != 0 && now.Add(clockSkew).Before(unixTime(claims.NotBefore)):
+		return claims, errors.New("token is not valid yet")
+	case s.issuer != "" && claims.Issuer != s.issuer:
+		return claims, errors.New("token issuer is not accepted")
+	case s.audience != "" && !claims.Audience.contains(s.audience):
+		return claims, errors.New("token audience is not accepted")
+	}
+
+	return claims, nil
+}
+
+// ParseRSAPublicKey parse a PEM encoded PKIX or PKCS #1 RSA public key
+func ParseRSAPublicKey(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("rsa public key is not PEM encoded")
+	}
+
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("public key is not an rsa key")
+	}
+	return rsaKey, nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func unixTime(seconds float64) time.Time {
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
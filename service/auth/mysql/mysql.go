@@ -0,0 +1,88 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/prabudzak/article/logger"
+	"github.com/prabudzak/article/model"
+	"github.com/prabudzak/article/service"
+	"github.com/prabudzak/article/service/mysqlerr"
+)
+
+// APIKeyDatabase represent API key database mysql implementation
+type APIKeyDatabase struct {
+	db *sql.DB
+}
+
+// NewAPIKeyDatabase create a new instance of mysql implementation API key database
+func NewAPIKeyDatabase(db *sql.DB) *APIKeyDatabase {
+	return &APIKeyDatabase{
+		db: db,
+	}
+}
+
+// Create write a new API key to database
+func (a *APIKeyDatabase) Create(ctx context.Context, key model.APIKey) (model.APIKey, error) {
+	if key.Hash == "" {
+		return key, errors.New("api key hash is invalid")
+	}
+
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = time.Now().UTC()
+	}
+
+	var authorID sql.NullInt64
+	if key.AuthorID != 0 {
+		authorID = sql.NullInt64{Int64: int64(key.AuthorID), Valid: true}
+	}
+
+	result, err := a.db.ExecContext(ctx, "INSERT INTO api_key (name, role, author_id, key_hash, created_at) VALUES (?, ?, ?, ?, ?)",
+		key.Name,
+		key.Role,
+		authorID,
+		key.Hash,
+		key.CreatedAt,
+	)
+	if err != nil {
+		logger.FromContext(ctx).Error("api key not created", logger.Err(err))
+		return key, mysqlerr.Wrap(err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		logger.FromContext(ctx).Error("api key not created", logger.Err(err))
+		return key, mysqlerr.Wrap(err)
+	}
+
+	key.ID = int(id)
+	return key, nil
+}
+
+// GetByHash retrieve an API key by the hash of the key from database
+func (a *APIKeyDatabase) GetByHash(ctx context.Context, hash string) (model.APIKey, error) {
+	var key model.APIKey
+	var authorID sql.NullInt64
+	var revokedAt sql.NullTime
+
+	if hash == "" {
+		return key, errors.New("hash parameter is invalid")
+	}
+
+	row := a.db.QueryRowContext(ctx, "SELECT id, name, role, author_id, key_hash, created_at, revoked_at FROM api_key WHERE key_hash = ?", hash)
+	err := row.Scan(&key.ID, &key.Name, &key.Role, &authorID, &key.Hash, &key.CreatedAt, &revokedAt)
+	if err == sql.ErrNoRows {
+		return key, service.ErrAPIKeyNotFound
+	} else if err != nil {
+		logger.FromContext(ctx).Error("api key not retrieved", logger.Err(err))
+		return key, mysqlerr.Wrap(err)
+	}
+
+	key.AuthorID = int(authorID.Int64)
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	return key, nil
+}
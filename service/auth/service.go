@@ -0,0 +1,206 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prabudzak/article/model"
+	"github.com/prabudzak/article/service"
+	"github.com/prabudzak/article/service/policy"
+)
+
+//go:generate mockgen -package=mock -source=service.go -destination=mock/service.go
+
+// apiKeyPrefix mark generated API keys, so leaked keys are easy to recognize
+const apiKeyPrefix = "ak_"
+
+// Database represent API key persistent storage
+type Database interface {
+	GetByHash(ctx context.Context, hash string) (model.APIKey, error)
+	Create(ctx context.Context, key model.APIKey) (model.APIKey, error)
+}
+
+// Service represent authentication service implementation
+type Service struct {
+	database Database
+
+	hmacKey  []byte
+	rsaKey   *rsa.PublicKey
+	issuer   string
+	audience string
+	now      func() time.Time
+}
+
+// Option represent authentication service configuration option
+type Option func(s *Service)
+
+// WithHS256Key accept JWT signed with HMAC SHA-256 by given secret
+func WithHS256Key(secret []byte) Option {
+	return func(s *Service) {
+		if len(secret) > 0 {
+			s.hmacKey = secret
+		}
+	}
+}
+
+// WithRS256Key accept JWT signed with RSA SHA-256 by the private key of given
+// public key
+func WithRS256Key(key *rsa.PublicKey) Option {
+	return func(s *Service) {
+		s.rsaKey = key
+	}
+}
+
+// WithIssuer only accept JWT issued by given issuer
+func WithIssuer(issuer string) Option {
+	return func(s *Service) {
+		s.issuer = issuer
+	}
+}
+
+// WithAudience only accept JWT intended for given audience
+func WithAudience(audience string) Option {
+	return func(s *Service) {
+		s.audience = audience
+	}
+}
+
+// NewAuthService create a new authentication service instance. JWT are
+// rejected unless a verification key is configured
+func NewAuthService(database Database, options ...Option) *Service {
+	s := &Service{
+		database: database,
+		now:      time.Now,
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	return s
+}
+
+// AuthenticateAPIKey authenticate a caller by its API key. Unknown and revoked
+// keys are rejected with unauthenticated error
+func (s *Service) AuthenticateAPIKey(ctx context.Context, key string) (model.Principal, error) {
+	if key == "" {
+		return model.Principal{}, service.Unauthenticated(errors.New("api key is blank"))
+	}
+
+	apiKey, err := s.database.GetByHash(ctx, hashAPIKey(key))
+	if errors.Is(err, service.ErrAPIKeyNotFound) {
+		return model.Principal{}, service.Unauthenticated(errors.New("api key is invalid"))
+	} else if err != nil {
+		return model.Principal{}, err
+	}
+
+	if apiKey.RevokedAt != nil {
+		return model.Principal{}, service.Unauthenticated(errors.New("api key is revoked"))
+	}
+
+	return model.Principal{
+		Subject:  strconv.Itoa(apiKey.ID),
+		Name:     apiKey.Name,
+		Role:     apiKey.Role,
+		AuthorID: apiKey.AuthorID,
+		Method:   model.AuthMethodAPIKey,
+	}, nil
+}
+
+// AuthenticateToken authenticate a caller by its JWT bearer token. The token
+// must be signed by a configured key, unexpired, and issued by and for the
+// configured issuer and audience
+func (s *Service) AuthenticateToken(ctx context.Context, token string) (model.Principal, error) {
+	claims, err := s.verifyToken(token)
+	if err != nil {
+		return model.Principal{}, service.Unauthenticated(err)
+	}
+
+	role := claims.Role
+	if role == "" {
+		role = model.RoleAuthor
+	}
+
+	if !isRole(role) {
+		return model.Principal{}, service.Unauthenticated(errors.New("token role is invalid"))
+	}
+
+	return model.Principal{
+		Subject:  claims.Subject,
+		Name:     claims.Name,
+		Role:     role,
+		AuthorID: claims.AuthorID,
+		Method:   model.AuthMethodJWT,
+	}, nil
+}
+
+// CreateAPIKey generate and store a new API key of given role, bound to the
+// author of given id if any. Author keys must be bound to an author, which
+// owns the articles the key may write. The returned key is never stored and
+// can not be retrieved again
+func (s *Service) CreateAPIKey(ctx context.Context, name string, role string, authorID int) (string, model.APIKey, error) {
+	err := policy.Authorize(ctx, policy.ActionManageAPIKeys, model.Article{})
+	if err != nil {
+		return "", model.APIKey{}, err
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", model.APIKey{}, service.InvalidArgument(errors.New("api key name is blank"))
+	}
+
+	if !isRole(role) {
+		return "", model.APIKey{}, service.InvalidArgument(errors.New("api key role is not one of author, editor or admin"))
+	}
+
+	if authorID < 0 {
+		return "", model.APIKey{}, service.InvalidArgument(errors.New("api key author id is invalid"))
+	}
+
+	if role == model.RoleAuthor && authorID == 0 {
+		return "", model.APIKey{}, service.InvalidArgument(errors.New("author api key is not bound to an author"))
+	}
+
+	secret := make([]byte, 32)
+	_, err = rand.Read(secret)
+	if err != nil {
+		return "", model.APIKey{}, err
+	}
+	key := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	apiKey, err := s.database.Create(ctx, model.APIKey{
+		Name:      name,
+		Role:      role,
+		AuthorID:  authorID,
+		Hash:      hashAPIKey(key),
+		CreatedAt: s.now().UTC(),
+	})
+	if err != nil {
+		return "", model.APIKey{}, err
+	}
+
+	return key, apiKey, nil
+}
+
+// hashAPIKey return the stored hash of an API key. Generated keys are random
+// enough for an unsalted hash
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func isRole(role string) bool {
+	switch role {
+	case model.RoleAuthor, model.RoleEditor, model.RoleAdmin:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,412 @@
+package auth_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prabudzak/article/model"
+	"github.com/prabudzak/article/service"
+	"github.com/prabudzak/article/service/auth"
+	"github.com/prabudzak/article/service/auth/mock"
+	"github.com/prabudzak/article/service/policy"
+	"github.com/stretchr/testify/assert"
+)
+
+type dependency struct {
+	database *mock.MockDatabase
+}
+
+func initialize(ctrl *gomock.Controller) dependency {
+	return dependency{
+		database: mock.NewMockDatabase(ctrl),
+	}
+}
+
+var hmacSecret = []byte("test-secret")
+
+func signHS256(t *testing.T, header, claims map[string]interface{}) string {
+	signed := encodeSegment(t, header) + "." + encodeSegment(t, claims)
+	mac := hmac.New(sha256.New, hmacSecret)
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, claims map[string]interface{}) string {
+	signed := encodeSegment(t, map[string]interface{}{"alg": "RS256", "typ": "JWT"}) + "." + encodeSegment(t, claims)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// tamper replace the claims of a signed token, keeping its signature
+func tamper(token string, claims string) string {
+	parts := strings.Split(token, ".")
+	return parts[0] + "." + claims + "." + parts[2]
+}
+
+func encodeSegment(t *testing.T, v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	revokedAt := time.Now()
+
+	tests := []struct {
+		name              string
+		key               string
+		dbKey             model.APIKey
+		dbErr             error
+		expectedPrincipal model.Principal
+		expectedKind      service.Kind
+		expectErr         bool
+	}{
+		{
+			name:  "authenticated",
+			key:   "ak_secret",
+			dbKey: model.APIKey{ID: 7, Name: "editorial bot", Role: model.RoleEditor},
+			expectedPrincipal: model.Principal{
+				Subject: "7",
+				Name:    "editorial bot",
+				Role:    model.RoleEditor,
+				Method:  model.AuthMethodAPIKey,
+			},
+			expectErr: false,
+		},
+		{
+			name:  "author key",
+			key:   "ak_secret",
+			dbKey: model.APIKey{ID: 8, Name: "John Doe", Role: model.RoleAuthor, AuthorID: 3},
+			expectedPrincipal: model.Principal{
+				Subject:  "8",
+				Name:     "John Doe",
+				Role:     model.RoleAuthor,
+				AuthorID: 3,
+				Method:   model.AuthMethodAPIKey,
+			},
+			expectErr: false,
+		},
+		{
+			name:         "blank key",
+			key:          "",
+			expectedKind: service.KindUnauthenticated,
+			expectErr:    true,
+		},
+		{
+			name:         "unknown key",
+			key:          "ak_unknown",
+			dbErr:        service.ErrAPIKeyNotFound,
+			expectedKind: service.KindUnauthenticated,
+			expectErr:    true,
+		},
+		{
+			name:         "revoked key",
+			key:          "ak_secret",
+			dbKey:        model.APIKey{ID: 7, Role: model.RoleEditor, RevokedAt: &revokedAt},
+			expectedKind: service.KindUnauthenticated,
+			expectErr:    true,
+		},
+		{
+			name:         "database failure",
+			key:          "ak_secret",
+			dbErr:        errors.New("connection refused"),
+			expectedKind: service.KindInternal,
+			expectErr:    true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			sum := sha256.Sum256([]byte(tc.key))
+
+			dep := initialize(ctrl)
+			dep.database.EXPECT().GetByHash(gomock.Any(), hex.EncodeToString(sum[:])).MaxTimes(1).Return(tc.dbKey, tc.dbErr)
+
+			authService := auth.NewAuthService(dep.database)
+
+			principal, err := authService.AuthenticateAPIKey(context.Background(), tc.key)
+			assert.Equal(t, tc.expectErr, err != nil)
+			if tc.expectErr {
+				assert.Equal(t, tc.expectedKind, service.KindOf(err))
+				return
+			}
+			assert.Equal(t, tc.expectedPrincipal, principal)
+		})
+	}
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		keyName   string
+		role      string
+		authorID  int
+		dbErr     error
+		expectErr bool
+	}{
+		{
+			name:      "key created",
+			ctx:       policy.WithSystem(context.Background()),
+			keyName:   "editorial bot",
+			role:      model.RoleEditor,
+			expectErr: false,
+		},
+		{
+			name:      "author key created",
+			ctx:       policy.WithSystem(context.Background()),
+			keyName:   "John Doe",
+			role:      model.RoleAuthor,
+			authorID:  3,
+			expectErr: false,
+		},
+		{
+			name:      "author key without author",
+			ctx:       policy.WithSystem(context.Background()),
+			keyName:   "John Doe",
+			role:      model.RoleAuthor,
+			expectErr: true,
+		},
+		{
+			name:      "without principal",
+			ctx:       context.Background(),
+			keyName:   "editorial bot",
+			role:      model.RoleEditor,
+			expectErr: true,
+		},
+		{
+			name:      "blank name",
+			ctx:       policy.WithSystem(context.Background()),
+			keyName:   " ",
+			role:      model.RoleEditor,
+			expectErr: true,
+		},
+		{
+			name:      "unknown role",
+			ctx:       policy.WithSystem(context.Background()),
+			keyName:   "editorial bot",
+			role:      "owner",
+			expectErr: true,
+		},
+		{
+			name:      "database failure",
+			ctx:       policy.WithSystem(context.Background()),
+			keyName:   "editorial bot",
+			role:      model.RoleAdmin,
+			dbErr:     errors.New("connection refused"),
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			var stored model.APIKey
+			dep := initialize(ctrl)
+			dep.database.EXPECT().Create(gomock.Any(), gomock.Any()).MaxTimes(1).DoAndReturn(func(ctx context.Context, key model.APIKey) (model.APIKey, error) {
+				stored = key
+				key.ID = 1
+				return key, tc.dbErr
+			})
+			dep.database.EXPECT().GetByHash(gomock.Any(), gomock.Any()).MaxTimes(1).DoAndReturn(func(ctx context.Context, hash string) (model.APIKey, error) {
+				if hash != stored.Hash {
+					return model.APIKey{}, service.ErrAPIKeyNotFound
+				}
+				return stored, nil
+			})
+
+			authService := auth.NewAuthService(dep.database)
+
+			key, apiKey, err := authService.CreateAPIKey(tc.ctx, tc.keyName, tc.role, tc.authorID)
+			assert.Equal(t, tc.expectErr, err != nil)
+			if tc.expectErr {
+				return
+			}
+
+			assert.NotEmpty(t, key)
+			assert.NotContains(t, apiKey.Hash, key)
+			assert.Equal(t, tc.role, apiKey.Role)
+
+			// the returned key authenticate against its stored hash
+			principal, err := authService.AuthenticateAPIKey(context.Background(), key)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.role, principal.Role)
+			assert.Equal(t, tc.authorID, principal.AuthorID)
+		})
+	}
+}
+
+func TestAuthenticateToken(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherRSAKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hs256 := map[string]interface{}{"alg": "HS256", "typ": "JWT"}
+	exp := time.Now().Add(time.Hour).Unix()
+	claims := func(extra map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{"sub": "42", "name": "Jane", "role": "editor", "iss": "auth.example.com", "aud": "article", "exp": exp}
+		for k, v := range extra {
+			if v == nil {
+				delete(c, k)
+				continue
+			}
+			c[k] = v
+		}
+		return c
+	}
+	unsigned := func(header, claims map[string]interface{}) string {
+		return encodeSegment(t, header) + "." + encodeSegment(t, claims) + "."
+	}
+
+	tests := []struct {
+		name              string
+		token             string
+		options           []auth.Option
+		expectedPrincipal model.Principal
+		expectErr         bool
+	}{
+		{
+			name:              "HS256 token",
+			token:             signHS256(t, hs256, claims(nil)),
+			expectedPrincipal: model.Principal{Subject: "42", Name: "Jane", Role: model.RoleEditor, Method: model.AuthMethodJWT},
+			expectErr:         false,
+		},
+		{
+			name:              "RS256 token",
+			token:             signRS256(t, rsaKey, claims(nil)),
+			expectedPrincipal: model.Principal{Subject: "42", Name: "Jane", Role: model.RoleEditor, Method: model.AuthMethodJWT},
+			expectErr:         false,
+		},
+		{
+			name:              "role default to author",
+			token:             signHS256(t, hs256, claims(map[string]interface{}{"role": nil})),
+			expectedPrincipal: model.Principal{Subject: "42", Name: "Jane", Role: model.RoleAuthor, Method: model.AuthMethodJWT},
+			expectErr:         false,
+		},
+		{
+			name:              "author id claim",
+			token:             signHS256(t, hs256, claims(map[string]interface{}{"role": "author", "author_id": 3})),
+			expectedPrincipal: model.Principal{Subject: "42", Name: "Jane", Role: model.RoleAuthor, AuthorID: 3, Method: model.AuthMethodJWT},
+			expectErr:         false,
+		},
+		{
+			name:              "audience list",
+			token:             signHS256(t, hs256, claims(map[string]interface{}{"aud": []string{"other", "article"}})),
+			expectedPrincipal: model.Principal{Subject: "42", Name: "Jane", Role: model.RoleEditor, Method: model.AuthMethodJWT},
+			expectErr:         false,
+		},
+		{
+			name:      "unknown role",
+			token:     signHS256(t, hs256, claims(map[string]interface{}{"role": "owner"})),
+			expectErr: true,
+		},
+		{
+			name:      "RS256 token signed by other key",
+			token:     signRS256(t, otherRSAKey, claims(nil)),
+			expectErr: true,
+		},
+		{
+			name:      "HS256 token without HMAC key configured",
+			token:     signHS256(t, hs256, claims(nil)),
+			options:   []auth.Option{auth.WithRS256Key(&rsaKey.PublicKey)},
+			expectErr: true,
+		},
+		{
+			name:      "tampered claims",
+			token:     tamper(signHS256(t, hs256, claims(nil)), encodeSegment(t, claims(map[string]interface{}{"role": "admin"}))),
+			expectErr: true,
+		},
+		{
+			name:      "none algorithm",
+			token:     unsigned(map[string]interface{}{"alg": "none"}, claims(nil)),
+			expectErr: true,
+		},
+		{
+			name:      "expired token",
+			token:     signHS256(t, hs256, claims(map[string]interface{}{"exp": time.Now().Add(-time.Hour).Unix()})),
+			expectErr: true,
+		},
+		{
+			name:      "token without expiration",
+			token:     signHS256(t, hs256, claims(map[string]interface{}{"exp": nil})),
+			expectErr: true,
+		},
+		{
+			name:      "token not valid yet",
+			token:     signHS256(t, hs256, claims(map[string]interface{}{"nbf": time.Now().Add(time.Hour).Unix()})),
+			expectErr: true,
+		},
+		{
+			name:      "token without subject",
+			token:     signHS256(t, hs256, claims(map[string]interface{}{"sub": nil})),
+			expectErr: true,
+		},
+		{
+			name:      "wrong issuer",
+			token:     signHS256(t, hs256, claims(map[string]interface{}{"iss": "evil.example.com"})),
+			expectErr: true,
+		},
+		{
+			name:      "wrong audience",
+			token:     signHS256(t, hs256, claims(map[string]interface{}{"aud": "billing"})),
+			expectErr: true,
+		},
+		{
+			name:      "malformed token",
+			token:     "not.a-token",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			options := tc.options
+			if options == nil {
+				options = []auth.Option{auth.WithHS256Key(hmacSecret), auth.WithRS256Key(&rsaKey.PublicKey)}
+			}
+			options = append(options, auth.WithIssuer("auth.example.com"), auth.WithAudience("article"))
+
+			dep := initialize(ctrl)
+			authService := auth.NewAuthService(dep.database, options...)
+
+			principal, err := authService.AuthenticateToken(context.Background(), tc.token)
+			assert.Equal(t, tc.expectErr, err != nil)
+			if tc.expectErr {
+				assert.Equal(t, service.KindUnauthenticated, service.KindOf(err))
+				return
+			}
+			assert.Equal(t, tc.expectedPrincipal, principal)
+		})
+	}
+}
@@ -0,0 +1,65 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: service.go
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	gomock "github.com/golang/mock/gomock"
+	model "github.com/prabudzak/article/model"
+	reflect "reflect"
+)
+
+// MockDatabase is a mock of Database interface
+type MockDatabase struct {
+	ctrl     *gomock.Controller
+	recorder *MockDatabaseMockRecorder
+}
+
+// MockDatabaseMockRecorder is the mock recorder for MockDatabase
+type MockDatabaseMockRecorder struct {
+	mock *MockDatabase
+}
+
+// NewMockDatabase creates a new mock instance
+func NewMockDatabase(ctrl *gomock.Controller) *MockDatabase {
+	mock := &MockDatabase{ctrl: ctrl}
+	mock.recorder = &MockDatabaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockDatabase) EXPECT() *MockDatabaseMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method
+func (m *MockDatabase) Create(ctx context.Context, author model.Author) (model.Author, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, author)
+	ret0, _ := ret[0].(model.Author)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create
+func (mr *MockDatabaseMockRecorder) Create(ctx, author interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockDatabase)(nil).Create), ctx, author)
+}
+
+// GetByHandle mocks base method
+func (m *MockDatabase) GetByHandle(ctx context.Context, handle string) (model.Author, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByHandle", ctx, handle)
+	ret0, _ := ret[0].(model.Author)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByHandle indicates an expected call of GetByHandle
+func (mr *MockDatabaseMockRecorder) GetByHandle(ctx, handle interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByHandle", reflect.TypeOf((*MockDatabase)(nil).GetByHandle), ctx, handle)
+}
@@ -0,0 +1,77 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/prabudzak/article/logger"
+	"github.com/prabudzak/article/model"
+	"github.com/prabudzak/article/service"
+	"github.com/prabudzak/article/service/mysqlerr"
+)
+
+// AuthorDatabase represent author database mysql implementation
+type AuthorDatabase struct {
+	db *sql.DB
+}
+
+// NewAuthorDatabase create a new instance of mysql implementation author database
+func NewAuthorDatabase(db *sql.DB) *AuthorDatabase {
+	return &AuthorDatabase{
+		db: db,
+	}
+}
+
+// Create write a new author to database. When an author with the same handle
+// already exist, the existing author is returned instead
+func (a *AuthorDatabase) Create(ctx context.Context, author model.Author) (model.Author, error) {
+	if author.Handle == "" {
+		return author, errors.New("author handle is invalid")
+	}
+
+	if author.CreatedAt.IsZero() {
+		author.CreatedAt = time.Now().UTC()
+	}
+
+	if author.UpdatedAt.IsZero() {
+		author.UpdatedAt = author.CreatedAt
+	}
+
+	_, err := a.db.ExecContext(ctx, "INSERT INTO author (handle, name, bio, created_at, updated_at) VALUES (?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE handle = handle",
+		author.Handle,
+		author.Name,
+		author.Bio,
+		author.CreatedAt,
+		author.UpdatedAt,
+	)
+	if err != nil {
+		logger.FromContext(ctx).Error("author not created", logger.String("author_handle", author.Handle), logger.Err(err))
+		return author, mysqlerr.Wrap(err)
+	}
+
+	return a.GetByHandle(ctx, author.Handle)
+}
+
+// GetByHandle retrieve an author by handle from database
+func (a *AuthorDatabase) GetByHandle(ctx context.Context, handle string) (model.Author, error) {
+	var author model.Author
+	var bio sql.NullString
+
+	if handle == "" {
+		return author, errors.New("handle parameter is invalid")
+	}
+
+	row := a.db.QueryRowContext(ctx, "SELECT id, handle, name, bio, created_at, updated_at FROM author WHERE handle = ?", handle)
+	err := row.Scan(&author.ID, &author.Handle, &author.Name, &bio, &author.CreatedAt, &author.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return author, service.ErrAuthorNotFound
+	} else if err != nil {
+		logger.FromContext(ctx).Error("author not retrieved", logger.String("author_handle", handle), logger.Err(err))
+		return author, mysqlerr.Wrap(err)
+	}
+
+	author.Bio = bio.String
+	return author, nil
+}
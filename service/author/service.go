@@ -0,0 +1,71 @@
+package author
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/prabudzak/article/model"
+	"github.com/prabudzak/article/service"
+)
+
+//go:generate mockgen -package=mock -source=service.go -destination=mock/service.go
+
+// Database represent author persistent storage
+type Database interface {
+	Create(ctx context.Context, author model.Author) (model.Author, error)
+	GetByHandle(ctx context.Context, handle string) (model.Author, error)
+}
+
+// Service represent author service implementation
+type Service struct {
+	database Database
+}
+
+// NewAuthorService create a new author service instance
+func NewAuthorService(database Database) *Service {
+	return &Service{
+		database: database,
+	}
+}
+
+// GetAuthor retrieve an author by handle
+func (s *Service) GetAuthor(ctx context.Context, handle string) (model.Author, error) {
+	handle = model.AuthorHandle(handle)
+	if handle == "" {
+		return model.Author{}, service.ErrAuthorNotFound
+	}
+
+	return s.database.GetByHandle(ctx, handle)
+}
+
+// GetOrCreateAuthor retrieve an author by the handle derived from given name,
+// creating a new author with given name as display name when none exist
+func (s *Service) GetOrCreateAuthor(ctx context.Context, name string) (model.Author, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return model.Author{}, service.InvalidArgument(errors.New("author name is blank"))
+	}
+
+	handle := model.AuthorHandle(name)
+	if utf8.RuneCountInString(handle) > model.MaxAuthorHandleLength {
+		return model.Author{}, service.InvalidArgument(errors.New("author name is too long"))
+	}
+
+	author, err := s.database.GetByHandle(ctx, handle)
+	if err == nil {
+		return author, nil
+	} else if !errors.Is(err, service.ErrAuthorNotFound) {
+		return author, err
+	}
+
+	now := time.Now().UTC()
+	return s.database.Create(ctx, model.Author{
+		Handle:    handle,
+		Name:      name,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+}
@@ -0,0 +1,148 @@
+package author_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prabudzak/article/model"
+	"github.com/prabudzak/article/service"
+	"github.com/prabudzak/article/service/author"
+	"github.com/prabudzak/article/service/author/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+type dependency struct {
+	database *mock.MockDatabase
+}
+
+func initialize(ctrl *gomock.Controller) dependency {
+	return dependency{
+		database: mock.NewMockDatabase(ctrl),
+	}
+}
+
+func TestGetAuthor(t *testing.T) {
+	tests := []struct {
+		name           string
+		handle         string
+		dbGetErr       error
+		expectedHandle string
+		expectErr      bool
+	}{
+		{
+			name:           "author retrieved",
+			handle:         "john-doe",
+			expectedHandle: "john-doe",
+			expectErr:      false,
+		},
+		{
+			name:           "author retrieved, handle normalized",
+			handle:         "John Doe",
+			expectedHandle: "john-doe",
+			expectErr:      false,
+		},
+		{
+			name:      "blank handle",
+			handle:    "  ",
+			expectErr: true,
+		},
+		{
+			name:           "author not found",
+			handle:         "john-doe",
+			dbGetErr:       service.ErrAuthorNotFound,
+			expectedHandle: "john-doe",
+			expectErr:      true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dep := initialize(ctrl)
+			dep.database.EXPECT().GetByHandle(gomock.Any(), tc.expectedHandle).MaxTimes(1).Return(model.Author{Handle: tc.expectedHandle}, tc.dbGetErr)
+
+			authorService := author.NewAuthorService(dep.database)
+
+			_, err := authorService.GetAuthor(context.Background(), tc.handle)
+			assert.Equal(t, tc.expectErr, err != nil)
+		})
+	}
+}
+
+func TestGetOrCreateAuthor(t *testing.T) {
+	tests := []struct {
+		name         string
+		authorName   string
+		dbGetErr     error
+		dbCreateErr  error
+		expectCreate bool
+		expectErr    bool
+	}{
+		{
+			name:       "existing author retrieved",
+			authorName: "John  doe",
+			expectErr:  false,
+		},
+		{
+			name:         "new author created",
+			authorName:   "John Doe",
+			dbGetErr:     service.ErrAuthorNotFound,
+			expectCreate: true,
+			expectErr:    false,
+		},
+		{
+			name:       "blank author name",
+			authorName: " ",
+			expectErr:  true,
+		},
+		{
+			name:       "author name too long",
+			authorName: strings.Repeat("John Doe ", 8),
+			expectErr:  true,
+		},
+		{
+			name:       "unable to retrieve author",
+			authorName: "John Doe",
+			dbGetErr:   assert.AnError,
+			expectErr:  true,
+		},
+		{
+			name:         "unable to create author",
+			authorName:   "John Doe",
+			dbGetErr:     service.ErrAuthorNotFound,
+			dbCreateErr:  assert.AnError,
+			expectCreate: true,
+			expectErr:    true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dep := initialize(ctrl)
+			dep.database.EXPECT().GetByHandle(gomock.Any(), "john-doe").MaxTimes(1).Return(model.Author{ID: 1, Handle: "john-doe", Name: "John Doe"}, tc.dbGetErr)
+
+			createTimes := 0
+			if tc.expectCreate {
+				createTimes = 1
+			}
+			dep.database.EXPECT().Create(gomock.Any(), gomock.Any()).Times(createTimes).DoAndReturn(func(ctx context.Context, a model.Author) (model.Author, error) {
+				assert.Equal(t, "john-doe", a.Handle)
+				assert.Equal(t, "John Doe", a.Name)
+				a.ID = 1
+				return a, tc.dbCreateErr
+			})
+
+			authorService := author.NewAuthorService(dep.database)
+
+			_, err := authorService.GetOrCreateAuthor(context.Background(), tc.authorName)
+			assert.Equal(t, tc.expectErr, err != nil)
+		})
+	}
+}
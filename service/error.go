@@ -1,11 +1,149 @@
 package service
 
-import "errors"
+import (
+	"context"
+	"errors"
+)
+
+// Kind represent category of a service error, telling callers how the
+// failure should be handled regardless of its cause
+type Kind string
+
+// Service error kinds
+const (
+	KindInternal         Kind = "internal"
+	KindNotFound         Kind = "not_found"
+	KindInvalidArgument  Kind = "invalid_argument"
+	KindConflict         Kind = "conflict"
+	KindUnavailable      Kind = "unavailable"
+	KindPermissionDenied Kind = "permission_denied"
+	KindUnauthenticated  Kind = "unauthenticated"
+)
+
+// Error represent typed service error. Code is a stable machine readable
+// identifier of the error and Err is the optional wrapped cause
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Err     error
+}
+
+// NewError create a new service error of given kind
+func NewError(kind Kind, code string, message string) *Error {
+	return &Error{
+		Kind:    kind,
+		Code:    code,
+		Message: message,
+	}
+}
+
+// Error return the error message, followed by the wrapped cause if any
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+	return e.Message + ": " + e.Err.Error()
+}
+
+// Unwrap return the wrapped cause
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is report whether target is a service error of the same kind and code, so
+// wrapped copies still match their sentinel with errors.Is
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	return e.Kind == t.Kind && e.Code == t.Code
+}
 
-// GeneralError repesent general service error
-type GeneralError error
+// Wrap return a copy of the error wrapping the given cause
+func (e *Error) Wrap(err error) *Error {
+	wrapped := *e
+	wrapped.Err = err
+	return &wrapped
+}
 
 var (
+	// ErrInvalidArgument represent invalid request argument service error
+	ErrInvalidArgument = NewError(KindInvalidArgument, "invalid_argument", "invalid argument")
+	// ErrUnavailable represent temporarily unavailable dependency service error
+	ErrUnavailable = NewError(KindUnavailable, "unavailable", "service unavailable")
+	// ErrPermissionDenied represent caller is not allowed to perform the
+	// operation service error
+	ErrPermissionDenied = NewError(KindPermissionDenied, "permission_denied", "permission denied")
+	// ErrUnauthenticated represent missing or invalid caller credentials
+	// service error
+	ErrUnauthenticated = NewError(KindUnauthenticated, "unauthenticated", "unauthenticated")
 	// ErrArticleNotFound represent article not found service error
-	ErrArticleNotFound GeneralError = errors.New("article not found")
+	ErrArticleNotFound = NewError(KindNotFound, "article_not_found", "article not found")
+	// ErrArticleArchived represent archived article can not be published service error
+	ErrArticleArchived = NewError(KindConflict, "article_archived", "article is archived")
+	// ErrArticleVersionConflict represent article was modified since the
+	// version the write is based on service error
+	ErrArticleVersionConflict = NewError(KindConflict, "article_version_conflict", "article version conflict")
+	// ErrRevisionNotFound represent article revision not found service error
+	ErrRevisionNotFound = NewError(KindNotFound, "revision_not_found", "article revision not found")
+	// ErrAuthorNotFound represent author not found service error
+	ErrAuthorNotFound = NewError(KindNotFound, "author_not_found", "author not found")
+	// ErrIdempotencyKeyNotFound represent idempotency key not found service error
+	ErrIdempotencyKeyNotFound = NewError(KindNotFound, "idempotency_key_not_found", "idempotency key not found")
+	// ErrIdempotencyKeyReused represent idempotency key reused for a different
+	// request service error
+	ErrIdempotencyKeyReused = NewError(KindInvalidArgument, "idempotency_key_reused", "idempotency key was used for a different request")
+	// ErrAPIKeyNotFound represent API key not found service error
+	ErrAPIKeyNotFound = NewError(KindNotFound, "api_key_not_found", "api key not found")
+	// ErrIdempotencyKeyInProgress represent the request of idempotency key is
+	// still being processed service error
+	ErrIdempotencyKeyInProgress = NewError(KindConflict, "idempotency_key_in_progress", "request with the idempotency key is in progress")
 )
+
+// InvalidArgument wrap err as an invalid argument service error
+func InvalidArgument(err error) error {
+	return ErrInvalidArgument.Wrap(err)
+}
+
+// Unauthenticated wrap err as an unauthenticated service error
+func Unauthenticated(err error) error {
+	return ErrUnauthenticated.Wrap(err)
+}
+
+// PermissionDenied wrap err as a permission denied service error
+func PermissionDenied(err error) error {
+	return ErrPermissionDenied.Wrap(err)
+}
+
+// Unavailable wrap err as an unavailable service error
+func Unavailable(err error) error {
+	return ErrUnavailable.Wrap(err)
+}
+
+// KindOf return the kind of the outermost service error in err chain.
+// Expired or canceled context is unavailable, any other error is internal
+func KindOf(err error) Kind {
+	var serviceErr *Error
+	if errors.As(err, &serviceErr) {
+		return serviceErr.Kind
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return KindUnavailable
+	}
+
+	return KindInternal
+}
+
+// CodeOf return the machine readable code of the outermost service error in
+// err chain, or the kind of err when it has no code
+func CodeOf(err error) string {
+	var serviceErr *Error
+	if errors.As(err, &serviceErr) && serviceErr.Code != "" {
+		return serviceErr.Code
+	}
+
+	return string(KindOf(err))
+}
@@ -0,0 +1,77 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/prabudzak/article/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestError(t *testing.T) {
+	cause := errors.New("connection refused")
+
+	tests := []struct {
+		name         string
+		err          error
+		sentinel     error
+		expectedKind service.Kind
+		expectedCode string
+	}{
+		{
+			name:         "sentinel",
+			err:          service.ErrArticleNotFound,
+			sentinel:     service.ErrArticleNotFound,
+			expectedKind: service.KindNotFound,
+			expectedCode: "article_not_found",
+		},
+		{
+			name:         "wrapped cause",
+			err:          service.Unavailable(cause),
+			sentinel:     cause,
+			expectedKind: service.KindUnavailable,
+			expectedCode: "unavailable",
+		},
+		{
+			name:         "wrapped sentinel",
+			err:          fmt.Errorf("publish article 1: %w", service.ErrArticleVersionConflict),
+			sentinel:     service.ErrArticleVersionConflict,
+			expectedKind: service.KindConflict,
+			expectedCode: "article_version_conflict",
+		},
+		{
+			name:         "copy of sentinel",
+			err:          service.ErrInvalidArgument.Wrap(cause),
+			sentinel:     service.ErrInvalidArgument,
+			expectedKind: service.KindInvalidArgument,
+			expectedCode: "invalid_argument",
+		},
+		{
+			name:         "expired context",
+			err:          context.DeadlineExceeded,
+			sentinel:     context.DeadlineExceeded,
+			expectedKind: service.KindUnavailable,
+			expectedCode: "unavailable",
+		},
+		{
+			name:         "untyped error",
+			err:          cause,
+			sentinel:     cause,
+			expectedKind: service.KindInternal,
+			expectedCode: "internal",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.True(t, errors.Is(tc.err, tc.sentinel))
+			assert.Equal(t, tc.expectedKind, service.KindOf(tc.err))
+			assert.Equal(t, tc.expectedCode, service.CodeOf(tc.err))
+		})
+	}
+
+	assert.Equal(t, "service unavailable: connection refused", service.Unavailable(cause).Error())
+	assert.False(t, errors.Is(service.ErrArticleNotFound, service.ErrAuthorNotFound))
+}
@@ -0,0 +1,98 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis"
+
+	"github.com/prabudzak/article/logger"
+	"github.com/prabudzak/article/model"
+	"github.com/prabudzak/article/service"
+)
+
+const idempotencyKey = "20210130/idempotency/%s"
+
+// IdempotencyStore represent idempotency record store redis implementation
+type IdempotencyStore struct {
+	client *redis.Client
+}
+
+// NewIdempotencyStore create a new instance of redis implementation
+// idempotency record store
+func NewIdempotencyStore(redisClient *redis.Client) *IdempotencyStore {
+	return &IdempotencyStore{
+		client: redisClient,
+	}
+}
+
+// Reserve write a record unless one with the same key exists, reporting
+// whether it was written
+func (i *IdempotencyStore) Reserve(ctx context.Context, record model.IdempotencyRecord, ttl time.Duration) (bool, error) {
+	if record.Key == "" {
+		return false, errors.New("idempotency key is invalid")
+	}
+
+	jsoned, _ := json.Marshal(record)
+
+	key := fmt.Sprintf(idempotencyKey, record.Key)
+	reserved, err := i.client.SetNX(key, jsoned, ttl).Result()
+	if err != nil {
+		logger.FromContext(ctx).Error("idempotency record not reserved", logger.String("idempotency_key", record.Key), logger.Err(err))
+		return false, service.Unavailable(err)
+	}
+
+	return reserved, nil
+}
+
+// Get retrieve a record by idempotency key
+func (i *IdempotencyStore) Get(ctx context.Context, key string) (model.IdempotencyRecord, error) {
+	var record model.IdempotencyRecord
+
+	result, err := i.client.Get(fmt.Sprintf(idempotencyKey, key)).Result()
+	if err == redis.Nil {
+		return record, service.ErrIdempotencyKeyNotFound
+	} else if err != nil {
+		logger.FromContext(ctx).Error("idempotency record not retrieved", logger.String("idempotency_key", key), logger.Err(err))
+		return record, service.Unavailable(err)
+	}
+
+	err = json.Unmarshal([]byte(result), &record)
+	if err != nil {
+		logger.FromContext(ctx).Error("idempotency record not retrieved", logger.String("idempotency_key", key), logger.Err(err))
+		return record, err
+	}
+
+	return record, nil
+}
+
+// Save overwrite a record
+func (i *IdempotencyStore) Save(ctx context.Context, record model.IdempotencyRecord, ttl time.Duration) error {
+	if record.Key == "" {
+		return errors.New("idempotency key is invalid")
+	}
+
+	jsoned, _ := json.Marshal(record)
+
+	err := i.client.Set(fmt.Sprintf(idempotencyKey, record.Key), jsoned, ttl).Err()
+	if err != nil {
+		logger.FromContext(ctx).Error("idempotency record not saved", logger.String("idempotency_key", record.Key), logger.Err(err))
+		return service.Unavailable(err)
+	}
+
+	return nil
+}
+
+// Delete remove a record by idempotency key
+func (i *IdempotencyStore) Delete(ctx context.Context, key string) error {
+	err := i.client.Del(fmt.Sprintf(idempotencyKey, key)).Err()
+	if err != nil {
+		logger.FromContext(ctx).Error("idempotency record not deleted", logger.String("idempotency_key", key), logger.Err(err))
+		return service.Unavailable(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+
+	"github.com/prabudzak/article/model"
+	"github.com/prabudzak/article/service"
+)
+
+//go:generate mockgen -package=mock -source=service.go -destination=mock/service.go
+
+const (
+	// defaultTTL is how long an idempotency key is remembered when not configured
+	defaultTTL = 24 * time.Hour
+	// reservationTTL is how long a key is reserved for a request still being
+	// processed, so the key of a node dying midway is freed for retries long
+	// before its completed response would expire
+	reservationTTL = 5 * time.Minute
+)
+
+// Store represent idempotency record storage expiring records after ttl
+type Store interface {
+	Reserve(ctx context.Context, record model.IdempotencyRecord, ttl time.Duration) (bool, error)
+	Get(ctx context.Context, key string) (model.IdempotencyRecord, error)
+	Save(ctx context.Context, record model.IdempotencyRecord, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+}
+
+// Service represent idempotency service implementation
+type Service struct {
+	store          Store
+	ttl            time.Duration
+	reservationTTL time.Duration
+}
+
+// NewIdempotencyService create a new idempotency service instance remembering
+// keys for ttl, default to 24 hours. Keys of requests still being processed
+// are reserved for 5 minutes at most
+func NewIdempotencyService(store Store, ttl time.Duration) *Service {
+	if ttl <= 0 {
+		ttl = defaultTTL
+	}
+
+	reservation := reservationTTL
+	if ttl < reservation {
+		reservation = ttl
+	}
+
+	return &Service{
+		store:          store,
+		ttl:            ttl,
+		reservationTTL: reservation,
+	}
+}
+
+// Begin reserve an idempotency key for a request identified by its hash.
+// A zero record is returned when the request is new and should be processed.
+// A completed record is returned when the request was already processed and
+// its response should be replayed
+func (s *Service) Begin(ctx context.Context, key string, requestHash string) (model.IdempotencyRecord, error) {
+	record := model.IdempotencyRecord{
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   time.Now().UTC(),
+	}
+
+	reserved, err := s.store.Reserve(ctx, record, s.reservationTTL)
+	if err != nil {
+		return model.IdempotencyRecord{}, err
+	} else if reserved {
+		return model.IdempotencyRecord{}, nil
+	}
+
+	existing, err := s.store.Get(ctx, key)
+	if err != nil {
+		return model.IdempotencyRecord{}, err
+	}
+
+	if existing.RequestHash != requestHash {
+		return model.IdempotencyRecord{}, service.ErrIdempotencyKeyReused
+	}
+
+	if !existing.Completed {
+		return model.IdempotencyRecord{}, service.ErrIdempotencyKeyInProgress
+	}
+
+	return existing, nil
+}
+
+// Complete store the response of a reserved request to be replayed on its
+// retries
+func (s *Service) Complete(ctx context.Context, record model.IdempotencyRecord) error {
+	record.Completed = true
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now().UTC()
+	}
+
+	return s.store.Save(ctx, record, s.ttl)
+}
+
+// Release forget a reserved request which could not be processed, so its
+// retries are processed again
+func (s *Service) Release(ctx context.Context, key string) error {
+	return s.store.Delete(ctx, key)
+}
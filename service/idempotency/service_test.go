@@ -0,0 +1,130 @@
+package idempotency_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prabudzak/article/model"
+	"github.com/prabudzak/article/service"
+	"github.com/prabudzak/article/service/idempotency"
+	"github.com/prabudzak/article/service/idempotency/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+type dependency struct {
+	store *mock.MockStore
+}
+
+func initialize(ctrl *gomock.Controller) dependency {
+	return dependency{
+		store: mock.NewMockStore(ctrl),
+	}
+}
+
+func TestBegin(t *testing.T) {
+	tests := []struct {
+		name           string
+		reserved       bool
+		reserveErr     error
+		existing       model.IdempotencyRecord
+		getErr         error
+		expectedRecord model.IdempotencyRecord
+		expectedErr    error
+	}{
+		{
+			name:     "new request reserved",
+			reserved: true,
+		},
+		{
+			name: "completed request replayed",
+			existing: model.IdempotencyRecord{
+				Key:         "key",
+				RequestHash: "hash",
+				Completed:   true,
+				StatusCode:  201,
+				Body:        []byte(`{"message":"article created"}`),
+			},
+			expectedRecord: model.IdempotencyRecord{
+				Key:         "key",
+				RequestHash: "hash",
+				Completed:   true,
+				StatusCode:  201,
+				Body:        []byte(`{"message":"article created"}`),
+			},
+		},
+		{
+			name:        "key reused for different request",
+			existing:    model.IdempotencyRecord{Key: "key", RequestHash: "other-hash", Completed: true},
+			expectedErr: service.ErrIdempotencyKeyReused,
+		},
+		{
+			name:        "request in progress",
+			existing:    model.IdempotencyRecord{Key: "key", RequestHash: "hash"},
+			expectedErr: service.ErrIdempotencyKeyInProgress,
+		},
+		{
+			name:        "unable to reserve",
+			reserveErr:  assert.AnError,
+			expectedErr: assert.AnError,
+		},
+		{
+			name:        "unable to get existing request",
+			getErr:      assert.AnError,
+			expectedErr: assert.AnError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dep := initialize(ctrl)
+			dep.store.EXPECT().Reserve(gomock.Any(), gomock.Any(), 5*time.Minute).DoAndReturn(
+				func(ctx context.Context, record model.IdempotencyRecord, ttl time.Duration) (bool, error) {
+					assert.Equal(t, "key", record.Key)
+					assert.Equal(t, "hash", record.RequestHash)
+					assert.False(t, record.Completed)
+					return tc.reserved, tc.reserveErr
+				})
+			dep.store.EXPECT().Get(gomock.Any(), "key").MaxTimes(1).Return(tc.existing, tc.getErr)
+
+			s := idempotency.NewIdempotencyService(dep.store, time.Hour)
+			record, err := s.Begin(context.Background(), "key", "hash")
+			assert.Equal(t, tc.expectedErr, err)
+			assert.Equal(t, tc.expectedRecord, record)
+		})
+	}
+}
+
+func TestBeginShortTTL(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dep := initialize(ctrl)
+	dep.store.EXPECT().Reserve(gomock.Any(), gomock.Any(), time.Minute).Return(true, nil)
+
+	s := idempotency.NewIdempotencyService(dep.store, time.Minute)
+	_, err := s.Begin(context.Background(), "key", "hash")
+	assert.NoError(t, err)
+}
+
+func TestComplete(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dep := initialize(ctrl)
+	dep.store.EXPECT().Save(gomock.Any(), gomock.Any(), 24*time.Hour).DoAndReturn(
+		func(ctx context.Context, record model.IdempotencyRecord, ttl time.Duration) error {
+			assert.True(t, record.Completed)
+			assert.Equal(t, 201, record.StatusCode)
+			assert.False(t, record.CreatedAt.IsZero())
+			return nil
+		})
+
+	s := idempotency.NewIdempotencyService(dep.store, 0)
+	err := s.Complete(context.Background(), model.IdempotencyRecord{Key: "key", RequestHash: "hash", StatusCode: 201})
+	assert.NoError(t, err)
+}
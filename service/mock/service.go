@@ -9,6 +9,7 @@ import (
 	gomock "github.com/golang/mock/gomock"
 	model "github.com/prabudzak/article/model"
 	reflect "reflect"
+	time "time"
 )
 
 // MockArticleService is a mock of ArticleService interface
@@ -35,11 +36,12 @@ func (m *MockArticleService) EXPECT() *MockArticleServiceMockRecorder {
 }
 
 // CreateArticle mocks base method
-func (m *MockArticleService) CreateArticle(ctx context.Context, article model.Article) error {
+func (m *MockArticleService) CreateArticle(ctx context.Context, article model.Article) (model.Article, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreateArticle", ctx, article)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(model.Article)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // CreateArticle indicates an expected call of CreateArticle
@@ -48,6 +50,21 @@ func (mr *MockArticleServiceMockRecorder) CreateArticle(ctx, article interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateArticle", reflect.TypeOf((*MockArticleService)(nil).CreateArticle), ctx, article)
 }
 
+// ImportArticles mocks base method
+func (m *MockArticleService) ImportArticles(ctx context.Context, articles []model.Article) ([]model.ArticleImportResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportArticles", ctx, articles)
+	ret0, _ := ret[0].([]model.ArticleImportResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportArticles indicates an expected call of ImportArticles
+func (mr *MockArticleServiceMockRecorder) ImportArticles(ctx, articles interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportArticles", reflect.TypeOf((*MockArticleService)(nil).ImportArticles), ctx, articles)
+}
+
 // SearchArticle mocks base method
 func (m *MockArticleService) SearchArticle(ctx context.Context, query model.ArticleSearchQuery) ([]model.Article, error) {
 	m.ctrl.T.Helper()
@@ -62,3 +79,392 @@ func (mr *MockArticleServiceMockRecorder) SearchArticle(ctx, query interface{})
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchArticle", reflect.TypeOf((*MockArticleService)(nil).SearchArticle), ctx, query)
 }
+
+// ExportArticle mocks base method
+func (m *MockArticleService) ExportArticle(ctx context.Context, query model.ArticleSearchQuery, fn func(model.Article) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportArticle", ctx, query, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportArticle indicates an expected call of ExportArticle
+func (mr *MockArticleServiceMockRecorder) ExportArticle(ctx, query, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportArticle", reflect.TypeOf((*MockArticleService)(nil).ExportArticle), ctx, query, fn)
+}
+
+// RelatedArticle mocks base method
+func (m *MockArticleService) RelatedArticle(ctx context.Context, query model.ArticleRelatedQuery) ([]model.Article, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RelatedArticle", ctx, query)
+	ret0, _ := ret[0].([]model.Article)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RelatedArticle indicates an expected call of RelatedArticle
+func (mr *MockArticleServiceMockRecorder) RelatedArticle(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RelatedArticle", reflect.TypeOf((*MockArticleService)(nil).RelatedArticle), ctx, query)
+}
+
+// ListTag mocks base method
+func (m *MockArticleService) ListTag(ctx context.Context, query model.TagQuery) ([]model.TagCount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTag", ctx, query)
+	ret0, _ := ret[0].([]model.TagCount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTag indicates an expected call of ListTag
+func (mr *MockArticleServiceMockRecorder) ListTag(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTag", reflect.TypeOf((*MockArticleService)(nil).ListTag), ctx, query)
+}
+
+// LastModified mocks base method
+func (m *MockArticleService) LastModified(ctx context.Context) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LastModified", ctx)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LastModified indicates an expected call of LastModified
+func (mr *MockArticleServiceMockRecorder) LastModified(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LastModified", reflect.TypeOf((*MockArticleService)(nil).LastModified), ctx)
+}
+
+// GetArticle mocks base method
+func (m *MockArticleService) GetArticle(ctx context.Context, id int) (model.Article, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetArticle", ctx, id)
+	ret0, _ := ret[0].(model.Article)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetArticle indicates an expected call of GetArticle
+func (mr *MockArticleServiceMockRecorder) GetArticle(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetArticle", reflect.TypeOf((*MockArticleService)(nil).GetArticle), ctx, id)
+}
+
+// UpdateArticle mocks base method
+func (m *MockArticleService) UpdateArticle(ctx context.Context, article model.Article) (model.Article, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateArticle", ctx, article)
+	ret0, _ := ret[0].(model.Article)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateArticle indicates an expected call of UpdateArticle
+func (mr *MockArticleServiceMockRecorder) UpdateArticle(ctx, article interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateArticle", reflect.TypeOf((*MockArticleService)(nil).UpdateArticle), ctx, article)
+}
+
+// PublishArticle mocks base method
+func (m *MockArticleService) PublishArticle(ctx context.Context, id int) (model.Article, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishArticle", ctx, id)
+	ret0, _ := ret[0].(model.Article)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublishArticle indicates an expected call of PublishArticle
+func (mr *MockArticleServiceMockRecorder) PublishArticle(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishArticle", reflect.TypeOf((*MockArticleService)(nil).PublishArticle), ctx, id)
+}
+
+// ArchiveArticle mocks base method
+func (m *MockArticleService) ArchiveArticle(ctx context.Context, id int) (model.Article, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ArchiveArticle", ctx, id)
+	ret0, _ := ret[0].(model.Article)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ArchiveArticle indicates an expected call of ArchiveArticle
+func (mr *MockArticleServiceMockRecorder) ArchiveArticle(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ArchiveArticle", reflect.TypeOf((*MockArticleService)(nil).ArchiveArticle), ctx, id)
+}
+
+// ListArticleRevision mocks base method
+func (m *MockArticleService) ListArticleRevision(ctx context.Context, id int) ([]model.ArticleRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListArticleRevision", ctx, id)
+	ret0, _ := ret[0].([]model.ArticleRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListArticleRevision indicates an expected call of ListArticleRevision
+func (mr *MockArticleServiceMockRecorder) ListArticleRevision(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListArticleRevision", reflect.TypeOf((*MockArticleService)(nil).ListArticleRevision), ctx, id)
+}
+
+// GetArticleRevision mocks base method
+func (m *MockArticleService) GetArticleRevision(ctx context.Context, id, revision int) (model.ArticleRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetArticleRevision", ctx, id, revision)
+	ret0, _ := ret[0].(model.ArticleRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetArticleRevision indicates an expected call of GetArticleRevision
+func (mr *MockArticleServiceMockRecorder) GetArticleRevision(ctx, id, revision interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetArticleRevision", reflect.TypeOf((*MockArticleService)(nil).GetArticleRevision), ctx, id, revision)
+}
+
+// DiffArticleRevision mocks base method
+func (m *MockArticleService) DiffArticleRevision(ctx context.Context, id, from, to int) (model.ArticleRevisionDiff, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DiffArticleRevision", ctx, id, from, to)
+	ret0, _ := ret[0].(model.ArticleRevisionDiff)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DiffArticleRevision indicates an expected call of DiffArticleRevision
+func (mr *MockArticleServiceMockRecorder) DiffArticleRevision(ctx, id, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiffArticleRevision", reflect.TypeOf((*MockArticleService)(nil).DiffArticleRevision), ctx, id, from, to)
+}
+
+// RestoreArticleRevision mocks base method
+func (m *MockArticleService) RestoreArticleRevision(ctx context.Context, id, revision int) (model.Article, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreArticleRevision", ctx, id, revision)
+	ret0, _ := ret[0].(model.Article)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreArticleRevision indicates an expected call of RestoreArticleRevision
+func (mr *MockArticleServiceMockRecorder) RestoreArticleRevision(ctx, id, revision interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreArticleRevision", reflect.TypeOf((*MockArticleService)(nil).RestoreArticleRevision), ctx, id, revision)
+}
+
+// MockAuthorService is a mock of AuthorService interface
+type MockAuthorService struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuthorServiceMockRecorder
+}
+
+// MockAuthorServiceMockRecorder is the mock recorder for MockAuthorService
+type MockAuthorServiceMockRecorder struct {
+	mock *MockAuthorService
+}
+
+// NewMockAuthorService creates a new mock instance
+func NewMockAuthorService(ctrl *gomock.Controller) *MockAuthorService {
+	mock := &MockAuthorService{ctrl: ctrl}
+	mock.recorder = &MockAuthorServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockAuthorService) EXPECT() *MockAuthorServiceMockRecorder {
+	return m.recorder
+}
+
+// GetAuthor mocks base method
+func (m *MockAuthorService) GetAuthor(ctx context.Context, handle string) (model.Author, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuthor", ctx, handle)
+	ret0, _ := ret[0].(model.Author)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuthor indicates an expected call of GetAuthor
+func (mr *MockAuthorServiceMockRecorder) GetAuthor(ctx, handle interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuthor", reflect.TypeOf((*MockAuthorService)(nil).GetAuthor), ctx, handle)
+}
+
+// MockAuthService is a mock of AuthService interface
+type MockAuthService struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuthServiceMockRecorder
+}
+
+// MockAuthServiceMockRecorder is the mock recorder for MockAuthService
+type MockAuthServiceMockRecorder struct {
+	mock *MockAuthService
+}
+
+// NewMockAuthService creates a new mock instance
+func NewMockAuthService(ctrl *gomock.Controller) *MockAuthService {
+	mock := &MockAuthService{ctrl: ctrl}
+	mock.recorder = &MockAuthServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockAuthService) EXPECT() *MockAuthServiceMockRecorder {
+	return m.recorder
+}
+
+// AuthenticateAPIKey mocks base method
+func (m *MockAuthService) AuthenticateAPIKey(ctx context.Context, key string) (model.Principal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthenticateAPIKey", ctx, key)
+	ret0, _ := ret[0].(model.Principal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthenticateAPIKey indicates an expected call of AuthenticateAPIKey
+func (mr *MockAuthServiceMockRecorder) AuthenticateAPIKey(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthenticateAPIKey", reflect.TypeOf((*MockAuthService)(nil).AuthenticateAPIKey), ctx, key)
+}
+
+// AuthenticateToken mocks base method
+func (m *MockAuthService) AuthenticateToken(ctx context.Context, token string) (model.Principal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthenticateToken", ctx, token)
+	ret0, _ := ret[0].(model.Principal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthenticateToken indicates an expected call of AuthenticateToken
+func (mr *MockAuthServiceMockRecorder) AuthenticateToken(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthenticateToken", reflect.TypeOf((*MockAuthService)(nil).AuthenticateToken), ctx, token)
+}
+
+// MockRateLimitService is a mock of RateLimitService interface
+type MockRateLimitService struct {
+	ctrl     *gomock.Controller
+	recorder *MockRateLimitServiceMockRecorder
+}
+
+// MockRateLimitServiceMockRecorder is the mock recorder for MockRateLimitService
+type MockRateLimitServiceMockRecorder struct {
+	mock *MockRateLimitService
+}
+
+// NewMockRateLimitService creates a new mock instance
+func NewMockRateLimitService(ctrl *gomock.Controller) *MockRateLimitService {
+	mock := &MockRateLimitService{ctrl: ctrl}
+	mock.recorder = &MockRateLimitServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockRateLimitService) EXPECT() *MockRateLimitServiceMockRecorder {
+	return m.recorder
+}
+
+// Allow mocks base method
+func (m *MockRateLimitService) Allow(ctx context.Context, client, method, path string) (model.RateLimitResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Allow", ctx, client, method, path)
+	ret0, _ := ret[0].(model.RateLimitResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Allow indicates an expected call of Allow
+func (mr *MockRateLimitServiceMockRecorder) Allow(ctx, client, method, path interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Allow", reflect.TypeOf((*MockRateLimitService)(nil).Allow), ctx, client, method, path)
+}
+
+// Check mocks base method
+func (m *MockRateLimitService) Check(ctx context.Context, client, method, path string) (model.RateLimitResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Check", ctx, client, method, path)
+	ret0, _ := ret[0].(model.RateLimitResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Check indicates an expected call of Check
+func (mr *MockRateLimitServiceMockRecorder) Check(ctx, client, method, path interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*MockRateLimitService)(nil).Check), ctx, client, method, path)
+}
+
+// MockIdempotencyService is a mock of IdempotencyService interface
+type MockIdempotencyService struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdempotencyServiceMockRecorder
+}
+
+// MockIdempotencyServiceMockRecorder is the mock recorder for MockIdempotencyService
+type MockIdempotencyServiceMockRecorder struct {
+	mock *MockIdempotencyService
+}
+
+// NewMockIdempotencyService creates a new mock instance
+func NewMockIdempotencyService(ctrl *gomock.Controller) *MockIdempotencyService {
+	mock := &MockIdempotencyService{ctrl: ctrl}
+	mock.recorder = &MockIdempotencyServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockIdempotencyService) EXPECT() *MockIdempotencyServiceMockRecorder {
+	return m.recorder
+}
+
+// Begin mocks base method
+func (m *MockIdempotencyService) Begin(ctx context.Context, key, requestHash string) (model.IdempotencyRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Begin", ctx, key, requestHash)
+	ret0, _ := ret[0].(model.IdempotencyRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Begin indicates an expected call of Begin
+func (mr *MockIdempotencyServiceMockRecorder) Begin(ctx, key, requestHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Begin", reflect.TypeOf((*MockIdempotencyService)(nil).Begin), ctx, key, requestHash)
+}
+
+// Complete mocks base method
+func (m *MockIdempotencyService) Complete(ctx context.Context, record model.IdempotencyRecord) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Complete", ctx, record)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Complete indicates an expected call of Complete
+func (mr *MockIdempotencyServiceMockRecorder) Complete(ctx, record interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Complete", reflect.TypeOf((*MockIdempotencyService)(nil).Complete), ctx, record)
+}
+
+// Release mocks base method
+func (m *MockIdempotencyService) Release(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Release", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Release indicates an expected call of Release
+func (mr *MockIdempotencyServiceMockRecorder) Release(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockIdempotencyService)(nil).Release), ctx, key)
+}
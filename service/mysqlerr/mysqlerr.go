@@ -0,0 +1,26 @@
+// Package mysqlerr classify errors of mysql database implementations
+package mysqlerr
+
+import (
+	"database/sql/driver"
+	"errors"
+	"net"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+
+	"github.com/prabudzak/article/service"
+)
+
+// Wrap mark connection failure to mysql server as unavailable service error.
+// Errors already classified by service are returned as is
+func Wrap(err error) error {
+	if err == nil || service.KindOf(err) != service.KindInternal {
+		return err
+	}
+
+	var netErr net.Error
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysqldriver.ErrInvalidConn) || errors.As(err, &netErr) {
+		return service.Unavailable(err)
+	}
+	return err
+}
@@ -0,0 +1,51 @@
+package mysqlerr_test
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"testing"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/prabudzak/article/service"
+	"github.com/prabudzak/article/service/mysqlerr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		expectedKind service.Kind
+	}{
+		{
+			name:         "bad connection",
+			err:          driver.ErrBadConn,
+			expectedKind: service.KindUnavailable,
+		},
+		{
+			name:         "wrapped invalid connection",
+			err:          fmt.Errorf("query: %w", mysqldriver.ErrInvalidConn),
+			expectedKind: service.KindUnavailable,
+		},
+		{
+			name:         "query failure",
+			err:          assert.AnError,
+			expectedKind: service.KindInternal,
+		},
+		{
+			name:         "service error",
+			err:          service.ErrArticleNotFound,
+			expectedKind: service.KindNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := mysqlerr.Wrap(tc.err)
+			assert.ErrorIs(t, err, tc.err)
+			assert.Equal(t, tc.expectedKind, service.KindOf(err))
+		})
+	}
+
+	assert.NoError(t, mysqlerr.Wrap(nil))
+}
@@ -0,0 +1,90 @@
+// Package policy decide which principal may perform which action
+package policy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/prabudzak/article/model"
+	"github.com/prabudzak/article/service"
+)
+
+// Action represent an operation a principal may be allowed to perform
+type Action string
+
+// Policy actions
+const (
+	ActionCreateArticle  Action = "create article"
+	ActionUpdateArticle  Action = "update article"
+	ActionPublishArticle Action = "publish article"
+	ActionArchiveArticle Action = "archive article"
+	ActionRestoreArticle Action = "restore article"
+	ActionDeleteArticle  Action = "delete article"
+	ActionManageAPIKeys  Action = "manage api keys"
+	ActionReindex        Action = "reindex articles"
+	ActionReadArticle    Action = "read unpublished article"
+)
+
+type systemKey struct{}
+
+// WithSystem return a copy of ctx of a trusted internal call, such as the
+// scheduler and command line apps, allowed any action without principal
+func WithSystem(ctx context.Context) context.Context {
+	return context.WithValue(ctx, systemKey{}, true)
+}
+
+// IsSystem report whether ctx is of a trusted internal call
+func IsSystem(ctx context.Context) bool {
+	system, _ := ctx.Value(systemKey{}).(bool)
+	return system
+}
+
+// Authorize return permission denied error unless the principal of ctx may
+// perform action on article. Article is zero for actions not on an article.
+// Calls without principal are rejected as unauthenticated unless they are
+// trusted internal calls
+func Authorize(ctx context.Context, action Action, article model.Article) error {
+	principal, ok := service.PrincipalFrom(ctx)
+	if !ok {
+		if IsSystem(ctx) {
+			return nil
+		}
+		return service.Unauthenticated(fmt.Errorf("caller may not %s without credentials", action))
+	}
+
+	if Allowed(principal, action, article) {
+		return nil
+	}
+
+	return service.PermissionDenied(fmt.Errorf("%s %s may not %s", principal.Role, principal.Name, action))
+}
+
+// Allowed report whether principal may perform action on article. Admins may
+// do anything, editors may read and write any article, authors may read and
+// write their own articles only
+func Allowed(principal model.Principal, action Action, article model.Article) bool {
+	switch principal.Role {
+	case model.RoleAdmin:
+		return true
+	case model.RoleEditor:
+		switch action {
+		case ActionCreateArticle, ActionUpdateArticle, ActionPublishArticle, ActionArchiveArticle, ActionRestoreArticle, ActionReadArticle:
+			return true
+		case ActionDeleteArticle:
+			return Owns(principal, article)
+		}
+	case model.RoleAuthor:
+		switch action {
+		case ActionCreateArticle, ActionUpdateArticle, ActionPublishArticle, ActionArchiveArticle, ActionRestoreArticle, ActionDeleteArticle, ActionReadArticle:
+			return Owns(principal, article)
+		}
+	}
+
+	return false
+}
+
+// Owns report whether article is written by the author principal is bound
+// to. Principal not bound to an author own no article
+func Owns(principal model.Principal, article model.Article) bool {
+	return principal.AuthorID != 0 && principal.AuthorID == article.AuthorID
+}
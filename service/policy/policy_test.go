@@ -0,0 +1,124 @@
+package policy_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prabudzak/article/model"
+	"github.com/prabudzak/article/service"
+	"github.com/prabudzak/article/service/policy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllowed(t *testing.T) {
+	author := model.Principal{Subject: "1", Name: "John Doe", Role: model.RoleAuthor, AuthorID: 1}
+	namesake := model.Principal{Subject: "5", Name: "John Doe", Role: model.RoleAuthor}
+	editor := model.Principal{Subject: "2", Name: "Jane Roe", Role: model.RoleEditor}
+	admin := model.Principal{Subject: "3", Name: "Root", Role: model.RoleAdmin}
+	unknown := model.Principal{Subject: "4", Name: "John Doe", Role: "owner", AuthorID: 1}
+
+	own := model.Article{ID: 1, AuthorID: 1, Author: "John Doe", AuthorHandle: "john-doe"}
+	other := model.Article{ID: 2, AuthorID: 2, Author: "Richard Roe", AuthorHandle: "richard-roe"}
+	ownNew := model.Article{AuthorID: 1, Author: "john  DOE"}
+	otherNew := model.Article{AuthorID: 2, Author: "Richard Roe"}
+
+	tests := []struct {
+		name      string
+		principal model.Principal
+		action    policy.Action
+		article   model.Article
+		expected  bool
+	}{
+		{name: "author create own article", principal: author, action: policy.ActionCreateArticle, article: ownNew, expected: true},
+		{name: "author create article of other author", principal: author, action: policy.ActionCreateArticle, article: otherNew, expected: false},
+		{name: "author update own article", principal: author, action: policy.ActionUpdateArticle, article: own, expected: true},
+		{name: "author update article of other author", principal: author, action: policy.ActionUpdateArticle, article: other, expected: false},
+		{name: "author publish own article", principal: author, action: policy.ActionPublishArticle, article: own, expected: true},
+		{name: "author publish article of other author", principal: author, action: policy.ActionPublishArticle, article: other, expected: false},
+		{name: "author restore own article", principal: author, action: policy.ActionRestoreArticle, article: own, expected: true},
+		{name: "author restore article of other author", principal: author, action: policy.ActionRestoreArticle, article: other, expected: false},
+		{name: "author delete own article", principal: author, action: policy.ActionDeleteArticle, article: own, expected: true},
+		{name: "author delete article of other author", principal: author, action: policy.ActionDeleteArticle, article: other, expected: false},
+		{name: "author manage api keys", principal: author, action: policy.ActionManageAPIKeys, expected: false},
+		{name: "author reindex", principal: author, action: policy.ActionReindex, expected: false},
+
+		{name: "editor create article of other author", principal: editor, action: policy.ActionCreateArticle, article: otherNew, expected: true},
+		{name: "editor update article of other author", principal: editor, action: policy.ActionUpdateArticle, article: other, expected: true},
+		{name: "editor publish article of other author", principal: editor, action: policy.ActionPublishArticle, article: other, expected: true},
+		{name: "editor restore article of other author", principal: editor, action: policy.ActionRestoreArticle, article: other, expected: true},
+		{name: "editor delete article of other author", principal: editor, action: policy.ActionDeleteArticle, article: other, expected: false},
+		{name: "editor manage api keys", principal: editor, action: policy.ActionManageAPIKeys, expected: false},
+		{name: "editor reindex", principal: editor, action: policy.ActionReindex, expected: false},
+
+		{name: "admin create article of other author", principal: admin, action: policy.ActionCreateArticle, article: otherNew, expected: true},
+		{name: "admin update article of other author", principal: admin, action: policy.ActionUpdateArticle, article: other, expected: true},
+		{name: "admin publish article of other author", principal: admin, action: policy.ActionPublishArticle, article: other, expected: true},
+		{name: "admin restore article of other author", principal: admin, action: policy.ActionRestoreArticle, article: other, expected: true},
+		{name: "admin delete article of other author", principal: admin, action: policy.ActionDeleteArticle, article: other, expected: true},
+		{name: "admin manage api keys", principal: admin, action: policy.ActionManageAPIKeys, expected: true},
+		{name: "admin reindex", principal: admin, action: policy.ActionReindex, expected: true},
+
+		{name: "unknown role update own article", principal: unknown, action: policy.ActionUpdateArticle, article: own, expected: false},
+		{name: "author without author id update article without author", principal: model.Principal{Role: model.RoleAuthor}, action: policy.ActionUpdateArticle, article: model.Article{ID: 3}, expected: false},
+		{name: "author archive own article", principal: author, action: policy.ActionArchiveArticle, article: own, expected: true},
+		{name: "author archive article of other author", principal: author, action: policy.ActionArchiveArticle, article: other, expected: false},
+		{name: "editor archive article of other author", principal: editor, action: policy.ActionArchiveArticle, article: own, expected: true},
+		{name: "author named as other author update article of that author", principal: namesake, action: policy.ActionUpdateArticle, article: own, expected: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, policy.Allowed(tc.principal, tc.action, tc.article))
+		})
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	article := model.Article{ID: 1, AuthorID: 1, Author: "John Doe", AuthorHandle: "john-doe"}
+
+	tests := []struct {
+		name         string
+		ctx          context.Context
+		expectedKind service.Kind
+		expectErr    bool
+	}{
+		{
+			name:      "allowed principal",
+			ctx:       service.WithPrincipal(context.Background(), model.Principal{Name: "John Doe", Role: model.RoleAuthor, AuthorID: 1}),
+			expectErr: false,
+		},
+		{
+			name:         "denied principal",
+			ctx:          service.WithPrincipal(context.Background(), model.Principal{Name: "Richard Roe", Role: model.RoleAuthor, AuthorID: 2}),
+			expectedKind: service.KindPermissionDenied,
+			expectErr:    true,
+		},
+		{
+			name:      "internal call",
+			ctx:       policy.WithSystem(context.Background()),
+			expectErr: false,
+		},
+		{
+			name:         "call without principal",
+			ctx:          context.Background(),
+			expectedKind: service.KindUnauthenticated,
+			expectErr:    true,
+		},
+		{
+			name:         "denied principal of internal call",
+			ctx:          service.WithPrincipal(policy.WithSystem(context.Background()), model.Principal{Role: model.RoleAuthor, AuthorID: 2}),
+			expectedKind: service.KindPermissionDenied,
+			expectErr:    true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := policy.Authorize(tc.ctx, policy.ActionUpdateArticle, article)
+			assert.Equal(t, tc.expectErr, err != nil)
+			if tc.expectErr {
+				assert.Equal(t, tc.expectedKind, service.KindOf(err))
+			}
+		})
+	}
+}
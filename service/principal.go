@@ -0,0 +1,20 @@
+package service
+
+import (
+	"context"
+
+	"github.com/prabudzak/article/model"
+)
+
+type principalKey struct{}
+
+// WithPrincipal return a copy of ctx carrying the authenticated principal
+func WithPrincipal(ctx context.Context, principal model.Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFrom return the authenticated principal carried by ctx, if any
+func PrincipalFrom(ctx context.Context) (model.Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(model.Principal)
+	return principal, ok
+}
@@ -0,0 +1,95 @@
+package memory
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/prabudzak/article/model"
+)
+
+// sweepInterval is how often buckets refilled to full are forgotten
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	limit   model.RateLimit
+}
+
+// refill add the tokens accumulated since the bucket was last updated
+func (b *bucket) refill(now time.Time) {
+	elapsed := now.Sub(b.updated)
+	if elapsed > 0 {
+		b.tokens = math.Min(float64(b.limit.Limit), b.tokens+float64(b.limit.Limit)*elapsed.Seconds()/b.limit.Period.Seconds())
+		b.updated = now
+	}
+}
+
+// RateLimitStore represent token bucket store in memory implementation, for
+// a single node
+type RateLimitStore struct {
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	mutex     sync.Mutex
+}
+
+// NewRateLimitStore create a new instance of in memory implementation token
+// bucket store
+func NewRateLimitStore() *RateLimitStore {
+	return &RateLimitStore{
+		buckets: map[string]*bucket{},
+	}
+}
+
+// Take refill the bucket of key, then take a token from it when one is left
+func (r *RateLimitStore) Take(ctx context.Context, key string, limit model.RateLimit, now time.Time) (bool, float64, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.sweep(now)
+
+	b, ok := r.buckets[key]
+	if !ok || b.limit != limit {
+		b = &bucket{tokens: float64(limit.Limit), updated: now, limit: limit}
+		r.buckets[key] = b
+	}
+
+	b.refill(now)
+	if b.tokens < 1 {
+		return false, b.tokens, nil
+	}
+
+	b.tokens--
+	return true, b.tokens, nil
+}
+
+// Peek refill the bucket of key, then report the tokens left in it
+func (r *RateLimitStore) Peek(ctx context.Context, key string, limit model.RateLimit, now time.Time) (float64, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	b, ok := r.buckets[key]
+	if !ok || b.limit != limit {
+		return float64(limit.Limit), nil
+	}
+
+	b.refill(now)
+	return b.tokens, nil
+}
+
+// sweep forget buckets refilled to full, which are the same as new buckets
+func (r *RateLimitStore) sweep(now time.Time) {
+	if now.Sub(r.lastSweep) < sweepInterval {
+		return
+	}
+	r.lastSweep = now
+
+	for key, b := range r.buckets {
+		b.refill(now)
+		if b.tokens >= float64(b.limit.Limit) {
+			delete(r.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prabudzak/article/model"
+	"github.com/prabudzak/article/service/ratelimit/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTake(t *testing.T) {
+	limit := model.RateLimit{Limit: 2, Period: time.Minute}
+	start := time.Now()
+
+	tests := []struct {
+		name           string
+		key            string
+		at             time.Duration
+		expectedTaken  bool
+		expectedTokens float64
+	}{
+		{name: "first token of burst", key: "a", at: 0, expectedTaken: true, expectedTokens: 1},
+		{name: "last token of burst", key: "a", at: 0, expectedTaken: true, expectedTokens: 0},
+		{name: "bucket empty", key: "a", at: 15 * time.Second, expectedTaken: false, expectedTokens: 0.5},
+		{name: "other client bucket", key: "b", at: 15 * time.Second, expectedTaken: true, expectedTokens: 1},
+		{name: "bucket refilled", key: "a", at: 30 * time.Second, expectedTaken: true, expectedTokens: 0},
+		{name: "bucket refilled to full only", key: "a", at: time.Hour, expectedTaken: true, expectedTokens: 1},
+	}
+
+	store := memory.NewRateLimitStore()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			taken, tokens, err := store.Take(context.Background(), tc.key, limit, start.Add(tc.at))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedTaken, taken)
+			assert.InDelta(t, tc.expectedTokens, tokens, 0.001)
+		})
+	}
+}
+
+func TestPeek(t *testing.T) {
+	limit := model.RateLimit{Limit: 2, Period: time.Minute}
+	start := time.Now()
+	store := memory.NewRateLimitStore()
+
+	tokens, err := store.Peek(context.Background(), "a", limit, start)
+	assert.NoError(t, err)
+	assert.InDelta(t, 2, tokens, 0.001)
+
+	_, _, err = store.Take(context.Background(), "a", limit, start)
+	assert.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		tokens, err = store.Peek(context.Background(), "a", limit, start.Add(15*time.Second))
+		assert.NoError(t, err)
+		assert.InDelta(t, 1.5, tokens, 0.001)
+	}
+}
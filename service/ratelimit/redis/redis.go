@@ -0,0 +1,130 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+
+	"github.com/prabudzak/article/logger"
+	"github.com/prabudzak/article/model"
+	"github.com/prabudzak/article/service"
+)
+
+const rateLimitKey = "20210209/ratelimit/%s"
+
+// takeScript refill and take a token from a bucket atomically. The bucket
+// expire once it would be full again, as a new bucket is
+var takeScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "updated")
+local tokens = tonumber(bucket[1])
+local updated = tonumber(bucket[2])
+if tokens == nil or updated == nil then
+  tokens = capacity
+  updated = now
+end
+
+tokens = math.min(capacity, tokens + math.max(0, now - updated) * rate)
+local allowed = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+end
+
+redis.call("HMSET", KEYS[1], "tokens", tostring(tokens), "updated", tostring(now))
+redis.call("PEXPIRE", KEYS[1], math.ceil((capacity - tokens) / rate) + 1000)
+return {allowed, tostring(tokens)}
+`)
+
+// RateLimitStore represent token bucket store redis implementation, shared by
+// every node of a cluster
+type RateLimitStore struct {
+	client *redis.Client
+}
+
+// NewRateLimitStore create a new instance of redis implementation token
+// bucket store
+func NewRateLimitStore(redisClient *redis.Client) *RateLimitStore {
+	return &RateLimitStore{
+		client: redisClient,
+	}
+}
+
+// Take refill the bucket of key, then take a token from it when one is left
+func (r *RateLimitStore) Take(ctx context.Context, key string, limit model.RateLimit, now time.Time) (bool, float64, error) {
+	if limit.Limit <= 0 || limit.Period < time.Millisecond {
+		return false, 0, errors.New("rate limit is invalid")
+	}
+
+	// tokens refilled per millisecond
+	rate := float64(limit.Limit) / float64(limit.Period/time.Millisecond)
+	nowMillis := now.UnixNano() / int64(time.Millisecond)
+
+	result, err := takeScript.Run(r.client, []string{fmt.Sprintf(rateLimitKey, key)},
+		limit.Limit,
+		strconv.FormatFloat(rate, 'g', -1, 64),
+		nowMillis,
+	).Result()
+	if err != nil {
+		logger.FromContext(ctx).Error("rate limit token not taken", logger.Err(err))
+		return false, 0, service.Unavailable(err)
+	}
+
+	values, ok := result.([]interface{})
+	if !ok || len(values) != 2 {
+		return false, 0, errors.New("rate limit script result is invalid")
+	}
+
+	allowed, _ := values[0].(int64)
+	tokensValue, _ := values[1].(string)
+	tokens, err := strconv.ParseFloat(tokensValue, 64)
+	if err != nil {
+		logger.FromContext(ctx).Error("rate limit token not taken", logger.Err(err))
+		return false, 0, err
+	}
+
+	return allowed == 1, tokens, nil
+}
+
+// Peek report the tokens left in the bucket of key once refilled up to now
+func (r *RateLimitStore) Peek(ctx context.Context, key string, limit model.RateLimit, now time.Time) (float64, error) {
+	if limit.Limit <= 0 || limit.Period < time.Millisecond {
+		return 0, errors.New("rate limit is invalid")
+	}
+
+	values, err := r.client.HMGet(fmt.Sprintf(rateLimitKey, key), "tokens", "updated").Result()
+	if err != nil {
+		logger.FromContext(ctx).Error("rate limit tokens not retrieved", logger.Err(err))
+		return 0, service.Unavailable(err)
+	}
+
+	tokensValue, tokensOk := values[0].(string)
+	updatedValue, updatedOk := values[1].(string)
+	if !tokensOk || !updatedOk {
+		return float64(limit.Limit), nil
+	}
+
+	tokens, err := strconv.ParseFloat(tokensValue, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	updated, err := strconv.ParseFloat(updatedValue, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	// tokens refilled per millisecond
+	rate := float64(limit.Limit) / float64(limit.Period/time.Millisecond)
+	nowMillis := float64(now.UnixNano() / int64(time.Millisecond))
+
+	return math.Min(float64(limit.Limit), tokens+math.Max(0, nowMillis-updated)*rate), nil
+}
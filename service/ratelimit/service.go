@@ -0,0 +1,172 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prabudzak/article/model"
+)
+
+//go:generate mockgen -package=mock -source=service.go -destination=mock/service.go
+
+// Store represent token bucket storage. Take refill the bucket of key up to
+// now, then take a token from it when one is left, reporting whether a token
+// was taken and the tokens left. Peek report the tokens left in the bucket of
+// key up to now without taking one
+type Store interface {
+	Take(ctx context.Context, key string, limit model.RateLimit, now time.Time) (bool, float64, error)
+	Peek(ctx context.Context, key string, limit model.RateLimit, now time.Time) (float64, error)
+}
+
+// Service represent rate limit service implementation
+type Service struct {
+	store        Store
+	limits       map[string]model.RateLimit
+	defaultLimit model.RateLimit
+	now          func() time.Time
+}
+
+// Option represent rate limit service configuration option
+type Option func(s *Service)
+
+// WithLimit limit requests of each client to the route of given method and
+// path pattern
+func WithLimit(method string, path string, limit model.RateLimit) Option {
+	return func(s *Service) {
+		s.limits[method+" "+path] = limit
+	}
+}
+
+// WithDefaultLimit limit requests of each client to every route without its
+// own limit. Routes without limit are not limited by default
+func WithDefaultLimit(limit model.RateLimit) Option {
+	return func(s *Service) {
+		s.defaultLimit = limit
+	}
+}
+
+// NewRateLimitService create a new rate limit service instance
+func NewRateLimitService(store Store, options ...Option) *Service {
+	s := &Service{
+		store:  store,
+		limits: map[string]model.RateLimit{},
+		now:    time.Now,
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	return s
+}
+
+// Allow take a token from the bucket of client on the route of given method
+// and path pattern. Request of a route without limit is always allowed with
+// zero limit
+func (s *Service) Allow(ctx context.Context, client string, method string, path string) (model.RateLimitResult, error) {
+	route := method + " " + path
+	limit, ok := s.limit(route)
+	if !ok {
+		return model.RateLimitResult{Allowed: true}, nil
+	}
+
+	allowed, tokens, err := s.store.Take(ctx, route+" "+client, limit, s.now())
+	if err != nil {
+		return model.RateLimitResult{}, err
+	}
+
+	return result(limit, allowed, tokens), nil
+}
+
+// Check report whether the bucket of client on the route of given method and
+// path pattern has a token left, without taking it
+func (s *Service) Check(ctx context.Context, client string, method string, path string) (model.RateLimitResult, error) {
+	route := method + " " + path
+	limit, ok := s.limit(route)
+	if !ok {
+		return model.RateLimitResult{Allowed: true}, nil
+	}
+
+	tokens, err := s.store.Peek(ctx, route+" "+client, limit, s.now())
+	if err != nil {
+		return model.RateLimitResult{}, err
+	}
+
+	return result(limit, tokens >= 1, tokens), nil
+}
+
+// limit return the limit of route, or its default limit, reporting whether
+// the route is limited at all
+func (s *Service) limit(route string) (model.RateLimit, bool) {
+	limit, ok := s.limits[route]
+	if !ok {
+		limit = s.defaultLimit
+	}
+
+	return limit, limit.Limit > 0 && limit.Period > 0
+}
+
+// result describe a bucket of given limit with tokens left
+func result(limit model.RateLimit, allowed bool, tokens float64) model.RateLimitResult {
+	perToken := limit.Period / time.Duration(limit.Limit)
+	result := model.RateLimitResult{
+		Allowed:   allowed,
+		Limit:     limit.Limit,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     time.Duration((float64(limit.Limit) - tokens) * float64(perToken)),
+	}
+	if !allowed {
+		result.RetryAfter = time.Duration((1 - tokens) * float64(perToken))
+	}
+
+	return result
+}
+
+// ParseLimit parse a rate limit written as <limit>/<period>, such as 60/1m
+func ParseLimit(s string) (model.RateLimit, error) {
+	parts := strings.SplitN(strings.TrimSpace(s), "/", 2)
+	if len(parts) != 2 {
+		return model.RateLimit{}, fmt.Errorf("rate limit %q is not <limit>/<period>", s)
+	}
+
+	limit, err := strconv.Atoi(parts[0])
+	if err != nil || limit <= 0 {
+		return model.RateLimit{}, fmt.Errorf("rate limit %q limit is not a positive number", s)
+	}
+
+	period, err := time.ParseDuration(parts[1])
+	if err != nil || period <= 0 {
+		return model.RateLimit{}, fmt.Errorf("rate limit %q period is not a positive duration", s)
+	}
+
+	return model.RateLimit{Limit: limit, Period: period}, nil
+}
+
+// ParseRouteLimits parse semicolon separated route rate limits written as
+// <method> <path>=<limit>/<period>, such as "POST /articles=10/1m"
+func ParseRouteLimits(s string) ([]Option, error) {
+	options := []Option{}
+	for _, spec := range strings.Split(s, ";") {
+		if strings.TrimSpace(spec) == "" {
+			continue
+		}
+
+		parts := strings.SplitN(spec, "=", 2)
+		route := strings.Fields(parts[0])
+		if len(parts) != 2 || len(route) != 2 {
+			return nil, fmt.Errorf("route rate limit %q is not <method> <path>=<limit>/<period>", spec)
+		}
+
+		limit, err := ParseLimit(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, WithLimit(strings.ToUpper(route[0]), route[1], limit))
+	}
+
+	return options, nil
+}
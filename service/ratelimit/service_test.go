@@ -0,0 +1,241 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prabudzak/article/model"
+	"github.com/prabudzak/article/service/ratelimit"
+	"github.com/prabudzak/article/service/ratelimit/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+type dependency struct {
+	store *mock.MockStore
+}
+
+func initialize(ctrl *gomock.Controller) dependency {
+	return dependency{
+		store: mock.NewMockStore(ctrl),
+	}
+}
+
+func TestAllow(t *testing.T) {
+	writeLimit := model.RateLimit{Limit: 10, Period: time.Minute}
+	defaultLimit := model.RateLimit{Limit: 60, Period: time.Minute}
+
+	tests := []struct {
+		name           string
+		options        []ratelimit.Option
+		method         string
+		path           string
+		taken          bool
+		tokens         float64
+		storeErr       error
+		expectedKey    string
+		expectedLimit  model.RateLimit
+		expectedResult model.RateLimitResult
+		expectErr      bool
+	}{
+		{
+			name:          "route limit",
+			options:       []ratelimit.Option{ratelimit.WithLimit("POST", "/articles", writeLimit), ratelimit.WithDefaultLimit(defaultLimit)},
+			method:        "POST",
+			path:          "/articles",
+			taken:         true,
+			tokens:        4.5,
+			expectedKey:   "POST /articles ip:10.0.0.1",
+			expectedLimit: writeLimit,
+			expectedResult: model.RateLimitResult{
+				Allowed:   true,
+				Limit:     10,
+				Remaining: 4,
+				Reset:     33 * time.Second,
+			},
+		},
+		{
+			name:          "default limit",
+			options:       []ratelimit.Option{ratelimit.WithLimit("POST", "/articles", writeLimit), ratelimit.WithDefaultLimit(defaultLimit)},
+			method:        "GET",
+			path:          "/articles",
+			taken:         true,
+			tokens:        59,
+			expectedKey:   "GET /articles ip:10.0.0.1",
+			expectedLimit: defaultLimit,
+			expectedResult: model.RateLimitResult{
+				Allowed:   true,
+				Limit:     60,
+				Remaining: 59,
+				Reset:     time.Second,
+			},
+		},
+		{
+			name:          "bucket empty",
+			options:       []ratelimit.Option{ratelimit.WithLimit("POST", "/articles", writeLimit)},
+			method:        "POST",
+			path:          "/articles",
+			taken:         false,
+			tokens:        0.25,
+			expectedKey:   "POST /articles ip:10.0.0.1",
+			expectedLimit: writeLimit,
+			expectedResult: model.RateLimitResult{
+				Allowed:    false,
+				Limit:      10,
+				Remaining:  0,
+				Reset:      58500 * time.Millisecond,
+				RetryAfter: 4500 * time.Millisecond,
+			},
+		},
+		{
+			name:           "route without limit",
+			options:        []ratelimit.Option{ratelimit.WithLimit("POST", "/articles", writeLimit)},
+			method:         "GET",
+			path:           "/articles",
+			expectedResult: model.RateLimitResult{Allowed: true},
+		},
+		{
+			name:          "store failure",
+			options:       []ratelimit.Option{ratelimit.WithDefaultLimit(defaultLimit)},
+			method:        "GET",
+			path:          "/articles",
+			storeErr:      assert.AnError,
+			expectedKey:   "GET /articles ip:10.0.0.1",
+			expectedLimit: defaultLimit,
+			expectErr:     true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dep := initialize(ctrl)
+			dep.store.EXPECT().Take(gomock.Any(), tc.expectedKey, tc.expectedLimit, gomock.Any()).MaxTimes(1).Return(tc.taken, tc.tokens, tc.storeErr)
+
+			rateLimitService := ratelimit.NewRateLimitService(dep.store, tc.options...)
+
+			result, err := rateLimitService.Allow(context.Background(), "ip:10.0.0.1", tc.method, tc.path)
+			assert.Equal(t, tc.expectErr, err != nil)
+			if !tc.expectErr {
+				assert.Equal(t, tc.expectedResult, result)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	limit := model.RateLimit{Limit: 10, Period: time.Minute}
+
+	tests := []struct {
+		name           string
+		options        []ratelimit.Option
+		tokens         float64
+		storeErr       error
+		expectedResult model.RateLimitResult
+		expectErr      bool
+	}{
+		{
+			name:           "token left",
+			options:        []ratelimit.Option{ratelimit.WithLimit("AUTH", "/failures", limit)},
+			tokens:         4.5,
+			expectedResult: model.RateLimitResult{Allowed: true, Limit: 10, Remaining: 4, Reset: 33 * time.Second},
+		},
+		{
+			name:           "bucket empty",
+			options:        []ratelimit.Option{ratelimit.WithLimit("AUTH", "/failures", limit)},
+			tokens:         0.25,
+			expectedResult: model.RateLimitResult{Allowed: false, Limit: 10, Remaining: 0, Reset: 58500 * time.Millisecond, RetryAfter: 4500 * time.Millisecond},
+		},
+		{
+			name:           "route without limit",
+			expectedResult: model.RateLimitResult{Allowed: true},
+		},
+		{
+			name:      "store failure",
+			options:   []ratelimit.Option{ratelimit.WithLimit("AUTH", "/failures", limit)},
+			storeErr:  assert.AnError,
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dep := initialize(ctrl)
+			dep.store.EXPECT().Take(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			dep.store.EXPECT().Peek(gomock.Any(), "AUTH /failures ip:10.0.0.1", limit, gomock.Any()).MaxTimes(1).Return(tc.tokens, tc.storeErr)
+
+			rateLimitService := ratelimit.NewRateLimitService(dep.store, tc.options...)
+
+			result, err := rateLimitService.Check(context.Background(), "ip:10.0.0.1", "AUTH", "/failures")
+			assert.Equal(t, tc.expectErr, err != nil)
+			if !tc.expectErr {
+				assert.Equal(t, tc.expectedResult, result)
+			}
+		})
+	}
+}
+
+func TestParseRouteLimits(t *testing.T) {
+	tests := []struct {
+		name          string
+		spec          string
+		expectedLimit model.RateLimit
+		expectErr     bool
+	}{
+		{
+			name:          "route limits",
+			spec:          "post /articles=10/1m; POST /articles:batch=2/1h",
+			expectedLimit: model.RateLimit{Limit: 10, Period: time.Minute},
+		},
+		{
+			name: "no route limit",
+			spec: " ",
+		},
+		{
+			name:      "missing limit",
+			spec:      "POST /articles",
+			expectErr: true,
+		},
+		{
+			name:      "missing method",
+			spec:      "/articles=10/1m",
+			expectErr: true,
+		},
+		{
+			name:      "invalid limit",
+			spec:      "POST /articles=ten/1m",
+			expectErr: true,
+		},
+		{
+			name:      "invalid period",
+			spec:      "POST /articles=10/minute",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			options, err := ratelimit.ParseRouteLimits(tc.spec)
+			assert.Equal(t, tc.expectErr, err != nil)
+			if tc.expectErr || tc.expectedLimit.Limit == 0 {
+				return
+			}
+
+			dep := initialize(ctrl)
+			dep.store.EXPECT().Take(gomock.Any(), gomock.Any(), tc.expectedLimit, gomock.Any()).Return(true, 9.0, nil)
+
+			rateLimitService := ratelimit.NewRateLimitService(dep.store, options...)
+			_, err = rateLimitService.Allow(context.Background(), "ip:10.0.0.1", "POST", "/articles")
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/prabudzak/article/model"
 )
@@ -10,6 +11,43 @@ import (
 
 // ArticleService represent article service interface
 type ArticleService interface {
-	CreateArticle(ctx context.Context, article model.Article) error
+	CreateArticle(ctx context.Context, article model.Article) (model.Article, error)
+	ImportArticles(ctx context.Context, articles []model.Article) ([]model.ArticleImportResult, error)
 	SearchArticle(ctx context.Context, query model.ArticleSearchQuery) ([]model.Article, error)
+	ExportArticle(ctx context.Context, query model.ArticleSearchQuery, fn func(article model.Article) error) error
+	RelatedArticle(ctx context.Context, query model.ArticleRelatedQuery) ([]model.Article, error)
+	ListTag(ctx context.Context, query model.TagQuery) ([]model.TagCount, error)
+	LastModified(ctx context.Context) (time.Time, error)
+	GetArticle(ctx context.Context, id int) (model.Article, error)
+	UpdateArticle(ctx context.Context, article model.Article) (model.Article, error)
+	PublishArticle(ctx context.Context, id int) (model.Article, error)
+	ArchiveArticle(ctx context.Context, id int) (model.Article, error)
+	ListArticleRevision(ctx context.Context, id int) ([]model.ArticleRevision, error)
+	GetArticleRevision(ctx context.Context, id int, revision int) (model.ArticleRevision, error)
+	DiffArticleRevision(ctx context.Context, id int, from int, to int) (model.ArticleRevisionDiff, error)
+	RestoreArticleRevision(ctx context.Context, id int, revision int) (model.Article, error)
+}
+
+// AuthorService represent author service interface
+type AuthorService interface {
+	GetAuthor(ctx context.Context, handle string) (model.Author, error)
+}
+
+// AuthService represent caller authentication service interface
+type AuthService interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (model.Principal, error)
+	AuthenticateToken(ctx context.Context, token string) (model.Principal, error)
+}
+
+// RateLimitService represent per client rate limit service interface
+type RateLimitService interface {
+	Allow(ctx context.Context, client string, method string, path string) (model.RateLimitResult, error)
+	Check(ctx context.Context, client string, method string, path string) (model.RateLimitResult, error)
+}
+
+// IdempotencyService represent idempotent request service interface
+type IdempotencyService interface {
+	Begin(ctx context.Context, key string, requestHash string) (model.IdempotencyRecord, error)
+	Complete(ctx context.Context, record model.IdempotencyRecord) error
+	Release(ctx context.Context, key string) error
 }
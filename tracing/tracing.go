@@ -0,0 +1,103 @@
+// Package tracing trace requests across the article service with
+// OpenTelemetry spans, propagated as W3C trace context
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp"
+	"go.opentelemetry.io/otel/exporters/stdout"
+	"go.opentelemetry.io/otel/propagation"
+	export "go.opentelemetry.io/otel/sdk/export/trace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/semconv"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName name the tracer of every span of the service
+const instrumentationName = "github.com/prabudzak/article"
+
+// Span exporters
+const (
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+// Setup propagate W3C trace context and export spans of serviceName with
+// given exporter, sent to the OTLP collector at endpoint. Spans are not
+// recorded without exporter. The returned function flush remaining spans
+func Setup(ctx context.Context, exporter string, endpoint string, serviceName string) (func(ctx context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var spanExporter export.SpanExporter
+	var err error
+	switch exporter {
+	case "":
+		return func(ctx context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		options := []otlp.ExporterOption{otlp.WithInsecure()}
+		if endpoint != "" {
+			options = append(options, otlp.WithAddress(endpoint))
+		}
+		spanExporter, err = otlp.NewExporter(ctx, options...)
+	case ExporterStdout:
+		spanExporter, err = stdout.NewExporter(stdout.WithPrettyPrint(), stdout.WithoutMetricExport())
+	default:
+		return nil, fmt.Errorf("tracing exporter %q is not one of otlp or stdout", exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.ServiceNameKey.String(serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Start start a span of given name as a child of the span of ctx, if any
+func Start(ctx context.Context, name string, options ...trace.SpanOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, options...)
+}
+
+// End record err, if any, as the outcome of span and end it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Carrier represent W3C trace context headers carried out of a request, such
+// as along a dispatched event
+type Carrier map[string]string
+
+// Get return the value of header key
+func (c Carrier) Get(key string) string {
+	return c[key]
+}
+
+// Set set the value of header key
+func (c Carrier) Set(key string, value string) {
+	c[key] = value
+}
+
+// Inject return the trace context of ctx as headers
+func Inject(ctx context.Context) Carrier {
+	carrier := Carrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	return carrier
+}
+
+// Extract return a copy of ctx continuing the trace context of carrier
+func Extract(ctx context.Context, carrier Carrier) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, carrier)
+}
@@ -0,0 +1,63 @@
+package tracing_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/oteltest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/prabudzak/article/tracing"
+)
+
+func TestSetup(t *testing.T) {
+	tests := []struct {
+		name      string
+		exporter  string
+		expectErr bool
+	}{
+		{
+			name:      "no exporter",
+			exporter:  "",
+			expectErr: false,
+		},
+		{
+			name:      "stdout exporter",
+			exporter:  tracing.ExporterStdout,
+			expectErr: false,
+		},
+		{
+			name:      "unknown exporter",
+			exporter:  "zipkin",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			shutdown, err := tracing.Setup(context.Background(), tc.exporter, "", "article")
+			assert.Equal(t, tc.expectErr, err != nil)
+			if tc.expectErr {
+				return
+			}
+			assert.NoError(t, shutdown(context.Background()))
+		})
+	}
+}
+
+func TestCarrier(t *testing.T) {
+	tracing.Setup(context.Background(), "", "", "article")
+
+	_, span := oteltest.NewTracerProvider().Tracer("test").Start(context.Background(), "request")
+	defer span.End()
+
+	carrier := tracing.Inject(trace.ContextWithSpan(context.Background(), span))
+	assert.NotEmpty(t, carrier.Get("traceparent"))
+
+	spanContext := trace.RemoteSpanContextFromContext(tracing.Extract(context.Background(), carrier))
+	assert.Equal(t, span.SpanContext().TraceID, spanContext.TraceID)
+	assert.Equal(t, span.SpanContext().SpanID, spanContext.SpanID)
+
+	assert.False(t, trace.RemoteSpanContextFromContext(tracing.Extract(context.Background(), tracing.Carrier{})).IsValid())
+}
@@ -0,0 +1,96 @@
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+	"unicode/utf8"
+
+	"github.com/prabudzak/article/model"
+)
+
+// Article field limits
+const (
+	MaxAuthorLength = 64
+	MaxTitleLength  = 255
+	MaxBodyBytes    = 65535
+	MaxTags         = 10
+	MaxTagLength    = 32
+)
+
+var (
+	languageCodePattern = regexp.MustCompile(`^[a-z]{2}$`)
+	tagPattern          = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+)
+
+// Article validate a new article written by client
+func Article(article model.Article) error {
+	v := &Validator{}
+
+	if v.Required("author", article.Author) {
+		v.Text("author", article.Author, MaxAuthorLength)
+	}
+
+	validateContent(v, article)
+
+	switch article.Status {
+	case "", model.ArticleStatusDraft, model.ArticleStatusPublished:
+		if article.PublishAt != nil {
+			v.Add("publish_at", CodeInvalidValue, "publish_at is only allowed for scheduled article")
+		}
+	case model.ArticleStatusScheduled:
+		if article.PublishAt == nil || article.PublishAt.IsZero() {
+			v.Add("publish_at", CodeRequired, "publish_at is blank")
+		} else if !article.PublishAt.After(time.Now()) {
+			v.Add("publish_at", CodeInvalidValue, "publish_at is not in the future")
+		}
+	default:
+		v.Add("status", CodeInvalidValue, "status is not one of draft, scheduled or published")
+	}
+
+	return v.Err()
+}
+
+// ArticleContent validate title, body, language and tags of an article
+// updated by client
+func ArticleContent(article model.Article) error {
+	v := &Validator{}
+	validateContent(v, article)
+	return v.Err()
+}
+
+func validateContent(v *Validator, article model.Article) {
+	if v.Required("title", article.Title) {
+		v.Text("title", article.Title, MaxTitleLength)
+	}
+
+	if v.Required("body", article.Body) {
+		if !utf8.ValidString(article.Body) {
+			v.Add("body", CodeInvalidUTF8, "body is not valid UTF-8")
+		} else {
+			v.MaxBytes("body", article.Body, MaxBodyBytes)
+		}
+	}
+
+	switch article.BodyFormat {
+	case "", model.BodyFormatPlain, model.BodyFormatMarkdown, model.BodyFormatHTML:
+	default:
+		v.Add("body_format", CodeInvalidValue, "body_format is not one of plain, markdown or html")
+	}
+
+	if article.Language != "" && !languageCodePattern.MatchString(article.Language) {
+		v.Add("language", CodeInvalidFormat, "language is not a two letter language code")
+	}
+
+	if len(article.Tags) > MaxTags {
+		v.Add("tags", CodeTooMany, fmt.Sprintf("tags exceed %d tags", MaxTags))
+	}
+	for i, tag := range article.Tags {
+		field := fmt.Sprintf("tags[%d]", i)
+		if len(tag) > MaxTagLength {
+			v.Add(field, CodeTooLong, fmt.Sprintf("tag %s exceed %d characters", tag, MaxTagLength))
+		} else if !tagPattern.MatchString(tag) {
+			v.Add(field, CodeInvalidFormat, fmt.Sprintf("tag %s contain invalid characters", tag))
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package validation_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prabudzak/article/model"
+	"github.com/prabudzak/article/validation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArticle(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+
+	valid := model.Article{
+		Author: "John Doe",
+		Title:  "A Valid Title",
+		Body:   "A very interesting content",
+	}
+
+	tests := []struct {
+		name               string
+		modify             func(a *model.Article)
+		expectedViolations validation.Errors
+	}{
+		{
+			name:   "valid article",
+			modify: func(a *model.Article) {},
+		},
+		{
+			name: "valid scheduled article",
+			modify: func(a *model.Article) {
+				a.Status = model.ArticleStatusScheduled
+				a.PublishAt = &future
+			},
+		},
+		{
+			name: "all violations reported",
+			modify: func(a *model.Article) {
+				a.Author = ""
+				a.Title = "  "
+				a.Body = ""
+			},
+			expectedViolations: validation.Errors{
+				{Field: "author", Code: validation.CodeRequired, Message: "author is blank"},
+				{Field: "title", Code: validation.CodeRequired, Message: "title is blank"},
+				{Field: "body", Code: validation.CodeRequired, Message: "body is blank"},
+			},
+		},
+		{
+			name: "text limits",
+			modify: func(a *model.Article) {
+				a.Author = " John Doe"
+				a.Title = strings.Repeat("a", validation.MaxTitleLength+1)
+				a.Body = strings.Repeat("a", validation.MaxBodyBytes+1)
+			},
+			expectedViolations: validation.Errors{
+				{Field: "author", Code: validation.CodeUntrimmed, Message: "author has leading or trailing whitespaces"},
+				{Field: "title", Code: validation.CodeTooLong, Message: "title exceed 255 characters"},
+				{Field: "body", Code: validation.CodeTooLong, Message: "body exceed 65535 bytes"},
+			},
+		},
+		{
+			name: "title length counted in characters",
+			modify: func(a *model.Article) {
+				a.Title = strings.Repeat("é", validation.MaxTitleLength)
+			},
+		},
+		{
+			name: "invalid utf-8",
+			modify: func(a *model.Article) {
+				a.Title = "title \xff"
+				a.Body = "body \xff"
+			},
+			expectedViolations: validation.Errors{
+				{Field: "title", Code: validation.CodeInvalidUTF8, Message: "title is not valid UTF-8"},
+				{Field: "body", Code: validation.CodeInvalidUTF8, Message: "body is not valid UTF-8"},
+			},
+		},
+		{
+			name: "markdown body",
+			modify: func(a *model.Article) {
+				a.BodyFormat = model.BodyFormatMarkdown
+			},
+		},
+		{
+			name: "invalid body format",
+			modify: func(a *model.Article) {
+				a.BodyFormat = "rtf"
+			},
+			expectedViolations: validation.Errors{
+				{Field: "body_format", Code: validation.CodeInvalidValue, Message: "body_format is not one of plain, markdown or html"},
+			},
+		},
+		{
+			name: "invalid language and tags",
+			modify: func(a *model.Article) {
+				a.Language = "english"
+				a.Tags = []string{"go", "c++", strings.Repeat("a", validation.MaxTagLength+1)}
+			},
+			expectedViolations: validation.Errors{
+				{Field: "language", Code: validation.CodeInvalidFormat, Message: "language is not a two letter language code"},
+				{Field: "tags[1]", Code: validation.CodeInvalidFormat, Message: "tag c++ contain invalid characters"},
+				{Field: "tags[2]", Code: validation.CodeTooLong, Message: "tag " + strings.Repeat("a", validation.MaxTagLength+1) + " exceed 32 characters"},
+			},
+		},
+		{
+			name: "too many tags",
+			modify: func(a *model.Article) {
+				a.Tags = []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k"}
+			},
+			expectedViolations: validation.Errors{
+				{Field: "tags", Code: validation.CodeTooMany, Message: "tags exceed 10 tags"},
+			},
+		},
+		{
+			name: "scheduled in the past",
+			modify: func(a *model.Article) {
+				a.Status = model.ArticleStatusScheduled
+				a.PublishAt = &past
+			},
+			expectedViolations: validation.Errors{
+				{Field: "publish_at", Code: validation.CodeInvalidValue, Message: "publish_at is not in the future"},
+			},
+		},
+		{
+			name: "scheduled without publish time",
+			modify: func(a *model.Article) {
+				a.Status = model.ArticleStatusScheduled
+			},
+			expectedViolations: validation.Errors{
+				{Field: "publish_at", Code: validation.CodeRequired, Message: "publish_at is blank"},
+			},
+		},
+		{
+			name: "publish time of draft",
+			modify: func(a *model.Article) {
+				a.Status = model.ArticleStatusDraft
+				a.PublishAt = &future
+			},
+			expectedViolations: validation.Errors{
+				{Field: "publish_at", Code: validation.CodeInvalidValue, Message: "publish_at is only allowed for scheduled article"},
+			},
+		},
+		{
+			name: "invalid status",
+			modify: func(a *model.Article) {
+				a.Status = model.ArticleStatusArchived
+			},
+			expectedViolations: validation.Errors{
+				{Field: "status", Code: validation.CodeInvalidValue, Message: "status is not one of draft, scheduled or published"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			article := valid
+			tc.modify(&article)
+
+			err := validation.Article(article)
+			if tc.expectedViolations == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.Equal(t, tc.expectedViolations, err)
+		})
+	}
+}
+
+func TestArticleContent(t *testing.T) {
+	err := validation.ArticleContent(model.Article{Title: "A Valid Title", Body: "A very interesting content"})
+	assert.NoError(t, err)
+
+	err = validation.ArticleContent(model.Article{})
+	assert.Equal(t, validation.Errors{
+		{Field: "title", Code: validation.CodeRequired, Message: "title is blank"},
+		{Field: "body", Code: validation.CodeRequired, Message: "body is blank"},
+	}, err)
+	assert.Equal(t, "title is blank, body is blank", err.Error())
+}
@@ -0,0 +1,92 @@
+// Package validation validate client written values and report every
+// violation found instead of only the first one
+package validation
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Violation code
+const (
+	CodeRequired      = "required"
+	CodeTooLong       = "too_long"
+	CodeTooMany       = "too_many"
+	CodeInvalidUTF8   = "invalid_utf8"
+	CodeInvalidFormat = "invalid_format"
+	CodeInvalidValue  = "invalid_value"
+	CodeUntrimmed     = "untrimmed"
+)
+
+// Violation represent a field failing a validation rule
+type Violation struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Errors represent all violations found in a validated value
+type Errors []Violation
+
+func (e Errors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, violation := range e {
+		messages = append(messages, violation.Message)
+	}
+	return strings.Join(messages, ", ")
+}
+
+// Validator accumulate violations of validated fields
+type Validator struct {
+	violations Errors
+}
+
+// Add record a violation of a field
+func (v *Validator) Add(field string, code string, message string) {
+	v.violations = append(v.violations, Violation{Field: field, Code: code, Message: message})
+}
+
+// Required check a text field is not blank
+func (v *Validator) Required(field string, value string) bool {
+	if strings.TrimSpace(value) == "" {
+		v.Add(field, CodeRequired, fmt.Sprintf("%s is blank", field))
+		return false
+	}
+	return true
+}
+
+// Text check a text field is valid UTF-8, has no surrounding whitespaces and
+// is at most max characters long
+func (v *Validator) Text(field string, value string, max int) bool {
+	if !utf8.ValidString(value) {
+		v.Add(field, CodeInvalidUTF8, fmt.Sprintf("%s is not valid UTF-8", field))
+		return false
+	}
+	if strings.TrimSpace(value) != value {
+		v.Add(field, CodeUntrimmed, fmt.Sprintf("%s has leading or trailing whitespaces", field))
+		return false
+	}
+	if utf8.RuneCountInString(value) > max {
+		v.Add(field, CodeTooLong, fmt.Sprintf("%s exceed %d characters", field, max))
+		return false
+	}
+	return true
+}
+
+// MaxBytes check a text field is at most max bytes long
+func (v *Validator) MaxBytes(field string, value string, max int) bool {
+	if len(value) > max {
+		v.Add(field, CodeTooLong, fmt.Sprintf("%s exceed %d bytes", field, max))
+		return false
+	}
+	return true
+}
+
+// Err return all recorded violations as an error, or nil when there is none
+func (v *Validator) Err() error {
+	if len(v.violations) == 0 {
+		return nil
+	}
+	return v.violations
+}